@@ -12,6 +12,8 @@ import (
 	"api-gateway/internal/auth/service"
 	"api-gateway/internal/common/config"
 	"api-gateway/internal/common/middleware"
+	"api-gateway/internal/converter/mapper"
+	"api-gateway/internal/converter/report"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
@@ -47,6 +49,33 @@ func main() {
 	converterURL := getenv("CONVERTER_URL", "http://localhost:3001")
 	authHandler := handlers.NewAuthHandler(repo, sessionManager, fileStorage, converterURL)
 
+	// PDF отчеты: встроенный генератор (native) или PDF Service (service)
+	switch backend := getenv("PDF_BACKEND", "native"); backend {
+	case "native":
+		reports := report.NewGenerator()
+		if path := os.Getenv("REPORT_TEMPLATE_PATH"); path != "" {
+			loaded, err := report.LoadTemplate(path)
+			if err != nil {
+				log.Fatalf("load report template: %v", err)
+			}
+			reports.SetTemplate(loaded)
+			log.Printf("Loaded report template from %s", path)
+		}
+		if path := os.Getenv("REPORT_THEMES_PATH"); path != "" {
+			loaded, err := mapper.LoadThemes(path)
+			if err != nil {
+				log.Fatalf("load report themes: %v", err)
+			}
+			reports.SetThemes(loaded)
+			log.Printf("Loaded %d report themes from %s", len(loaded.Themes), path)
+		}
+		authHandler.SetReportGenerator(reports)
+	case "service":
+		log.Printf("PDF reports are generated by PDF Service")
+	default:
+		log.Fatalf("unknown PDF_BACKEND %q (expected native or service)", backend)
+	}
+
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
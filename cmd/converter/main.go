@@ -3,11 +3,15 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"api-gateway/internal/common/config"
 	"api-gateway/internal/common/middleware"
+	"api-gateway/internal/converter/compliance"
 	"api-gateway/internal/converter/handlers"
+	"api-gateway/internal/converter/mapper"
+	"api-gateway/internal/converter/parser"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/recover"
@@ -20,6 +24,50 @@ import (
 func main() {
 	cfg := config.Load()
 
+	// Правила классификации элементов: из файла или встроенные (префиксы id)
+	rules := parser.DefaultRules()
+	if path := os.Getenv("CONVERTER_RULES_PATH"); path != "" {
+		loaded, err := parser.LoadRules(path)
+		if err != nil {
+			log.Fatalf("load classification rules: %v", err)
+		}
+		rules = loaded
+		log.Printf("Loaded %d classification rules from %s", len(rules.Rules), path)
+	}
+
+	// Таблица items каталога react-planner (сантехника, мебель): из файла или встроенная
+	catalog := mapper.DefaultCatalog()
+	if path := os.Getenv("CONVERTER_CATALOG_PATH"); path != "" {
+		loaded, err := mapper.LoadCatalog(path)
+		if err != nil {
+			log.Fatalf("load item catalog: %v", err)
+		}
+		catalog = loaded
+		log.Printf("Loaded %d catalog items from %s", len(catalog.Items), path)
+	}
+
+	// Нормы перепланировки по регионам: из файла или встроенный профиль (СП 54.13330)
+	profiles := compliance.DefaultProfiles()
+	if path := os.Getenv("CONVERTER_COMPLIANCE_PATH"); path != "" {
+		loaded, err := compliance.LoadProfiles(path)
+		if err != nil {
+			log.Fatalf("load compliance profiles: %v", err)
+		}
+		profiles = loaded
+		log.Printf("Loaded %d compliance profiles from %s", len(profiles.Profiles), path)
+	}
+
+	// Темы оформления /render: из файла или встроенные (debug, blueprint, print, marketing)
+	themes := mapper.DefaultThemes()
+	if path := os.Getenv("CONVERTER_THEMES_PATH"); path != "" {
+		loaded, err := mapper.LoadThemes(path)
+		if err != nil {
+			log.Fatalf("load render themes: %v", err)
+		}
+		themes = loaded
+		log.Printf("Loaded %d render themes from %s", len(themes.Themes), path)
+	}
+
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
@@ -49,8 +97,12 @@ func main() {
 	// Converter Routes
 	// ============================================================
 
-	app.Post("/convert", handlers.ConvertSVG)
-	app.Post("/render", handlers.RenderSVG)
+	app.Post("/convert", handlers.ConvertSVG(rules, catalog))
+	app.Post("/classify", handlers.ClassifySVG(rules, catalog))
+	app.Post("/render", handlers.RenderSVG(themes))
+	app.Post("/analyze", handlers.AnalyzeScene)
+	app.Post("/diff", handlers.DiffScenes(profiles))
+	app.Post("/validate", handlers.ValidateScene)
 
 	// ============================================================
 	// Server Start
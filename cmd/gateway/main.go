@@ -72,7 +72,19 @@ func main() {
 	// Converter Service
 	converterURL := getEnv("CONVERTER_URL", "http://localhost:3001")
 	api.Post("/convert", proxy.ProxyTo(converterURL+"/convert"))
-	api.Post("/render", proxy.ProxyTo(converterURL+"/render"))
+	api.Post("/render", func(c fiber.Ctx) error {
+		return proxy.Forward(c, fmt.Sprintf("%s/render?%s", converterURL, c.Request().URI().QueryString()))
+	})
+	api.Post("/classify", proxy.ProxyTo(converterURL+"/classify"))
+	api.Post("/analyze", func(c fiber.Ctx) error {
+		return proxy.Forward(c, fmt.Sprintf("%s/analyze?%s", converterURL, c.Request().URI().QueryString()))
+	})
+	api.Post("/diff", func(c fiber.Ctx) error {
+		return proxy.Forward(c, fmt.Sprintf("%s/diff?%s", converterURL, c.Request().URI().QueryString()))
+	})
+	api.Post("/validate", func(c fiber.Ctx) error {
+		return proxy.Forward(c, fmt.Sprintf("%s/validate?%s", converterURL, c.Request().URI().QueryString()))
+	})
 
 	// Auth Service
 	authURL := getEnv("AUTH_URL", "http://localhost:3002")
@@ -153,12 +153,40 @@ def fetch_user_data(user_id: str) -> dict:
         }
 
 
+# ============================================================
+# Compliance
+# ============================================================
+
+def compliance_to_html(diff: dict | None) -> str:
+    """Список нарушений норм из ответа Converter /diff"""
+    from html import escape
+
+    if not diff or not diff.get('compliance'):
+        return '<p class="no-changes">Проверка норм не выполнялась.</p>'
+
+    compliance = diff['compliance']
+    findings = compliance.get('findings') or []
+    if not findings:
+        return '<p class="no-changes">Нарушений норм не обнаружено.</p>'
+
+    items = []
+    for finding in findings:
+        css = 'existing' if finding.get('existing') else finding.get('severity', 'error')
+        note = ' (было до перепланировки)' if finding.get('existing') else ''
+        items.append(f'<li class="{css}">{escape(finding.get("message", ""))}{note}</li>')
+
+    verdict = 'Перепланировка соответствует нормам' if compliance.get('passed') else 'Перепланировка нарушает нормы'
+    profile = escape(compliance.get('profile') or compliance.get('region', ''))
+    return (f'<p><strong>{verdict}</strong> ({profile})</p>'
+            f'<ul class="changes-list compliance-list">{"".join(items)}</ul>')
+
+
 # ============================================================
 # PDF Generation
 # ============================================================
 
-def generate_pdf_report(file_id: str, user_id: str) -> BytesIO:
-    """Генерирует PDF отчёт о изменениях планировки"""
+def generate_pdf_report(file_id: str, user_id: str, diff: dict | None = None) -> BytesIO:
+    """Генерирует PDF отчёт о изменениях планировки. diff — ответ Converter /diff (необязательно)"""
 
     # Пути к SVG файлам
     original_svg = SOURCE_DIR / f'{user_id}/svg/{file_id}.svg'
@@ -186,6 +214,7 @@ def generate_pdf_report(file_id: str, user_id: str) -> BytesIO:
         'original_png': original_png_b64,
         'edited_png': edited_png_b64,
         'changes': changes,
+        'compliance_html': compliance_to_html(diff),
         'fio': user_data.get('fio', 'Не указано'),
         'phone': user_data.get('phone', 'Не указано'),
         'address': user_data.get('address', 'Не указано'),
@@ -244,7 +273,7 @@ def health():
 def generate():
     """
     Генерация PDF отчёта
-    Body: { "file_id": "1", "user_id": "demo-user" }
+    Body: { "file_id": "1", "user_id": "demo-user", "diff": {...} }
     """
     data = request.get_json()
 
@@ -258,7 +287,7 @@ def generate():
         return jsonify({'error': 'file_id is required'}), 400
 
     try:
-        pdf_buffer = generate_pdf_report(file_id, user_id)
+        pdf_buffer = generate_pdf_report(file_id, user_id, data.get('diff'))
         return send_file(
             pdf_buffer,
             mimetype='application/pdf',
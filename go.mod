@@ -6,6 +6,7 @@ require (
 	github.com/gofiber/fiber/v3 v3.0.0-rc.3
 	github.com/google/uuid v1.6.0
 	github.com/ncruces/go-sqlite3 v0.30.2
+	golang.org/x/image v0.25.0
 )
 
 require (
@@ -9,6 +9,7 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,6 +18,10 @@ import (
 	"api-gateway/internal/auth/models"
 	"api-gateway/internal/auth/repository"
 	"api-gateway/internal/auth/service"
+	"api-gateway/internal/converter/compliance"
+	"api-gateway/internal/converter/diff"
+	planner "api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/report"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -30,6 +35,7 @@ type AuthHandler struct {
 	sessions     *service.SessionManager
 	storage      *service.FileStorage
 	converterURL string
+	reports      *report.Generator
 }
 
 func NewAuthHandler(repo *repository.Repository, sessions *service.SessionManager, storage *service.FileStorage, converterURL string) *AuthHandler {
@@ -41,6 +47,11 @@ func NewAuthHandler(repo *repository.Repository, sessions *service.SessionManage
 	}
 }
 
+// SetReportGenerator включает встроенную генерацию PDF отчетов; nil — отчет строит PDF Service.
+func (h *AuthHandler) SetReportGenerator(g *report.Generator) {
+	h.reports = g
+}
+
 type loginRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
@@ -227,11 +238,14 @@ func (h *AuthHandler) UploadEditedJSON(c fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "empty body"})
 	}
 	
-	// Валидируем JSON
-	var tmp interface{}
-	if err := json.Unmarshal(jsonData, &tmp); err != nil {
+	// Проверяем структуру сцены (с ?repair=true восстанавливаем обратные ссылки)
+	jsonData, validation, err := validateEditedScene(jsonData, c.Query("repair") == "true")
+	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
 	}
+	if !validation.Valid {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": "invalid scene", "validation": validation})
+	}
 	
 	// Получаем имя файла из query параметра или генерируем
 	filename := c.Query("name")
@@ -255,8 +269,9 @@ func (h *AuthHandler) UploadEditedJSON(c fiber.Ctx) error {
 	}
 	
 	return c.Status(http.StatusCreated).JSON(fiber.Map{
-		"path":     targetPath,
-		"filename": filename,
+		"path":       targetPath,
+		"filename":   filename,
+		"validation": validation,
 	})
 }
 
@@ -281,11 +296,14 @@ func (h *AuthHandler) SaveEditedJSONAndGeneratePDF(c fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "empty body"})
 	}
 	
-	// Валидируем JSON
-	var tmp interface{}
-	if err := json.Unmarshal(editedJSONData, &tmp); err != nil {
+	// Проверяем структуру сцены: битая сцена сломает рендер
+	editedJSONData, validation, err := validateEditedScene(editedJSONData, c.Query("repair") == "true")
+	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
 	}
+	if !validation.Valid {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": "invalid scene", "validation": validation})
+	}
 	
 	// Создаем директорию для edited JSON
 	if err := h.storage.EnsureEditedJSONDir(userID); err != nil {
@@ -345,8 +363,19 @@ func (h *AuthHandler) SaveEditedJSONAndGeneratePDF(c fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save edited svg"})
 	}
 	
-	// Генерируем PDF через PDF Service
-	pdfData, err := h.generatePDF(fileID, userID)
+	// Изменения и проверка норм перепланировки для отчета (без них отчет строится по SVG)
+	changes, err := h.diffScenes(originalJSONData, editedJSONData, c.Query("region"))
+	if err != nil {
+		log.Printf("[AUTH] diff scenes error: %v", err)
+	}
+	
+	// Генерируем PDF: встроенный генератор или PDF Service
+	var pdfData []byte
+	if h.reports != nil {
+		pdfData, err = h.buildReport(fileID, userID, originalJSONData, editedJSONData, changes)
+	} else {
+		pdfData, err = h.generatePDF(fileID, userID, changes)
+	}
 	if err != nil {
 		log.Printf("[AUTH] generate pdf error: %v", err)
 		return c.Status(http.StatusBadGateway).JSON(fiber.Map{"error": "pdf generation failed", "details": err.Error()})
@@ -769,6 +798,45 @@ func (h *AuthHandler) renderScene(scene []byte) ([]byte, error) {
 	return data, nil
 }
 
+// diffScenes вызывает Converter /diff: изменения сцены и проверка норм региона.
+func (h *AuthHandler) diffScenes(original, edited []byte, region string) (json.RawMessage, error) {
+	if h.converterURL == "" {
+		return nil, fmt.Errorf("converter url is empty")
+	}
+
+	body, err := json.Marshal(map[string]json.RawMessage{"original": original, "edited": edited})
+	if err != nil {
+		return nil, err
+	}
+
+	target := h.converterURL + "/diff"
+	if region != "" {
+		target += "?region=" + url.QueryEscape(region)
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("converter status %d: %s", resp.StatusCode, string(data))
+	}
+
+	return data, nil
+}
+
 // resolveFilePath выбирает файл по имени или, если имя пустое и файлов >1, возвращает 400/404.
 func (h *AuthHandler) resolveFilePath(userID, dir, name, ext string) (string, error) {
 	alts := listFilesWithExt(dir, ext)
@@ -796,6 +864,31 @@ func (h *AuthHandler) resolveFilePath(userID, dir, name, ext string) (string, er
 	return path, nil
 }
 
+// validateEditedScene проверяет react-planner JSON. При repair восстанавливает обратные ссылки
+// и, если что-то исправлено, возвращает пересобранный JSON вместо исходного.
+func validateEditedScene(data []byte, repair bool) ([]byte, *planner.ValidationResult, error) {
+	var scene planner.Scene
+	if err := json.Unmarshal(data, &scene); err != nil {
+		return nil, nil, err
+	}
+
+	var repairs []planner.Issue
+	if repair {
+		repairs = planner.RepairScene(&scene)
+	}
+	result := planner.ValidateScene(&scene)
+	result.Repairs = repairs
+	if len(repairs) == 0 {
+		return data, result, nil
+	}
+
+	repaired, err := json.Marshal(scene)
+	if err != nil {
+		return nil, nil, err
+	}
+	return repaired, result, nil
+}
+
 func (h *AuthHandler) saveJSONFile(userID, filename string, data []byte) (string, error) {
 	if err := h.storage.EnsureJSONDir(userID); err != nil {
 		return "", err
@@ -804,17 +897,66 @@ func (h *AuthHandler) saveJSONFile(userID, filename string, data []byte) (string
 	return path, h.storage.SaveFile(userID, path, data)
 }
 
-// generatePDF вызывает PDF Service для генерации отчёта.
-func (h *AuthHandler) generatePDF(fileID, userID string) ([]byte, error) {
+// buildReport собирает PDF отчет встроенным генератором и сохраняет его в pdf/ пользователя.
+// changes — ответ Converter /diff (изменения и проверка норм), может быть пустым.
+func (h *AuthHandler) buildReport(fileID, userID string, original, edited []byte, changes json.RawMessage) ([]byte, error) {
+	input := report.Input{FileID: fileID}
+	if err := json.Unmarshal(original, &input.Original); err != nil {
+		return nil, fmt.Errorf("decode original scene: %w", err)
+	}
+	if err := json.Unmarshal(edited, &input.Edited); err != nil {
+		return nil, fmt.Errorf("decode edited scene: %w", err)
+	}
+
+	user, err := h.repo.GetByID(context.Background(), userID)
+	if err != nil {
+		log.Printf("[AUTH] report user lookup error: %v", err)
+	} else {
+		input.Applicant = report.Applicant{FIO: user.FIO, Phone: user.Phone, Email: user.Email, Address: user.Address}
+	}
+
+	if len(changes) > 0 {
+		var resp struct {
+			*diff.Report
+			Compliance *compliance.Report `json:"compliance"`
+		}
+		if err := json.Unmarshal(changes, &resp); err != nil {
+			log.Printf("[AUTH] decode diff error: %v", err)
+		} else {
+			input.Changes, input.Compliance = resp.Report, resp.Compliance
+		}
+	}
+
+	data, err := h.reports.Generate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.storage.EnsurePDFDir(userID); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("report_%s_%s.pdf", fileID, time.Now().Format("20060102_150405"))
+	if err := h.storage.SaveFile(userID, h.storage.PDFPath(userID, name), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// generatePDF вызывает PDF Service для генерации отчёта. changes — ответ Converter /diff
+// (изменения и проверка норм), может быть пустым.
+func (h *AuthHandler) generatePDF(fileID, userID string, changes json.RawMessage) ([]byte, error) {
 	pdfServiceURL := os.Getenv("PDF_SERVICE_URL")
 	if pdfServiceURL == "" {
 		pdfServiceURL = "http://localhost:3004"
 	}
 	
-	reqBody := map[string]string{
+	reqBody := map[string]any{
 		"file_id": fileID,
 		"user_id": userID,
 	}
+	if len(changes) > 0 {
+		reqBody["diff"] = changes
+	}
 	
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
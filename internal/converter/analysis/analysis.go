@@ -0,0 +1,449 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Scene analysis
+// ============================================================
+
+const holeTolerance = 10.0    // Допуск привязки проема к контуру комнаты сверх полутолщины стены
+const adjacencyStep = 10.0    // Шаг выборки точек контура при поиске смежных комнат
+const minSharedLength = 50.0  // Минимальная длина общей стены смежных комнат
+const adjacencyTolerance = 10 // Допуск расстояния между контурами сверх толщины стены
+
+// Виды помещений: жилые, вспомогательные и летние (с понижающим коэффициентом).
+const (
+	KindLiving  = "living"
+	KindService = "service"
+	KindBalcony = "balcony"
+	KindLoggia  = "loggia"
+	KindTerrace = "terrace"
+	KindVeranda = "veranda"
+)
+
+// DefaultCoefficients — понижающие коэффициенты площади летних помещений
+// (балконы и террасы 0.3, лоджии 0.5, веранды 1.0).
+func DefaultCoefficients() map[string]float64 {
+	return map[string]float64{
+		KindBalcony: 0.3,
+		KindLoggia:  0.5,
+		KindTerrace: 0.3,
+		KindVeranda: 1.0,
+	}
+}
+
+// Options — параметры расчета.
+type Options struct {
+	Layer        string             // слой для расчета; пусто — выбранный слой сцены
+	Coefficients map[string]float64 // понижающие коэффициенты по виду помещения; nil — DefaultCoefficients
+}
+
+// Report — экспликация помещений сцены. Площади в м², длины в м, толщины в единицах сцены.
+type Report struct {
+	Layer              string          `json:"layer"`
+	Unit               string          `json:"unit"`
+	Rooms              []RoomReport    `json:"rooms"`
+	Balconies          []BalconyReport `json:"balconies"`
+	Walls              []WallGroup     `json:"walls"`
+	TotalArea          float64         `json:"total_area"`
+	LivingArea         float64         `json:"living_area"`
+	BalconyArea        float64         `json:"balcony_area"`
+	ReducedBalconyArea float64         `json:"reduced_balcony_area"`
+	TotalWithBalconies float64         `json:"total_with_balconies"`
+	WallLength         float64         `json:"wall_length"`
+	WindowArea         float64         `json:"window_area"`
+	WindowToFloor      float64         `json:"window_to_floor"`
+	Warnings           []string        `json:"warnings,omitempty"`
+}
+
+// RoomReport — строка экспликации.
+type RoomReport struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Kind          string   `json:"kind"`      // пусто — комната найдена по стенам без подписи
+	Area          float64  `json:"area"`      // площадь в чистоте (за вычетом половины толщины стен по оси)
+	Perimeter     float64  `json:"perimeter"` // периметр в чистоте
+	Doors         int      `json:"doors"`
+	Windows       int      `json:"windows"`
+	WindowArea    float64  `json:"window_area"`
+	WindowToFloor float64  `json:"window_to_floor"`
+	Adjacent      []string `json:"adjacent"`
+}
+
+// BalconyReport — летнее помещение с понижающим коэффициентом.
+type BalconyReport struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	Area        float64 `json:"area"`
+	Coefficient float64 `json:"coefficient"`
+	ReducedArea float64 `json:"reduced_area"`
+}
+
+// WallGroup — суммарная длина стен одной толщины.
+type WallGroup struct {
+	Thickness float64 `json:"thickness"`
+	Length    float64 `json:"length"`
+	Count     int     `json:"count"`
+}
+
+// room — контур комнаты в единицах сцены.
+type room struct {
+	area    models.Area
+	name    string
+	kind    string
+	outline []models.Point // контур, как он задан в сцене
+	net     []models.Point // контур в чистоте
+}
+
+// Analyze считает экспликацию помещений слоя сцены.
+func Analyze(scene *models.Scene, opts Options) (*Report, error) {
+	if scene == nil {
+		return nil, fmt.Errorf("scene is nil")
+	}
+	layerID, err := scene.PickLayer(opts.Layer)
+	if err != nil {
+		return nil, err
+	}
+	layer := scene.Layers[layerID]
+
+	cm, ok := parser.CentimetersPerUnit(scene.Unit)
+	if !ok {
+		return nil, fmt.Errorf("unknown scene unit %q", scene.Unit)
+	}
+	meters := cm / 100
+	coefficients := opts.Coefficients
+	if coefficients == nil {
+		coefficients = DefaultCoefficients()
+	}
+
+	report := &Report{Layer: layerID, Unit: scene.Unit, Rooms: []RoomReport{}, Balconies: []BalconyReport{}}
+
+	walls := wallThickness(layer)
+	var rooms []room
+	for _, id := range models.SortedKeys(layer.Areas) {
+		area := layer.Areas[id]
+		ids, outline, ok := areaOutline(area, layer.Vertices)
+		if !ok {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("area %s has fewer than 3 vertices", id))
+			continue
+		}
+		name := area.DisplayName()
+		r := room{area: area, name: name, outline: outline}
+		if !area.PlaceholderName() {
+			// у комнаты без подписи вид неизвестен: она не попадает ни в жилую, ни в летнюю площадь
+			r.kind = RoomKind(name)
+		}
+		r.net = netOutline(ids, outline, walls)
+		rooms = append(rooms, r)
+	}
+
+	// проемы: точка на оси стены и полутолщина стены
+	type opening struct {
+		hole  models.Hole
+		point models.Point
+		reach float64
+	}
+	var openings []opening
+	for _, id := range models.SortedKeys(layer.Holes) {
+		hole := layer.Holes[id]
+		p, thickness, ok := holePoint(hole, layer)
+		if !ok {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("hole %s references missing line %s", id, hole.Line))
+			continue
+		}
+		openings = append(openings, opening{hole: hole, point: p, reach: thickness/2 + holeTolerance})
+	}
+
+	maxThickness := 0.0
+	for _, t := range walls {
+		maxThickness = math.Max(maxThickness, t)
+	}
+	adjacent := adjacency(rooms, maxThickness+adjacencyTolerance)
+
+	var summer [][]models.Point // контуры летних помещений, заданных комнатами
+	for i, r := range rooms {
+		area := polygonArea(r.net) * meters * meters
+		if coefficient, ok := coefficients[r.kind]; ok {
+			summer = append(summer, r.outline)
+			report.Balconies = append(report.Balconies, BalconyReport{
+				ID:          r.area.ID,
+				Name:        r.name,
+				Kind:        r.kind,
+				Area:        models.Round(area, 2),
+				Coefficient: coefficient,
+				ReducedArea: models.Round(area*coefficient, 2),
+			})
+			continue
+		}
+
+		row := RoomReport{
+			ID:        r.area.ID,
+			Name:      r.name,
+			Kind:      r.kind,
+			Area:      models.Round(area, 2),
+			Perimeter: models.Round(graph.Perimeter(r.net)*meters, 2),
+			Adjacent:  adjacent[i],
+		}
+		var windowArea float64
+		for _, o := range openings {
+			if boundaryDistance(o.point, r.outline) > o.reach {
+				continue
+			}
+			switch o.hole.Kind() {
+			case "door":
+				row.Doors++
+			case "window":
+				row.Windows++
+				windowArea += models.LengthProperty(o.hole.Properties, "width", 0) * models.LengthProperty(o.hole.Properties, "height", 0) * meters * meters
+			}
+		}
+		row.WindowArea = models.Round(windowArea, 2)
+		if area > 0 {
+			row.WindowToFloor = models.Round(windowArea/area, 3)
+		}
+
+		report.Rooms = append(report.Rooms, row)
+		report.TotalArea += area
+		report.WindowArea += windowArea
+		if r.kind == KindLiving {
+			report.LivingArea += area
+		}
+	}
+
+	for _, id := range models.SortedKeys(layer.Items) {
+		item := layer.Items[id]
+		kind := RoomKind(item.Type)
+		coefficient, ok := coefficients[kind]
+		if !ok || insideAny(models.Point{X: item.X, Y: item.Y}, summer) {
+			continue // балкон уже учтен комнатой с тем же контуром
+		}
+		area := models.LengthProperty(item.Properties, "width", 0) * models.LengthProperty(item.Properties, "depth", 0) * meters * meters
+		report.Balconies = append(report.Balconies, BalconyReport{
+			ID:          item.ID,
+			Name:        item.Name,
+			Kind:        kind,
+			Area:        models.Round(area, 2),
+			Coefficient: coefficient,
+			ReducedArea: models.Round(area*coefficient, 2),
+		})
+	}
+	for _, b := range report.Balconies {
+		report.BalconyArea += b.Area
+		report.ReducedBalconyArea += b.ReducedArea
+	}
+
+	if report.TotalArea > 0 {
+		report.WindowToFloor = models.Round(report.WindowArea/report.TotalArea, 3)
+	}
+	report.TotalWithBalconies = models.Round(report.TotalArea+report.ReducedBalconyArea, 2)
+	report.TotalArea = models.Round(report.TotalArea, 2)
+	report.LivingArea = models.Round(report.LivingArea, 2)
+	report.BalconyArea = models.Round(report.BalconyArea, 2)
+	report.ReducedBalconyArea = models.Round(report.ReducedBalconyArea, 2)
+	report.WindowArea = models.Round(report.WindowArea, 2)
+
+	report.Walls, report.WallLength = wallGroups(layer, meters)
+	return report, nil
+}
+
+// RoomKind определяет вид помещения по названию (русские и английские варианты).
+func RoomKind(name string) string {
+	name = strings.ToLower(name)
+	has := func(keys ...string) bool {
+		for _, key := range keys {
+			if strings.Contains(name, key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has("лоджи", "loggia"):
+		return KindLoggia
+	case has("балкон", "balcony"):
+		return KindBalcony
+	case has("террас", "terrace"):
+		return KindTerrace
+	case has("веранд", "veranda"):
+		return KindVeranda
+	case has("кухн", "kitchen", "ванн", "bath", "туалет", "toilet", "toliet", "санузел", "с/у", "wc",
+		"холл", "hall", "прихож", "коридор", "corridor", "кладов", "storage", "гардероб", "wardrobe", "постироч", "laundry"):
+		return KindService
+	}
+	return KindLiving
+}
+
+// ============================================================
+// Layer helpers
+// ============================================================
+
+// areaOutline возвращает контур area без повтора первой вершины и совпадающих соседних точек.
+func areaOutline(area models.Area, vertices map[string]models.Vertex) ([]string, []models.Point, bool) {
+	var ids []string
+	var points []models.Point
+	for _, id := range area.Vertices {
+		v, ok := vertices[id]
+		if !ok {
+			continue
+		}
+		p := models.Point{X: v.X, Y: v.Y}
+		if len(points) > 0 && points[len(points)-1] == p {
+			continue
+		}
+		ids = append(ids, id)
+		points = append(points, p)
+	}
+	for len(points) > 1 && points[len(points)-1] == points[0] {
+		ids, points = ids[:len(ids)-1], points[:len(points)-1]
+	}
+	return ids, points, len(points) >= 3
+}
+
+type vertexPair struct {
+	a, b string
+}
+
+func makePair(a, b string) vertexPair {
+	if a > b {
+		a, b = b, a
+	}
+	return vertexPair{a: a, b: b}
+}
+
+// wallThickness — толщина стены по паре ее вершин.
+func wallThickness(layer models.Layer) map[vertexPair]float64 {
+	out := make(map[vertexPair]float64, len(layer.Lines))
+	for _, line := range layer.Lines {
+		if len(line.Vertices) < 2 {
+			continue
+		}
+		out[makePair(line.Vertices[0], line.Vertices[1])] = models.LengthProperty(line.Properties, "thickness", 0)
+	}
+	return out
+}
+
+// netOutline смещает внутрь ребра контура, совпадающие с осью стены, на половину ее толщины.
+// Комнаты, нарисованные по внутренним граням стен, остаются без изменений.
+func netOutline(ids []string, outline []models.Point, walls map[vertexPair]float64) []models.Point {
+	offsets := make([]float64, len(outline))
+	shifted := false
+	for i := range outline {
+		if t, ok := walls[makePair(ids[i], ids[(i+1)%len(ids)])]; ok && t > 0 {
+			offsets[i] = t / 2
+			shifted = true
+		}
+	}
+	if !shifted {
+		return outline
+	}
+
+	inset, ok := insetPolygon(outline, offsets)
+	if !ok {
+		return outline
+	}
+	return inset
+}
+
+// holePoint — точка проема на оси стены и толщина стены.
+func holePoint(hole models.Hole, layer models.Layer) (models.Point, float64, bool) {
+	line, ok := layer.Lines[hole.Line]
+	if !ok || len(line.Vertices) < 2 {
+		return models.Point{}, 0, false
+	}
+	v1, ok1 := layer.Vertices[line.Vertices[0]]
+	v2, ok2 := layer.Vertices[line.Vertices[1]]
+	if !ok1 || !ok2 {
+		return models.Point{}, 0, false
+	}
+	p := models.Point{X: v1.X + (v2.X-v1.X)*hole.Offset, Y: v1.Y + (v2.Y-v1.Y)*hole.Offset}
+	return p, models.LengthProperty(line.Properties, "thickness", 0), true
+}
+
+// adjacency находит пары комнат с общей стеной: контуры идут рядом (не дальше tolerance)
+// на протяжении не меньше minSharedLength.
+func adjacency(rooms []room, tolerance float64) [][]string {
+	out := make([][]string, len(rooms))
+	for i := range out {
+		out[i] = []string{}
+	}
+	for i := range rooms {
+		for j := i + 1; j < len(rooms); j++ {
+			if sharedLength(rooms[i].outline, rooms[j].outline, tolerance) < minSharedLength &&
+				sharedLength(rooms[j].outline, rooms[i].outline, tolerance) < minSharedLength {
+				continue
+			}
+			out[i] = append(out[i], rooms[j].area.ID)
+			out[j] = append(out[j], rooms[i].area.ID)
+		}
+	}
+	return out
+}
+
+// sharedLength — длина участков контура a, лежащих не дальше tolerance от контура b.
+func sharedLength(a, b []models.Point, tolerance float64) float64 {
+	var total float64
+	for i, p := range a {
+		q := a[(i+1)%len(a)]
+		length := graph.Distance(p, q)
+		n := int(math.Ceil(length / adjacencyStep))
+		if n == 0 {
+			continue
+		}
+		step := length / float64(n)
+		for k := 0; k < n; k++ {
+			t := (float64(k) + 0.5) / float64(n)
+			s := models.Point{X: p.X + (q.X-p.X)*t, Y: p.Y + (q.Y-p.Y)*t}
+			if boundaryDistance(s, b) <= tolerance {
+				total += step
+			}
+		}
+	}
+	return total
+}
+
+// wallGroups суммирует длины стен по толщине (с точностью 0.1 единицы сцены).
+func wallGroups(layer models.Layer, meters float64) ([]WallGroup, float64) {
+	groups := make(map[float64]*WallGroup)
+	var total float64
+	for _, id := range models.SortedKeys(layer.Lines) {
+		line := layer.Lines[id]
+		if len(line.Vertices) < 2 {
+			continue
+		}
+		v1, ok1 := layer.Vertices[line.Vertices[0]]
+		v2, ok2 := layer.Vertices[line.Vertices[1]]
+		if !ok1 || !ok2 {
+			continue
+		}
+		length := math.Hypot(v2.X-v1.X, v2.Y-v1.Y) * meters
+		thickness := models.Round(models.LengthProperty(line.Properties, "thickness", 0), 1)
+
+		group, ok := groups[thickness]
+		if !ok {
+			group = &WallGroup{Thickness: thickness}
+			groups[thickness] = group
+		}
+		group.Length += length
+		group.Count++
+		total += length
+	}
+
+	out := make([]WallGroup, 0, len(groups))
+	for _, group := range groups {
+		group.Length = models.Round(group.Length, 2)
+		out = append(out, *group)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Thickness < out[j].Thickness })
+	return out, models.Round(total, 2)
+}
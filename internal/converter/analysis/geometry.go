@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"math"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Polygon geometry
+// ============================================================
+
+func polygonArea(points []models.Point) float64 {
+	return math.Abs(graph.SignedArea(points))
+}
+
+// insetPolygon сдвигает каждое ребро внутрь на offsets[i] и пересекает соседние ребра.
+// ok=false, если контур вырождается (смещение больше размеров комнаты).
+func insetPolygon(points []models.Point, offsets []float64) ([]models.Point, bool) {
+	n := len(points)
+	sign := 1.0
+	if graph.SignedArea(points) < 0 {
+		sign = -1
+	}
+
+	// смещенные ребра: точка и направление
+	origins := make([]models.Point, n)
+	normals := make([]models.Point, n)
+	dirs := make([]models.Point, n)
+	for i := range points {
+		p, q := points[i], points[(i+1)%n]
+		length := graph.Distance(p, q)
+		if length == 0 {
+			return nil, false
+		}
+		d := models.Point{X: (q.X - p.X) / length, Y: (q.Y - p.Y) / length}
+		normals[i] = models.Point{X: -d.Y * sign, Y: d.X * sign} // внутрь контура
+		origins[i] = models.Point{X: p.X + normals[i].X*offsets[i], Y: p.Y + normals[i].Y*offsets[i]}
+		dirs[i] = d
+	}
+
+	out := make([]models.Point, n)
+	for i, p := range points {
+		prev := (i - 1 + n) % n
+		// почти коллинеарные ребра (излом оси на стыке стен разной толщины): середина ступеньки
+		end := models.Point{X: p.X + normals[prev].X*offsets[prev], Y: p.Y + normals[prev].Y*offsets[prev]}
+		out[i] = models.Point{X: (end.X + origins[i].X) / 2, Y: (end.Y + origins[i].Y) / 2}
+
+		denom := dirs[prev].X*dirs[i].Y - dirs[prev].Y*dirs[i].X
+		if math.Abs(denom) < 1e-9 {
+			continue
+		}
+		dx, dy := origins[i].X-origins[prev].X, origins[i].Y-origins[prev].Y
+		t := (dx*dirs[i].Y - dy*dirs[i].X) / denom
+		corner := models.Point{X: origins[prev].X + dirs[prev].X*t, Y: origins[prev].Y + dirs[prev].Y*t}
+		if graph.Distance(corner, p) <= 4*math.Max(offsets[prev], offsets[i]) {
+			out[i] = corner
+		}
+	}
+
+	area := graph.SignedArea(out)
+	if area*sign <= 0 || math.Abs(area) > math.Abs(graph.SignedArea(points)) {
+		return nil, false
+	}
+	return out, true
+}
+
+func insideAny(p models.Point, polygons [][]models.Point) bool {
+	for _, polygon := range polygons {
+		if graph.PointInPolygon(p, polygon) {
+			return true
+		}
+	}
+	return false
+}
+
+// boundaryDistance — расстояние от точки до ближайшего ребра контура.
+func boundaryDistance(p models.Point, polygon []models.Point) float64 {
+	best := math.MaxFloat64
+	for i, a := range polygon {
+		best = math.Min(best, graph.SegmentDistance(p, a, polygon[(i+1)%len(polygon)]))
+	}
+	return best
+}
@@ -0,0 +1,428 @@
+package compliance
+
+import (
+	"fmt"
+	"math"
+
+	"api-gateway/internal/converter/analysis"
+	"api-gateway/internal/converter/diff"
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Compliance check
+// ============================================================
+
+const coverageStep = 5.0 // Шаг выборки точек оси снесенной стены, единицы сцены
+
+// Options — параметры проверки.
+type Options struct {
+	Layer string        // слой обеих сцен; пусто — выбранный слой каждой
+	Below *models.Scene // этаж ниже для wet_over_living; nil — исходная планировка
+}
+
+// Report — результат проверки перепланировки по профилю региона.
+type Report struct {
+	Region   string    `json:"region"`
+	Profile  string    `json:"profile,omitempty"`
+	Passed   bool      `json:"passed"` // нет новых нарушений уровня error
+	Findings []Finding `json:"findings"`
+}
+
+// Finding — нарушение правила конкретным элементом. Value и Limit — в единицах правила (см, м²).
+type Finding struct {
+	Rule     string  `json:"rule"`
+	Check    string  `json:"check"`
+	Severity string  `json:"severity"`
+	Element  string  `json:"element"` // wall, hole, area, item
+	ID       string  `json:"id"`
+	Name     string  `json:"name,omitempty"`
+	Message  string  `json:"message"`
+	Value    float64 `json:"value"`
+	Limit    float64 `json:"limit"`
+	Existing bool    `json:"existing,omitempty"` // нарушение было и в исходной планировке
+}
+
+// Check проверяет перепланировку original → edited по правилам профиля.
+// changes — результат diff.Compare для тех же сцен и слоя.
+func Check(original, edited *models.Scene, changes *diff.Report, profile *Profile, opts Options) (*Report, error) {
+	if original == nil || edited == nil || changes == nil || profile == nil {
+		return nil, fmt.Errorf("scenes, changes and profile are required")
+	}
+	c := &checker{changes: changes}
+	var err error
+	if c.before, c.beforeReport, err = prepare(original, opts.Layer); err != nil {
+		return nil, fmt.Errorf("original: %w", err)
+	}
+	if c.after, c.afterReport, err = prepare(edited, opts.Layer); err != nil {
+		return nil, fmt.Errorf("edited: %w", err)
+	}
+	c.below = c.before
+	if opts.Below != nil {
+		if c.below, _, err = prepare(opts.Below, opts.Layer); err != nil {
+			return nil, fmt.Errorf("below: %w", err)
+		}
+	}
+
+	report := &Report{Region: profile.Region, Profile: profile.Name, Passed: true, Findings: []Finding{}}
+	for i := range profile.Rules {
+		rule := &profile.Rules[i]
+		var findings []Finding
+		switch rule.Check {
+		case CheckBearingWallRemoved:
+			findings = c.bearingWallRemoved(rule)
+		case CheckBearingWallOpening:
+			findings = c.bearingWallOpening(rule)
+		case CheckWetOverLiving:
+			findings = c.wetOverLiving(rule)
+		case CheckWindow:
+			findings = c.markExisting(c.window(rule, c.after, c.afterReport), c.window(rule, c.before, c.beforeReport))
+		case CheckMinRoomArea:
+			findings = c.markExisting(c.minRoomArea(rule, c.after, c.afterReport), c.minRoomArea(rule, c.before, c.beforeReport))
+		case CheckMinDoorWidth:
+			findings = c.markExisting(c.minDoorWidth(rule, c.after), c.minDoorWidth(rule, c.before))
+		case CheckStructureMoved:
+			findings = c.structureMoved(rule)
+		}
+		for _, f := range findings {
+			if f.Severity == SeverityError && !f.Existing {
+				report.Passed = false
+			}
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	return report, nil
+}
+
+// plan — слой сцены с пересчетом единиц в см.
+type plan struct {
+	layer   models.Layer
+	cm      float64 // см в единице сцены
+	rooms   []roomShape
+	unnamed map[string]bool // найденные по стенам комнаты без подписи: вид неизвестен
+}
+
+type roomShape struct {
+	id      string
+	name    string
+	kind    string
+	outline []models.Point
+}
+
+type checker struct {
+	before       plan
+	after        plan
+	below        plan
+	beforeReport *analysis.Report
+	afterReport  *analysis.Report
+	changes      *diff.Report
+}
+
+func prepare(scene *models.Scene, layerID string) (plan, *analysis.Report, error) {
+	report, err := analysis.Analyze(scene, analysis.Options{Layer: layerID})
+	if err != nil {
+		return plan{}, nil, err
+	}
+	cm, _ := parser.CentimetersPerUnit(scene.Unit)
+	p := plan{layer: scene.Layers[report.Layer], cm: cm, unnamed: make(map[string]bool)}
+	for _, id := range models.SortedKeys(p.layer.Areas) {
+		area := p.layer.Areas[id]
+		kind := ""
+		if !area.PlaceholderName() {
+			kind = analysis.RoomKind(area.DisplayName())
+		} else {
+			p.unnamed[id] = true
+		}
+		var outline []models.Point
+		for _, vid := range area.Vertices {
+			if v, ok := p.layer.Vertices[vid]; ok {
+				outline = append(outline, models.Point{X: v.X, Y: v.Y})
+			}
+		}
+		if len(outline) < 3 {
+			continue
+		}
+		p.rooms = append(p.rooms, roomShape{id: id, name: area.DisplayName(), kind: kind, outline: outline})
+	}
+	return p, report, nil
+}
+
+func finding(rule *Rule, element, id, name string, value, limit float64, format string, args ...any) Finding {
+	message := rule.Message
+	if message == "" {
+		message = fmt.Sprintf(format, args...)
+	}
+	return Finding{
+		Rule: rule.Name, Check: rule.Check, Severity: rule.Severity,
+		Element: element, ID: id, Name: name, Message: message,
+		Value: value, Limit: limit,
+	}
+}
+
+// markExisting отмечает нарушения, которые были и в исходной планировке.
+// Комнаты, пересозданные с другим id, сопоставляются по id_before из diff.
+func (c *checker) markExisting(after, before []Finding) []Finding {
+	renamed := make(map[string]string)
+	for _, change := range c.changes.Rooms {
+		if change.IDBefore != "" {
+			renamed[change.IDBefore] = change.ID
+		}
+	}
+	seen := make(map[string]bool, len(before))
+	for _, f := range before {
+		id := f.ID
+		if f.Element == "area" && renamed[id] != "" {
+			id = renamed[id]
+		}
+		seen[f.Element+"/"+id] = true
+	}
+	for i := range after {
+		after[i].Existing = seen[after[i].Element+"/"+after[i].ID]
+	}
+	return after
+}
+
+// ============================================================
+// Bearing walls
+// ============================================================
+
+// bearing — несущая ли стена: properties.bearing, название по правилу или толщина больше порога.
+func bearing(rule *Rule, line models.Line, thicknessCm float64) bool {
+	if marked, ok := line.Properties["bearing"].(bool); ok {
+		return marked
+	}
+	if rule.wallsRe != nil && rule.wallsRe.MatchString(line.Name) {
+		return true
+	}
+	return rule.Thickness > 0 && thicknessCm > rule.Thickness
+}
+
+// bearingWallRemoved — несущие стены, ось которых больше не покрыта стеной той же толщины.
+// Перерисованные редактором стены (удалены и добавлены заново по той же оси) не считаются снесенными.
+func (c *checker) bearingWallRemoved(rule *Rule) []Finding {
+	var out []Finding
+	for _, change := range c.changes.Walls {
+		if change.Change == diff.ChangeAdded || change.Before == nil {
+			continue
+		}
+		line, ok := c.before.layer.Lines[change.ID]
+		if !ok || !bearing(rule, line, change.Before.Thickness*c.before.cm) {
+			continue
+		}
+		removed := c.uncoveredLength(change.Before.A, change.Before.B, change.Before.Thickness) * c.before.cm
+		if removed < math.Max(rule.Min, 1) {
+			continue
+		}
+		message := "Несущая стена %s снесена"
+		if change.Change != diff.ChangeRemoved {
+			message = "Несущая стена %s частично снесена"
+		}
+		out = append(out, finding(rule, "wall", change.ID, line.Name, models.Round(removed, 1), rule.Min,
+			message+" (%.0f см)", line.Name, removed))
+	}
+	return out
+}
+
+// uncoveredLength — длина оси a-b, не покрытая стенами отредактированной сцены не тоньше thickness.
+func (c *checker) uncoveredLength(a, b models.Point, thickness float64) float64 {
+	type segment struct{ a, b models.Point }
+	var walls []segment
+	for _, line := range c.after.layer.Lines {
+		if models.LengthProperty(line.Properties, "thickness", 0) < thickness-1 || len(line.Vertices) < 2 {
+			continue
+		}
+		v1, ok1 := c.after.layer.Vertices[line.Vertices[0]]
+		v2, ok2 := c.after.layer.Vertices[line.Vertices[1]]
+		if ok1 && ok2 {
+			walls = append(walls, segment{models.Point{X: v1.X, Y: v1.Y}, models.Point{X: v2.X, Y: v2.Y}})
+		}
+	}
+
+	length := graph.Distance(a, b)
+	steps := int(math.Ceil(length / coverageStep))
+	if steps == 0 {
+		return 0
+	}
+	tolerance := math.Max(thickness/2, 1)
+	uncovered := 0
+	for i := 0; i < steps; i++ {
+		t := (float64(i) + 0.5) / float64(steps)
+		p := models.Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+		covered := false
+		for _, w := range walls {
+			if graph.SegmentDistance(p, w.a, w.b) <= tolerance {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			uncovered++
+		}
+	}
+	return length * float64(uncovered) / float64(steps)
+}
+
+// bearingWallOpening — добавленные, перенесенные и расширенные проемы в несущих стенах.
+func (c *checker) bearingWallOpening(rule *Rule) []Finding {
+	var out []Finding
+	for _, change := range c.changes.Holes {
+		if change.After == nil {
+			continue
+		}
+		line, ok := c.after.layer.Lines[change.After.Line]
+		if !ok || !bearing(rule, line, models.LengthProperty(line.Properties, "thickness", 0)*c.after.cm) {
+			continue
+		}
+		var value float64
+		var message string
+		switch change.Change {
+		case diff.ChangeAdded:
+			value = change.After.Width * c.after.cm
+			message = fmt.Sprintf("Новый проем %s в несущей стене %s (%.0f см)", change.ID, line.Name, value)
+		case diff.ChangeMoved:
+			value = change.Shift * 100
+			message = fmt.Sprintf("Проем %s в несущей стене %s перенесен на %.0f см", change.ID, line.Name, value)
+		case diff.ChangeResized:
+			value = change.WidthDelta * 100
+			message = fmt.Sprintf("Проем %s в несущей стене %s расширен на %.0f см", change.ID, line.Name, value)
+		}
+		if value < math.Max(rule.Min, 1) {
+			continue
+		}
+		out = append(out, finding(rule, "hole", change.ID, line.Name, models.Round(value, 1), rule.Min, "%s", message))
+	}
+	return out
+}
+
+// ============================================================
+// Rooms
+// ============================================================
+
+// wetOverLiving — помещения правила (мокрые зоны), перекрывающие помещение этажа ниже
+// (по умолчанию — исходную планировку) больше чем на Min м².
+func (c *checker) wetOverLiving(rule *Rule) []Finding {
+	var out []Finding
+	scale := c.after.cm * c.after.cm / 10000
+	for _, room := range c.after.rooms {
+		if !rule.matchesRoom(room.name, room.kind) {
+			continue
+		}
+		for _, below := range c.below.rooms {
+			if !rule.matchesBelow(below.name, below.kind) {
+				continue
+			}
+			overlap := graph.OverlapArea(room.outline, below.outline) * scale
+			if overlap <= 0 || overlap < rule.Min {
+				continue
+			}
+			out = append(out, finding(rule, "area", room.id, room.name, models.Round(overlap, 2), rule.Min,
+				"%s над жилой комнатой %s нижнего этажа (%.2f м²)", room.name, below.name, overlap))
+		}
+	}
+	return out
+}
+
+func (c *checker) window(rule *Rule, p plan, report *analysis.Report) []Finding {
+	var out []Finding
+	for _, room := range report.Rooms {
+		if rule.matchesRoom(room.Name, p.kind(room)) && room.Windows == 0 {
+			out = append(out, finding(rule, "area", room.ID, room.Name, 0, 1, "%s без окна", room.Name))
+		}
+	}
+	return out
+}
+
+func (c *checker) minRoomArea(rule *Rule, p plan, report *analysis.Report) []Finding {
+	var out []Finding
+	for _, room := range report.Rooms {
+		if rule.matchesRoom(room.Name, p.kind(room)) && room.Area < rule.Min {
+			out = append(out, finding(rule, "area", room.ID, room.Name, room.Area, rule.Min,
+				"Площадь помещения %s %.2f м² меньше %.2f м²", room.Name, room.Area, rule.Min))
+		}
+	}
+	return out
+}
+
+// kind — вид помещения строки экспликации; пусто для комнат без подписи.
+func (p plan) kind(room analysis.RoomReport) string {
+	if p.unnamed[room.ID] {
+		return ""
+	}
+	return room.Kind
+}
+
+// ============================================================
+// Doors
+// ============================================================
+
+func (c *checker) minDoorWidth(rule *Rule, p plan) []Finding {
+	var out []Finding
+	for _, id := range models.SortedKeys(p.layer.Holes) {
+		hole := p.layer.Holes[id]
+		if hole.Kind() != "door" {
+			continue
+		}
+		width := models.LengthProperty(hole.Properties, "width", 0) * p.cm
+		if width < rule.Min {
+			out = append(out, finding(rule, "hole", id, hole.Name, models.Round(width, 1), rule.Min,
+				"Дверь %s шириной %.0f см уже %.0f см", id, width, rule.Min))
+		}
+	}
+	return out
+}
+
+// ============================================================
+// Structural elements
+// ============================================================
+
+// structuralKinds — типы items несущих элементов и их названия в сообщениях.
+var structuralKinds = map[string]string{"column": "Колонна", "shaft": "Шахта", "stair": "Лестница"}
+
+// structural — колонна, шахта или лестница (properties.structural имеет приоритет).
+func structural(item models.Item) bool {
+	if marked, ok := item.Properties["structural"].(bool); ok {
+		return marked
+	}
+	return structuralKinds[item.Type] != ""
+}
+
+// structureMoved — колонны, шахты и лестницы исходной планировки, которые удалены,
+// сдвинуты или изменили размер больше чем на min см.
+func (c *checker) structureMoved(rule *Rule) []Finding {
+	var out []Finding
+	for _, id := range models.SortedKeys(c.before.layer.Items) {
+		before := c.before.layer.Items[id]
+		if !structural(before) {
+			continue
+		}
+		kind := structuralKinds[before.Type]
+		if kind == "" {
+			kind = "Несущий элемент"
+		}
+		name := before.Name
+		after, ok := c.after.layer.Items[id]
+		if !ok {
+			out = append(out, finding(rule, "item", id, name, 0, rule.Min,
+				"%s %s отсутствует в новой планировке", kind, id))
+			continue
+		}
+
+		shift := math.Hypot(after.X-before.X, after.Y-before.Y) * c.before.cm
+		resize := 0.0
+		for _, key := range []string{"width", "depth"} {
+			delta := models.LengthProperty(after.Properties, key, 0)*c.after.cm - models.LengthProperty(before.Properties, key, 0)*c.before.cm
+			resize = math.Max(resize, math.Abs(delta))
+		}
+		switch {
+		case shift > rule.Min:
+			out = append(out, finding(rule, "item", id, name, models.Round(shift, 1), rule.Min,
+				"%s %s: сдвиг на %.0f см", kind, id, shift))
+		case resize > rule.Min:
+			out = append(out, finding(rule, "item", id, name, models.Round(resize, 1), rule.Min,
+				"%s %s: размер изменен на %.0f см", kind, id, resize))
+		}
+	}
+	return out
+}
@@ -0,0 +1,215 @@
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ============================================================
+// Compliance rules
+// ============================================================
+
+// Проверки, которые умеет движок. Правило выбирает проверку и задает ее пороги.
+const (
+	CheckBearingWallRemoved = "bearing_wall_removed" // несущая стена снесена или укорочена
+	CheckBearingWallOpening = "bearing_wall_opening" // новый проем в несущей стене
+	CheckWetOverLiving      = "wet_over_living"      // мокрая зона над жилой комнатой нижнего этажа
+	CheckWindow             = "window"               // помещение без окна (кухня)
+	CheckMinRoomArea        = "min_room_area"        // площадь помещения меньше минимальной
+	CheckMinDoorWidth       = "min_door_width"       // дверь уже минимальной
+	CheckStructureMoved     = "structure_moved"      // колонна, шахта или лестница сдвинута или удалена
+)
+
+// Уровни нарушений: error — перепланировка недопустима, warning — требует внимания.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Rule — декларативное правило. Пустые фильтры не ограничивают проверку.
+// Длины и толщины — в см, площади — в м², независимо от единиц сцены.
+type Rule struct {
+	Name      string  `json:"name"`
+	Check     string  `json:"check"`
+	Severity  string  `json:"severity,omitempty"`   // error (по умолчанию) или warning
+	Rooms     string  `json:"rooms,omitempty"`      // regex по названию помещения
+	Kind      string  `json:"kind,omitempty"`       // вид помещения (analysis.RoomKind): living, service...
+	Below     string  `json:"below,omitempty"`      // regex по названию помещения этажом ниже (wet_over_living)
+	BelowKind string  `json:"below_kind,omitempty"` // вид помещения этажом ниже
+	Walls     string  `json:"walls,omitempty"`      // regex по названию стены, отмечающий несущие стены
+	Thickness float64 `json:"thickness,omitempty"`  // стены толще (см) считаются несущими
+	Min       float64 `json:"min,omitempty"`        // порог проверки: м², см или см сноса/сдвига/изменения размера
+	Message   string  `json:"message,omitempty"`    // текст нарушения вместо стандартного
+
+	roomsRe *regexp.Regexp
+	belowRe *regexp.Regexp
+	wallsRe *regexp.Regexp
+}
+
+// Profile — набор правил региона.
+type Profile struct {
+	Region string `json:"region"`
+	Name   string `json:"name,omitempty"`
+	Rules  []Rule `json:"rules"`
+}
+
+// Profiles — профили по регионам. Default — регион, если в запросе он не указан.
+type Profiles struct {
+	Default  string     `json:"default,omitempty"`
+	Profiles []*Profile `json:"profiles"`
+}
+
+const wetRooms = `(?i)кухн|kitchen|ванн|bath|туалет|toilet|toliet|санузел|с/у|wc|душ|shower|постироч|laundry`
+
+// DefaultProfiles — общие нормы для жилых помещений (СП 54.13330) и запрет переноса
+// мокрых зон над жилыми комнатами. Нижний этаж считается повторяющим исходную планировку.
+func DefaultProfiles() *Profiles {
+	ps := &Profiles{Default: "ru", Profiles: []*Profile{{
+		Region: "ru",
+		Name:   "СП 54.13330",
+		Rules: []Rule{
+			{Name: "bearing-walls", Check: CheckBearingWallRemoved, Walls: `(?i)bearing|несущ`, Thickness: 12, Min: 10},
+			{Name: "bearing-openings", Check: CheckBearingWallOpening, Walls: `(?i)bearing|несущ`, Thickness: 12, Min: 10},
+			{Name: "wet-over-living", Check: CheckWetOverLiving, Rooms: wetRooms, BelowKind: "living", Min: 0.5},
+			{Name: "kitchen-window", Check: CheckWindow, Rooms: `(?i)кухн|kitchen`},
+			{Name: "kitchen-area", Check: CheckMinRoomArea, Rooms: `(?i)кухн|kitchen`, Min: 5},
+			{Name: "living-area", Check: CheckMinRoomArea, Kind: "living", Min: 8},
+			{Name: "door-width", Check: CheckMinDoorWidth, Severity: SeverityWarning, Min: 60},
+			{Name: "structural-elements", Check: CheckStructureMoved, Min: 5},
+		},
+	}}}
+	if err := ps.compile(); err != nil {
+		panic(err)
+	}
+	return ps
+}
+
+// LoadProfiles читает профили из JSON файла.
+func LoadProfiles(path string) (*Profiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProfiles(data)
+}
+
+// ParseProfiles разбирает JSON вида {"default": "ru", "profiles": [...]} или просто массив профилей.
+func ParseProfiles(data []byte) (*Profiles, error) {
+	var ps Profiles
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &ps.Profiles); err != nil {
+			return nil, fmt.Errorf("decode profiles: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &ps); err != nil {
+		return nil, fmt.Errorf("decode profiles: %w", err)
+	}
+
+	if len(ps.Profiles) == 0 {
+		return nil, fmt.Errorf("profile set is empty")
+	}
+	if err := ps.compile(); err != nil {
+		return nil, err
+	}
+	return &ps, nil
+}
+
+// Get возвращает профиль региона; пустой регион — профиль по умолчанию.
+func (ps *Profiles) Get(region string) (*Profile, bool) {
+	if region == "" {
+		region = ps.Default
+	}
+	for _, p := range ps.Profiles {
+		if p.Region == region {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (ps *Profiles) compile() error {
+	seen := make(map[string]bool)
+	for _, p := range ps.Profiles {
+		if p == nil || p.Region == "" {
+			return fmt.Errorf("profile region required")
+		}
+		if seen[p.Region] {
+			return fmt.Errorf("duplicate profile %s", p.Region)
+		}
+		seen[p.Region] = true
+		for i := range p.Rules {
+			if err := p.Rules[i].compile(i); err != nil {
+				return fmt.Errorf("profile %s: %w", p.Region, err)
+			}
+		}
+	}
+	if ps.Default == "" {
+		ps.Default = ps.Profiles[0].Region
+	} else if !seen[ps.Default] {
+		return fmt.Errorf("default profile %s not found", ps.Default)
+	}
+	return nil
+}
+
+func (r *Rule) compile(i int) error {
+	switch r.Check {
+	case CheckBearingWallRemoved, CheckBearingWallOpening, CheckWetOverLiving,
+		CheckWindow, CheckMinRoomArea, CheckMinDoorWidth, CheckStructureMoved:
+	case "":
+		return fmt.Errorf("rule %d (%s): check required", i, r.Name)
+	default:
+		return fmt.Errorf("rule %d (%s): unknown check %q", i, r.Name, r.Check)
+	}
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("rule-%d", i+1)
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityError
+	case SeverityError, SeverityWarning:
+	default:
+		return fmt.Errorf("rule %s: severity must be error or warning", r.Name)
+	}
+	if r.Min < 0 || r.Thickness < 0 {
+		return fmt.Errorf("rule %s: thresholds must be non-negative", r.Name)
+	}
+
+	for _, field := range []struct {
+		name    string
+		pattern string
+		re      **regexp.Regexp
+	}{
+		{"rooms", r.Rooms, &r.roomsRe},
+		{"below", r.Below, &r.belowRe},
+		{"walls", r.Walls, &r.wallsRe},
+	} {
+		if field.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(field.pattern)
+		if err != nil {
+			return fmt.Errorf("rule %s: %s: %w", r.Name, field.name, err)
+		}
+		*field.re = re
+	}
+	return nil
+}
+
+// matchesRoom — подходит ли помещение под фильтры rooms и kind.
+func (r *Rule) matchesRoom(name, kind string) bool {
+	if r.roomsRe != nil && !r.roomsRe.MatchString(name) {
+		return false
+	}
+	return r.Kind == "" || r.Kind == kind
+}
+
+// matchesBelow — подходит ли помещение этажом ниже под фильтры below и below_kind.
+func (r *Rule) matchesBelow(name, kind string) bool {
+	if r.belowRe != nil && !r.belowRe.MatchString(name) {
+		return false
+	}
+	return r.BelowKind == "" || r.BelowKind == kind
+}
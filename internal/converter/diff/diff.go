@@ -0,0 +1,553 @@
+package diff
+
+import (
+	"fmt"
+	"math"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Scene diff
+// ============================================================
+
+const defaultTolerance = 1.0 // Сдвиги меньше этого (в единицах сцены) не считаются изменением
+const overlapShare = 0.5     // Доля площади комнаты, покрытая другой, для слияния/разделения
+
+// Виды изменений.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeMoved   = "moved"
+	ChangeResized = "resized"
+	ChangeRenamed = "renamed"
+	ChangeMerged  = "merged"
+	ChangeSplit   = "split"
+)
+
+// Options — параметры сравнения.
+type Options struct {
+	Layer     string  // слой для сравнения; пусто — выбранный слой каждой сцены
+	Tolerance float64 // допуск сдвига в единицах сцены; 0 — defaultTolerance
+}
+
+// Report — изменения между исходной и отредактированной сценой.
+// Координаты, толщины и ширины в before/after — в единицах сцены; длины, сдвиги и изменения
+// толщины и ширины — в м; площади — в м².
+type Report struct {
+	Unit    string       `json:"unit"`
+	Summary Summary      `json:"summary"`
+	Walls   []WallChange `json:"walls"`
+	Holes   []HoleChange `json:"holes"`
+	Rooms   []RoomChange `json:"rooms"`
+}
+
+// Summary — количество изменений по видам и общая площадь комнат.
+type Summary struct {
+	Walls           map[string]int `json:"walls"`
+	Holes           map[string]int `json:"holes"`
+	Rooms           map[string]int `json:"rooms"`
+	TotalAreaBefore float64        `json:"total_area_before"`
+	TotalAreaAfter  float64        `json:"total_area_after"`
+	AreaDelta       float64        `json:"area_delta"`
+}
+
+// Segment — ось стены. В отчете длина — в м (segmentJSON), толщина — в единицах сцены.
+type Segment struct {
+	A         models.Point `json:"a"`
+	B         models.Point `json:"b"`
+	Thickness float64      `json:"thickness"`
+	Length    float64      `json:"length"`
+}
+
+// WallChange — добавленная, удаленная, сдвинутая или измененная по размеру стена.
+type WallChange struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Change         string   `json:"change"`
+	Before         *Segment `json:"before,omitempty"`
+	After          *Segment `json:"after,omitempty"`
+	Shift          float64  `json:"shift,omitempty"`           // сдвиг середины стены
+	LengthDelta    float64  `json:"length_delta,omitempty"`    // изменение длины
+	ThicknessDelta float64  `json:"thickness_delta,omitempty"` // изменение толщины
+}
+
+// HolePosition — положение проема: стена, offset вдоль нее и точка на оси.
+type HolePosition struct {
+	Line   string       `json:"line"`
+	Offset float64      `json:"offset"`
+	Point  models.Point `json:"point"`
+	Width  float64      `json:"width"` // в единицах сцены
+}
+
+// HoleChange — добавленный, удаленный, перенесенный или измененный по ширине проем.
+type HoleChange struct {
+	ID         string        `json:"id"`
+	Type       string        `json:"type"`
+	Kind       string        `json:"kind"` // door, window — см. models.Hole.Kind
+	Change     string        `json:"change"`
+	Before     *HolePosition `json:"before,omitempty"`
+	After      *HolePosition `json:"after,omitempty"`
+	Shift      float64       `json:"shift,omitempty"`       // вдоль стены, если стена та же, иначе по прямой
+	WidthDelta float64       `json:"width_delta,omitempty"` // изменение ширины
+}
+
+// RoomChange — изменение комнаты. Для слияния ID — новая комната, From — исходные;
+// для разделения ID — исходная комната, Into — новые.
+type RoomChange struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Change     string   `json:"change"`
+	IDBefore   string   `json:"id_before,omitempty"` // комната пересоздана с другим id (найдена по контуру)
+	NameBefore string   `json:"name_before,omitempty"`
+	AreaBefore float64  `json:"area_before"`
+	AreaAfter  float64  `json:"area_after"`
+	AreaDelta  float64  `json:"area_delta"`
+	From       []string `json:"from,omitempty"`
+	Into       []string `json:"into,omitempty"`
+}
+
+// Compare сравнивает исходную и отредактированную сцены.
+func Compare(original, edited *models.Scene, opts Options) (*Report, error) {
+	if original == nil || edited == nil {
+		return nil, fmt.Errorf("both scenes are required")
+	}
+	before, err := pickLayer(original, opts.Layer)
+	if err != nil {
+		return nil, fmt.Errorf("original: %w", err)
+	}
+	after, err := pickLayer(edited, opts.Layer)
+	if err != nil {
+		return nil, fmt.Errorf("edited: %w", err)
+	}
+	if original.Unit != edited.Unit {
+		return nil, fmt.Errorf("scene units differ: %q and %q", original.Unit, edited.Unit)
+	}
+	cm, ok := parser.CentimetersPerUnit(original.Unit)
+	if !ok {
+		return nil, fmt.Errorf("unknown scene unit %q", original.Unit)
+	}
+
+	tolerance := opts.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultTolerance
+	}
+	d := differ{before: before, after: after, meters: cm / 100, tolerance: tolerance}
+
+	report := &Report{
+		Unit:  original.Unit,
+		Walls: d.walls(),
+		Holes: d.holes(),
+		Rooms: d.rooms(),
+		Summary: Summary{
+			Walls: map[string]int{},
+			Holes: map[string]int{},
+			Rooms: map[string]int{},
+		},
+	}
+	for _, c := range report.Walls {
+		report.Summary.Walls[c.Change]++
+	}
+	for _, c := range report.Holes {
+		report.Summary.Holes[c.Change]++
+	}
+	for _, c := range report.Rooms {
+		report.Summary.Rooms[c.Change]++
+	}
+	for _, s := range shapes(before) {
+		report.Summary.TotalAreaBefore += s.area * d.meters * d.meters
+	}
+	for _, s := range shapes(after) {
+		report.Summary.TotalAreaAfter += s.area * d.meters * d.meters
+	}
+	report.Summary.AreaDelta = models.Round(report.Summary.TotalAreaAfter-report.Summary.TotalAreaBefore, 2)
+	report.Summary.TotalAreaBefore = models.Round(report.Summary.TotalAreaBefore, 2)
+	report.Summary.TotalAreaAfter = models.Round(report.Summary.TotalAreaAfter, 2)
+
+	return report, nil
+}
+
+type differ struct {
+	before    models.Layer
+	after     models.Layer
+	meters    float64
+	tolerance float64
+}
+
+// ============================================================
+// Walls
+// ============================================================
+
+func (d differ) walls() []WallChange {
+	out := []WallChange{}
+
+	// стены без пары по id сопоставляются по геометрии: редактор мог пересоздать линию
+	var removed, added []string
+	for _, id := range models.SortedKeys(d.before.Lines) {
+		if _, ok := d.after.Lines[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	for _, id := range models.SortedKeys(d.after.Lines) {
+		if _, ok := d.before.Lines[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	rematched := make(map[string]string)
+	for _, oldID := range removed {
+		a, ok := d.segment(d.before, oldID)
+		if !ok {
+			continue
+		}
+		for _, newID := range added {
+			if _, used := rematched[newID]; used {
+				continue
+			}
+			if b, ok := d.segment(d.after, newID); ok && sameSegment(a, b, d.tolerance) {
+				rematched[newID] = oldID
+				rematched[oldID] = newID
+				break
+			}
+		}
+	}
+
+	for _, id := range models.SortedKeys(d.before.Lines) {
+		line := d.before.Lines[id]
+		a, okA := d.segment(d.before, id)
+		if _, ok := rematched[id]; ok {
+			continue
+		}
+		if _, ok := d.after.Lines[id]; !ok {
+			change := WallChange{ID: id, Name: line.Name, Change: ChangeRemoved}
+			if okA {
+				change.Before = d.segmentJSON(a)
+			}
+			out = append(out, change)
+			continue
+		}
+		b, okB := d.segment(d.after, id)
+		if !okA || !okB {
+			continue
+		}
+		if change, ok := d.compareWall(id, d.after.Lines[id].Name, a, b); ok {
+			out = append(out, change)
+		}
+	}
+	for _, id := range added {
+		if _, ok := rematched[id]; ok {
+			continue
+		}
+		change := WallChange{ID: id, Name: d.after.Lines[id].Name, Change: ChangeAdded}
+		if b, ok := d.segment(d.after, id); ok {
+			change.After = d.segmentJSON(b)
+		}
+		out = append(out, change)
+	}
+	return out
+}
+
+func (d differ) compareWall(id, name string, a, b Segment) (WallChange, bool) {
+	lengthDelta := b.Length - a.Length
+	thicknessDelta := b.Thickness - a.Thickness
+	shift := graph.Distance(midpoint(a.A, a.B), midpoint(b.A, b.B))
+
+	change := WallChange{ID: id, Name: name, Before: d.segmentJSON(a), After: d.segmentJSON(b)}
+	switch {
+	case math.Abs(lengthDelta) > d.tolerance || math.Abs(thicknessDelta) > d.tolerance:
+		change.Change = ChangeResized
+	case shift > d.tolerance || !sameSegment(a, b, d.tolerance):
+		change.Change = ChangeMoved
+	default:
+		return WallChange{}, false
+	}
+	change.Shift = models.Round(shift*d.meters, 3)
+	change.LengthDelta = models.Round(lengthDelta*d.meters, 3)
+	change.ThicknessDelta = models.Round(thicknessDelta*d.meters, 3)
+	return change, true
+}
+
+func (d differ) segment(layer models.Layer, lineID string) (Segment, bool) {
+	line, ok := layer.Lines[lineID]
+	if !ok || len(line.Vertices) < 2 {
+		return Segment{}, false
+	}
+	v1, ok1 := layer.Vertices[line.Vertices[0]]
+	v2, ok2 := layer.Vertices[line.Vertices[1]]
+	if !ok1 || !ok2 {
+		return Segment{}, false
+	}
+	a, b := models.Point{X: v1.X, Y: v1.Y}, models.Point{X: v2.X, Y: v2.Y}
+	return Segment{A: a, B: b, Thickness: models.LengthProperty(line.Properties, "thickness", 0), Length: graph.Distance(a, b)}, true
+}
+
+// segmentJSON — сегмент для отчета: длина в м.
+func (d differ) segmentJSON(s Segment) *Segment {
+	s.Length = models.Round(s.Length*d.meters, 3)
+	return &s
+}
+
+// sameSegment сравнивает оси без учета направления. Сегменты до segmentJSON: координаты,
+// толщины и tolerance — в единицах сцены.
+func sameSegment(a, b Segment, tolerance float64) bool {
+	if math.Abs(a.Thickness-b.Thickness) > tolerance {
+		return false
+	}
+	direct := graph.Distance(a.A, b.A) <= tolerance && graph.Distance(a.B, b.B) <= tolerance
+	reverse := graph.Distance(a.A, b.B) <= tolerance && graph.Distance(a.B, b.A) <= tolerance
+	return direct || reverse
+}
+
+// ============================================================
+// Holes
+// ============================================================
+
+func (d differ) holes() []HoleChange {
+	out := []HoleChange{}
+
+	for _, id := range models.SortedKeys(d.before.Holes) {
+		hole := d.before.Holes[id]
+		a := d.holePosition(d.before, hole)
+		next, ok := d.after.Holes[id]
+		if !ok {
+			out = append(out, HoleChange{ID: id, Type: hole.Type, Kind: hole.Kind(), Change: ChangeRemoved, Before: a})
+			continue
+		}
+		b := d.holePosition(d.after, next)
+		if a == nil || b == nil {
+			continue
+		}
+
+		change := HoleChange{ID: id, Type: next.Type, Kind: next.Kind(), Before: a, After: b}
+		shift := graph.Distance(a.Point, b.Point)
+		if a.Line == b.Line {
+			if seg, ok := d.segment(d.after, b.Line); ok {
+				shift = math.Abs(b.Offset-a.Offset) * seg.Length
+			}
+		}
+		widthDelta := b.Width - a.Width
+		switch {
+		case shift > d.tolerance:
+			change.Change = ChangeMoved
+		case math.Abs(widthDelta) > d.tolerance:
+			change.Change = ChangeResized
+		default:
+			continue
+		}
+		change.Shift = models.Round(shift*d.meters, 3)
+		change.WidthDelta = models.Round(widthDelta*d.meters, 3)
+		out = append(out, change)
+	}
+
+	for _, id := range models.SortedKeys(d.after.Holes) {
+		if _, ok := d.before.Holes[id]; ok {
+			continue
+		}
+		hole := d.after.Holes[id]
+		out = append(out, HoleChange{ID: id, Type: hole.Type, Kind: hole.Kind(), Change: ChangeAdded, After: d.holePosition(d.after, hole)})
+	}
+	return out
+}
+
+func (d differ) holePosition(layer models.Layer, hole models.Hole) *HolePosition {
+	seg, ok := d.segment(layer, hole.Line)
+	if !ok {
+		return nil
+	}
+	return &HolePosition{
+		Line:   hole.Line,
+		Offset: hole.Offset,
+		Point: models.Point{
+			X: seg.A.X + (seg.B.X-seg.A.X)*hole.Offset,
+			Y: seg.A.Y + (seg.B.Y-seg.A.Y)*hole.Offset,
+		},
+		Width: models.LengthProperty(hole.Properties, "width", 0),
+	}
+}
+
+// ============================================================
+// Rooms
+// ============================================================
+
+type roomShape struct {
+	id      string
+	name    string
+	outline []models.Point
+	area    float64 // в единицах сцены²
+}
+
+func (d differ) rooms() []RoomChange {
+	out := []RoomChange{}
+	before := shapes(d.before)
+	after := shapes(d.after)
+	area := func(s roomShape) float64 { return models.Round(s.area*d.meters*d.meters, 2) }
+
+	// перекрытия исходных и новых комнат
+	overlap := make([][]float64, len(before))
+	for i := range before {
+		overlap[i] = make([]float64, len(after))
+		for j := range after {
+			overlap[i][j] = graph.OverlapArea(before[i].outline, after[j].outline)
+		}
+	}
+
+	involvedBefore := make(map[string]bool)
+	involvedAfter := make(map[string]bool)
+
+	// слияние: новая комната покрывает большую часть двух и более исходных
+	for j, target := range after {
+		var from []string
+		var sum float64
+		for i, source := range before {
+			if overlap[i][j] >= overlapShare*source.area {
+				from = append(from, source.id)
+				sum += area(source)
+			}
+		}
+		if len(from) < 2 {
+			continue
+		}
+		out = append(out, RoomChange{
+			ID: target.id, Name: target.name, Change: ChangeMerged,
+			AreaBefore: models.Round(sum, 2), AreaAfter: area(target), AreaDelta: models.Round(area(target)-sum, 2),
+			From: from,
+		})
+		involvedAfter[target.id] = true
+		for _, id := range from {
+			involvedBefore[id] = true
+		}
+	}
+
+	// разделение: исходная комната покрывает большую часть двух и более новых
+	for i, source := range before {
+		if involvedBefore[source.id] {
+			continue
+		}
+		var into []string
+		var sum float64
+		for j, target := range after {
+			if !involvedAfter[target.id] && overlap[i][j] >= overlapShare*target.area {
+				into = append(into, target.id)
+				sum += area(target)
+			}
+		}
+		if len(into) < 2 {
+			continue
+		}
+		out = append(out, RoomChange{
+			ID: source.id, Name: source.name, Change: ChangeSplit,
+			AreaBefore: area(source), AreaAfter: models.Round(sum, 2), AreaDelta: models.Round(sum-area(source), 2),
+			Into: into,
+		})
+		involvedBefore[source.id] = true
+		for _, id := range into {
+			involvedAfter[id] = true
+		}
+	}
+
+	afterByID := make(map[string]roomShape, len(after))
+	for _, s := range after {
+		afterByID[s.id] = s
+	}
+	beforeIDs := make(map[string]bool, len(before))
+	for _, s := range before {
+		beforeIDs[s.id] = true
+	}
+
+	// комнаты без пары по id сопоставляются по взаимному перекрытию
+	// (например, размеченная комната и та же комната, найденная по контуру стен)
+	rematched := make(map[string]string)
+	for i, source := range before {
+		if involvedBefore[source.id] || afterByID[source.id].id != "" {
+			continue
+		}
+		for j, target := range after {
+			if involvedAfter[target.id] || beforeIDs[target.id] || rematched[target.id] != "" {
+				continue
+			}
+			if overlap[i][j] >= overlapShare*source.area && overlap[i][j] >= overlapShare*target.area {
+				rematched[source.id] = target.id
+				rematched[target.id] = source.id
+				break
+			}
+		}
+	}
+
+	for _, source := range before {
+		if involvedBefore[source.id] {
+			continue
+		}
+		targetID := source.id
+		if id, ok := rematched[source.id]; ok {
+			targetID = id
+		}
+		target, ok := afterByID[targetID]
+		if !ok || involvedAfter[targetID] {
+			out = append(out, RoomChange{ID: source.id, Name: source.name, Change: ChangeRemoved, AreaBefore: area(source), AreaDelta: -area(source)})
+			continue
+		}
+
+		change := RoomChange{ID: target.id, Name: target.name, AreaBefore: area(source), AreaAfter: area(target)}
+		if target.id != source.id {
+			change.IDBefore = source.id
+		}
+		change.AreaDelta = models.Round(change.AreaAfter-change.AreaBefore, 2)
+		if source.name != target.name {
+			change.NameBefore = source.name
+		}
+		switch {
+		case math.Abs(source.area-target.area) > d.tolerance*graph.Perimeter(source.outline):
+			change.Change = ChangeResized
+		case source.name != target.name:
+			change.Change = ChangeRenamed
+		default:
+			continue
+		}
+		out = append(out, change)
+	}
+	for _, target := range after {
+		if involvedAfter[target.id] || beforeIDs[target.id] || rematched[target.id] != "" {
+			continue
+		}
+		out = append(out, RoomChange{ID: target.id, Name: target.name, Change: ChangeAdded, AreaAfter: area(target), AreaDelta: area(target)})
+	}
+	return out
+}
+
+func shapes(layer models.Layer) []roomShape {
+	var out []roomShape
+	for _, id := range models.SortedKeys(layer.Areas) {
+		area := layer.Areas[id]
+		var outline []models.Point
+		for i, vid := range area.Vertices {
+			if i > 0 && i == len(area.Vertices)-1 && vid == area.Vertices[0] {
+				break
+			}
+			if v, ok := layer.Vertices[vid]; ok {
+				outline = append(outline, models.Point{X: v.X, Y: v.Y})
+			}
+		}
+		if len(outline) < 3 {
+			continue
+		}
+		out = append(out, roomShape{id: id, name: area.DisplayName(), outline: outline, area: math.Abs(graph.SignedArea(outline))})
+	}
+	return out
+}
+
+// ============================================================
+// Helpers
+// ============================================================
+
+func pickLayer(scene *models.Scene, layerID string) (models.Layer, error) {
+	id, err := scene.PickLayer(layerID)
+	if err != nil {
+		return models.Layer{}, err
+	}
+	return scene.Layers[id], nil
+}
+
+func midpoint(a, b models.Point) models.Point {
+	return models.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+}
@@ -0,0 +1,261 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Curved walls
+// ============================================================
+
+// ArcMiscKey — ключ в Line.Misc с метаданными дуги.
+const ArcMiscKey = "arc"
+
+const minArcSegments = 2
+const maxArcSegments = 64
+
+// Arc — осевая дуга изогнутой стены. Стена хранится цепочкой прямых линий
+// с общим Group, каждая линия несет эти метаданные в Misc["arc"].
+// Углы в градусах, Sweep > 0 — по возрастанию угла (atan2 в координатах сцены).
+type Arc struct {
+	Group  string
+	Center models.Point
+	Radius float64
+	Start  float64
+	Sweep  float64
+}
+
+// LineArc читает метаданные дуги из линии (в том числе после JSON round-trip).
+func LineArc(line models.Line) (Arc, bool) {
+	raw, ok := line.Misc[ArcMiscKey].(map[string]any)
+	if !ok {
+		return Arc{}, false
+	}
+	group, _ := raw["group"].(string)
+	cx, ok1 := raw["cx"].(float64)
+	cy, ok2 := raw["cy"].(float64)
+	radius, ok3 := raw["radius"].(float64)
+	start, ok4 := raw["start"].(float64)
+	sweep, ok5 := raw["sweep"].(float64)
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || radius <= 0 {
+		return Arc{}, false
+	}
+	return Arc{Group: group, Center: models.Point{X: cx, Y: cy}, Radius: radius, Start: start, Sweep: sweep}, true
+}
+
+// Misc сериализует дугу для Line.Misc.
+func (a Arc) Misc() map[string]any {
+	return map[string]any{
+		"group":  a.Group,
+		"cx":     a.Center.X,
+		"cy":     a.Center.Y,
+		"radius": a.Radius,
+		"start":  a.Start,
+		"sweep":  a.Sweep,
+	}
+}
+
+// PointAt возвращает точку на дуге для угла в градусах.
+func (a Arc) PointAt(angleDeg float64) models.Point {
+	rad := angleDeg * math.Pi / 180
+	return models.Point{X: a.Center.X + a.Radius*math.Cos(rad), Y: a.Center.Y + a.Radius*math.Sin(rad)}
+}
+
+// Param возвращает положение угла вдоль дуги в градусах от Start (в направлении Sweep), [0, 360).
+func (a Arc) Param(p models.Point) float64 {
+	angle := math.Atan2(p.Y-a.Center.Y, p.X-a.Center.X) * 180 / math.Pi
+	u := angle - a.Start
+	if a.Sweep < 0 {
+		u = -u
+	}
+	u = math.Mod(u, 360)
+	if u < 0 {
+		u += 360
+	}
+	return u
+}
+
+// Along — как Param, но диапазон центрирован на середине дуги: точка чуть раньше Start
+// получает небольшое отрицательное значение, а не почти 360.
+func (a Arc) Along(p models.Point) float64 {
+	u := a.Param(p)
+	if u-math.Abs(a.Sweep)/2 > 180 {
+		u -= 360
+	}
+	return u
+}
+
+// addCurvedWall пытается распознать изогнутую стену (контур из двух концентрических дуг).
+// Возвращает false, если контур не похож на дугу — тогда стена строится как прямая.
+func (g *GraphBuilder) addCurvedWall(id string, segments []parser.PathSegment) bool {
+	hasCurve := false
+	for _, seg := range segments {
+		if seg.Kind == parser.SegmentArc || seg.Kind == parser.SegmentCubic || seg.Kind == parser.SegmentQuad {
+			hasCurve = true
+			break
+		}
+	}
+	if !hasCurve {
+		return false
+	}
+
+	// точки только с кривых участков — по ним подбирается окружность
+	var curves [][]models.Point
+	var curvePoints []models.Point
+	for _, seg := range segments {
+		if seg.Kind == parser.SegmentArc || seg.Kind == parser.SegmentCubic || seg.Kind == parser.SegmentQuad {
+			points := append([]models.Point{seg.From}, parser.FlattenSegments([]parser.PathSegment{seg}, g.curveTolerance)...)
+			curves = append(curves, points)
+			curvePoints = append(curvePoints, points...)
+		}
+	}
+	outline := parser.FlattenSegments(segments, g.curveTolerance)
+
+	center, ok := fitCircle(curves)
+	if !ok {
+		return false
+	}
+
+	rMin, rMax := math.MaxFloat64, 0.0
+	for _, p := range outline {
+		r := Distance(p, center)
+		rMin = math.Min(rMin, r)
+		rMax = math.Max(rMax, r)
+	}
+	thickness := rMax - rMin
+	radius := (rMin + rMax) / 2
+	if radius <= 0 || thickness >= radius {
+		return false
+	}
+
+	// точки кривых должны лежать на внутренней или внешней дуге
+	fitTolerance := math.Max(4*g.curveTolerance, thickness/4)
+	for _, p := range curvePoints {
+		r := Distance(p, center)
+		if math.Abs(r-rMin) > fitTolerance && math.Abs(r-rMax) > fitTolerance {
+			return false
+		}
+	}
+
+	start, sweep := angularExtent(outline, center)
+	sagitta := radius * (1 - math.Cos(sweep*math.Pi/360))
+	if sagitta < math.Max(thickness, 2) {
+		return false // почти прямая — пусть строится как обычная стена
+	}
+
+	maxStep := 2 * math.Acos(math.Max(-1, 1-g.curveTolerance/radius)) * 180 / math.Pi
+	n := int(math.Ceil(sweep / maxStep))
+	if n < minArcSegments {
+		n = minArcSegments
+	}
+	if n > maxArcSegments {
+		n = maxArcSegments
+	}
+
+	raw := Arc{Center: center, Radius: radius, Start: start, Sweep: sweep}
+	chain := make([]models.Point, n+1)
+	for i := range chain {
+		chain[i] = g.transform(raw.PointAt(start + sweep*float64(i)/float64(n)))
+	}
+
+	// метаданные дуги пересчитываются в координатах сцены (transform может отражать оси)
+	arc := Arc{Group: id, Center: g.transform(center)}
+	arc.Radius = Distance(arc.Center, chain[0])
+	arc.Start = math.Atan2(chain[0].Y-arc.Center.Y, chain[0].X-arc.Center.X) * 180 / math.Pi
+	arc.Sweep = sweep
+	if arc.Param(chain[n/2]) > 180 {
+		arc.Sweep = -sweep
+	}
+
+	for i := 0; i < n; i++ {
+		g.segments = append(g.segments, wallSegment{
+			id:         fmt.Sprintf("%s_a%d", id, i+1),
+			name:       id,
+			p1:         chain[i],
+			p2:         chain[i+1],
+			properties: defaultWallProperties(thickness),
+			misc:       map[string]any{ArcMiscKey: arc.Misc()},
+		})
+	}
+	return true
+}
+
+// fitCircle — алгебраическая подгонка окружности (метод Каса) по МНК с общим центром:
+// каждая группа точек (отдельная кривая контура) может лежать на своем радиусе,
+// поэтому внешняя и внутренняя дуги стены не смещают центр друг к другу.
+func fitCircle(groups [][]models.Point) (models.Point, bool) {
+	var ox, oy float64
+	total := 0
+	for _, g := range groups {
+		for _, p := range g {
+			ox += p.X
+			oy += p.Y
+			total++
+		}
+	}
+	if total < 3 {
+		return models.Point{}, false
+	}
+	ox /= float64(total)
+	oy /= float64(total)
+
+	// x² + y² = 2a·x + 2b·y + c_k; c_k исключается центрированием каждой группы
+	var sxx, sxy, syy, sxz, syz float64
+	for _, g := range groups {
+		if len(g) < 2 {
+			continue
+		}
+		var mx, my, mz float64
+		for _, p := range g {
+			x, y := p.X-ox, p.Y-oy
+			mx += x
+			my += y
+			mz += x*x + y*y
+		}
+		n := float64(len(g))
+		mx, my, mz = mx/n, my/n, mz/n
+		for _, p := range g {
+			x, y := p.X-ox, p.Y-oy
+			z := x*x + y*y - mz
+			x, y = x-mx, y-my
+			sxx += x * x
+			sxy += x * y
+			syy += y * y
+			sxz += x * z
+			syz += y * z
+		}
+	}
+
+	det := sxx*syy - sxy*sxy
+	if math.Abs(det) < 1e-9*math.Max(1, sxx*syy) {
+		return models.Point{}, false
+	}
+	a := (sxz*syy - syz*sxy) / det / 2
+	b := (syz*sxx - sxz*sxy) / det / 2
+	return models.Point{X: ox + a, Y: oy + b}, true
+}
+
+// angularExtent возвращает начальный угол и размах дуги (в градусах, по возрастанию угла),
+// которую покрывают точки: дуга — дополнение к наибольшему угловому разрыву.
+func angularExtent(points []models.Point, center models.Point) (float64, float64) {
+	angles := make([]float64, 0, len(points))
+	for _, p := range points {
+		angles = append(angles, math.Atan2(p.Y-center.Y, p.X-center.X)*180/math.Pi)
+	}
+	sort.Float64s(angles)
+
+	gapStart := angles[len(angles)-1]
+	gap := angles[0] + 360 - gapStart
+	for i := 1; i < len(angles); i++ {
+		if d := angles[i] - angles[i-1]; d > gap {
+			gap = d
+			gapStart = angles[i-1]
+		}
+	}
+	return gapStart + gap, 360 - gap
+}
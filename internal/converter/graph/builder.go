@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 
 	"api-gateway/internal/converter/models"
 	"api-gateway/internal/converter/parser"
@@ -17,22 +18,32 @@ const tolerance = 2.0         // Tolerance для объединения бли
 const connectTolerance = 15   // Допуск для поиска пересечения и снаппинга
 const mergeTolerance = 8.0    // Радиус склейки близких вершин после разрезания сегментов
 const axisSnapTolerance = 4.0 // Насколько расходиться от оси, чтобы зафиксировать координату
+const angleSnapDegrees = 2.0  // Стены, отклоненные от оси меньше чем на столько градусов, выравниваются по оси
+const roundingSlack = 1e-5    // Запас допусков на округление координат path после калибровки (parser округляет до 1e-6)
 
 type GraphBuilder struct {
-	vertices  map[string]models.Vertex
-	lines     map[string]models.Line
-	segments  []wallSegment
-	vertexID  int
-	transform func(models.Point) models.Point
+	vertices       map[string]models.Vertex
+	lines          map[string]models.Line
+	segments       []wallSegment
+	vertexID       int
+	transform      func(models.Point) models.Point
+	curveTolerance float64
+	unitScale      float64 // единиц сцены на единицу SVG: множитель всех допусков
+	vertexIndex    *GridIndex
+	lineIndex      *GridIndex
 }
 
 func NewGraphBuilder() *GraphBuilder {
 	return &GraphBuilder{
-		vertices:  make(map[string]models.Vertex),
-		lines:     make(map[string]models.Line),
-		segments:  []wallSegment{},
-		vertexID:  0,
-		transform: func(p models.Point) models.Point { return p },
+		vertices:       make(map[string]models.Vertex),
+		lines:          make(map[string]models.Line),
+		segments:       []wallSegment{},
+		vertexID:       0,
+		transform:      func(p models.Point) models.Point { return p },
+		curveTolerance: parser.DefaultCurveTolerance,
+		unitScale:      1,
+		vertexIndex:    NewGridIndex(vertexCellSize),
+		lineIndex:      NewGridIndex(lineCellSize),
 	}
 }
 
@@ -50,12 +61,58 @@ func (g *GraphBuilder) BuildFromWalls(walls []models.SVGElement) error {
 	return nil
 }
 
+// MeasureWall возвращает длину осевой линии элемента так, как ее построит граф
+// (для изогнутой стены — длина ломаной вдоль дуги). Используется для калибровки масштаба.
+func MeasureWall(elem models.SVGElement, curveTolerance float64) (float64, error) {
+	g := NewGraphBuilder()
+	g.SetCurveTolerance(curveTolerance)
+	if err := g.addWall(elem); err != nil {
+		return 0, err
+	}
+
+	length := 0.0
+	for _, seg := range g.segments {
+		length += Distance(seg.p1, seg.p2)
+	}
+	if length == 0 {
+		return 0, fmt.Errorf("element %s has no measurable length", elem.ID)
+	}
+	return length, nil
+}
+
+// DominantAxis возвращает угол преобладающего направления стен в градусах, (-45, 45]:
+// среднее направлений по модулю 90°, взвешенное по длине. Хорды изогнутых стен не учитываются.
+func DominantAxis(walls []models.SVGElement, curveTolerance float64) float64 {
+	g := NewGraphBuilder()
+	g.SetCurveTolerance(curveTolerance)
+	for _, wall := range walls {
+		_ = g.addWall(wall)
+	}
+
+	var sumCos, sumSin float64
+	for _, seg := range g.segments {
+		if _, curved := seg.misc[ArcMiscKey]; curved {
+			continue
+		}
+		length := Distance(seg.p1, seg.p2)
+		angle := math.Atan2(seg.p2.Y-seg.p1.Y, seg.p2.X-seg.p1.X)
+		sumCos += length * math.Cos(4*angle)
+		sumSin += length * math.Sin(4*angle)
+	}
+	if sumCos == 0 && sumSin == 0 {
+		return 0
+	}
+	return math.Atan2(sumSin, sumCos) / 4 * 180 / math.Pi
+}
+
 func (g *GraphBuilder) addWall(wall models.SVGElement) error {
 	switch geom := wall.Geometry.(type) {
 	case models.RectGeometry:
 		return g.addRectWall(wall.ID, geom)
 	case models.PathGeometry:
 		return g.addPathWall(wall.ID, geom)
+	case models.PolygonGeometry:
+		return g.addPointsWall(wall.ID, geom.Points)
 	}
 	return nil
 }
@@ -90,16 +147,60 @@ func (g *GraphBuilder) addRectWall(id string, rect models.RectGeometry) error {
 }
 
 func (g *GraphBuilder) addPathWall(id string, path models.PathGeometry) error {
-	points, err := parser.ParsePath(path.D)
+	segments, err := parser.ParsePathSegments(path.D)
 	if err != nil {
 		return err
 	}
 
+	if g.addCurvedWall(id, segments) {
+		return nil
+	}
+
+	return g.addPointsWall(id, parser.FlattenSegments(segments, g.curveTolerance))
+}
+
+// addPointsWall строит осевую линию стены по контуру (path, polygon, polyline, line).
+// Осевая линия берется из прямоугольника минимальной площади, поэтому диагональные
+// стены сохраняют свой угол; почти осевые стены выравниваются по bounding box.
+func (g *GraphBuilder) addPointsWall(id string, points []models.Point) error {
 	if len(points) < 2 {
 		return nil
 	}
 
-	// Для path берем центр длинной стороны bounding box
+	rect, ok := minAreaRect(points)
+	if !ok {
+		return nil
+	}
+
+	var p1, p2 models.Point
+	thickness := rect.Width
+
+	switch {
+	case rect.Length == 0:
+		p1, p2 = points[0], points[len(points)-1]
+	case axisDeviation(rect.Dir) <= angleSnapDegrees:
+		p1, p2, thickness = axisAlignedCenterline(points)
+	default:
+		half := rect.Length / 2
+		p1 = models.Point{X: rect.Center.X - rect.Dir.X*half, Y: rect.Center.Y - rect.Dir.Y*half}
+		p2 = models.Point{X: rect.Center.X + rect.Dir.X*half, Y: rect.Center.Y + rect.Dir.Y*half}
+	}
+
+	p1 = g.transform(p1)
+	p2 = g.transform(p2)
+
+	g.segments = append(g.segments, wallSegment{
+		id:         id,
+		name:       id,
+		p1:         p1,
+		p2:         p2,
+		properties: defaultWallProperties(thickness),
+	})
+	return nil
+}
+
+// axisAlignedCenterline — центр длинной стороны bounding box (для горизонтальных/вертикальных стен).
+func axisAlignedCenterline(points []models.Point) (models.Point, models.Point, float64) {
 	minX, maxX := points[0].X, points[0].X
 	minY, maxY := points[0].Y, points[0].Y
 	for _, p := range points {
@@ -119,44 +220,31 @@ func (g *GraphBuilder) addPathWall(id string, path models.PathGeometry) error {
 
 	width := maxX - minX
 	height := maxY - minY
-
 	thickness := math.Min(width, height)
-	var p1, p2 models.Point
-	switch {
-	case width == 0 && height == 0:
-		p1, p2 = points[0], points[len(points)-1]
-	case width >= height:
+
+	if width >= height {
 		// горизонтальная: середина по Y, края по X
 		midY := minY + height/2
-		p1 = models.Point{X: minX, Y: midY}
-		p2 = models.Point{X: maxX, Y: midY}
-	default:
-		// вертикальная: середина по X, края по Y
-		midX := minX + width/2
-		p1 = models.Point{X: midX, Y: minY}
-		p2 = models.Point{X: midX, Y: maxY}
+		return models.Point{X: minX, Y: midY}, models.Point{X: maxX, Y: midY}, thickness
 	}
-
-	p1 = g.transform(p1)
-	p2 = g.transform(p2)
-
-	g.segments = append(g.segments, wallSegment{
-		id:         id,
-		name:       id,
-		p1:         p1,
-		p2:         p2,
-		properties: defaultWallProperties(thickness),
-	})
-	return nil
+	// вертикальная: середина по X, края по Y
+	midX := minX + width/2
+	return models.Point{X: midX, Y: minY}, models.Point{X: midX, Y: maxY}, thickness
 }
 
 func (g *GraphBuilder) findOrCreateVertex(p models.Point) string {
-	// Ищем существующую близкую точку
-	for _, v := range g.vertices {
-		if distance(p, models.Point{X: v.X, Y: v.Y}) < tolerance {
-			return v.ID
+	// Ищем существующую близкую точку (ближайшую из попавших в допуск)
+	// допуск строгий: точка ровно на границе допуска — новая вершина
+	nearest, minDist := "", tolerance*g.unitScale-roundingSlack
+	for _, id := range g.vertexIndex.SearchRadius(p, g.tol(tolerance)) {
+		v := g.vertices[id]
+		if d := Distance(p, models.Point{X: v.X, Y: v.Y}); d < minDist {
+			nearest, minDist = id, d
 		}
 	}
+	if nearest != "" {
+		return nearest
+	}
 
 	// Создаем новую вершину
 	g.vertexID++
@@ -171,17 +259,13 @@ func (g *GraphBuilder) findOrCreateVertex(p models.Point) string {
 		Lines:     []string{},
 		Areas:     []string{},
 		Selected:  false,
+		Visible:   true,
 	}
+	g.vertexIndex.InsertPoint(id, p)
 
 	return id
 }
 
-func distance(p1, p2 models.Point) float64 {
-	dx := p1.X - p2.X
-	dy := p1.Y - p2.Y
-	return math.Sqrt(dx*dx + dy*dy)
-}
-
 func (g *GraphBuilder) GetVertices() map[string]models.Vertex {
 	return g.vertices
 }
@@ -190,6 +274,26 @@ func (g *GraphBuilder) GetLines() map[string]models.Line {
 	return g.lines
 }
 
+// LineIndex возвращает пространственный индекс стен построенного графа.
+func (g *GraphBuilder) LineIndex() *GridIndex {
+	return g.lineIndex
+}
+
+// NearestLine находит стену с минимальным dist(line). dist должна быть не меньше
+// расстояния от p до отрезка стены, иначе поиск по индексу может остановиться раньше.
+func (g *GraphBuilder) NearestLine(p models.Point, dist func(line models.Line, v1, v2 models.Vertex) float64) (string, bool) {
+	id, _, ok := g.lineIndex.Nearest(p, func(id string) float64 {
+		line := g.lines[id]
+		v1, ok1 := g.vertices[line.Vertices[0]]
+		v2, ok2 := g.vertices[line.Vertices[1]]
+		if !ok1 || !ok2 {
+			return math.MaxFloat64
+		}
+		return dist(line, v1, v2)
+	})
+	return id, ok
+}
+
 // ============================================================
 // Wall segments connection
 // ============================================================
@@ -200,14 +304,15 @@ type wallSegment struct {
 	p1         models.Point
 	p2         models.Point
 	properties map[string]any
+	misc       map[string]any
 }
 
+// segmentInfo — сегмент в параметрическом виде origin + t*dir, t ∈ [0, length].
 type segmentInfo struct {
 	segment     wallSegment
-	horizontal  bool
-	start       float64
-	end         float64
-	constant    float64
+	origin      models.Point
+	dir         models.Point
+	length      float64
 	splitPoints []float64
 }
 
@@ -216,6 +321,8 @@ func (g *GraphBuilder) reset() {
 	g.lines = make(map[string]models.Line)
 	g.segments = g.segments[:0]
 	g.vertexID = 0
+	g.vertexIndex = NewGridIndex(g.tol(vertexCellSize))
+	g.lineIndex = NewGridIndex(g.tol(lineCellSize))
 }
 
 func (g *GraphBuilder) buildConnectedGraph() {
@@ -232,7 +339,9 @@ func (g *GraphBuilder) buildConnectedGraph() {
 			Prototype:  "lines",
 			Vertices:   []string{v1ID, v2ID},
 			Holes:      []string{},
+			Visible:    true,
 			Properties: seg.properties,
+			Misc:       seg.misc,
 		}
 
 		g.lines[line.ID] = line
@@ -242,8 +351,46 @@ func (g *GraphBuilder) buildConnectedGraph() {
 
 	g.mergeCloseVertices()
 	g.snapAxisAligned()
+	g.rebuildIndexes()
+}
+
+// rebuildIndexes заново индексирует вершины и стены после склейки и выравнивания.
+func (g *GraphBuilder) rebuildIndexes() {
+	vertexIDs := make([]string, 0, len(g.vertices))
+	for id := range g.vertices {
+		vertexIDs = append(vertexIDs, id)
+	}
+	sort.Strings(vertexIDs)
+
+	g.vertexIndex = NewGridIndex(g.tol(vertexCellSize))
+	for _, id := range vertexIDs {
+		v := g.vertices[id]
+		g.vertexIndex.InsertPoint(id, models.Point{X: v.X, Y: v.Y})
+	}
+
+	lineIDs := make([]string, 0, len(g.lines))
+	for id := range g.lines {
+		lineIDs = append(lineIDs, id)
+	}
+	sort.Strings(lineIDs)
+
+	g.lineIndex = NewGridIndex(g.tol(lineCellSize))
+	for _, id := range lineIDs {
+		line := g.lines[id]
+		if len(line.Vertices) < 2 {
+			continue
+		}
+		v1, ok1 := g.vertices[line.Vertices[0]]
+		v2, ok2 := g.vertices[line.Vertices[1]]
+		if !ok1 || !ok2 {
+			continue
+		}
+		g.lineIndex.InsertSegment(id, models.Point{X: v1.X, Y: v1.Y}, models.Point{X: v2.X, Y: v2.Y})
+	}
 }
 
+// splitSegments режет сегменты в точках пересечения под любым углом (T- и X-узлы),
+// допуская недолет/перелет концов до connectTolerance.
 func (g *GraphBuilder) splitSegments(segments []wallSegment) []wallSegment {
 	if len(segments) == 0 {
 		return nil
@@ -251,41 +398,44 @@ func (g *GraphBuilder) splitSegments(segments []wallSegment) []wallSegment {
 
 	infos := make([]*segmentInfo, 0, len(segments))
 	for _, seg := range segments {
-		horizontal := math.Abs(seg.p1.Y-seg.p2.Y) <= math.Abs(seg.p1.X-seg.p2.X)
-		start, end := seg.p1.X, seg.p2.X
-		constant := seg.p1.Y
-
-		if !horizontal {
-			start, end = seg.p1.Y, seg.p2.Y
-			constant = seg.p1.X
+		p1, p2 := seg.p1, seg.p2
+		// ориентируем слева направо (горизонтальные) или сверху вниз (вертикальные)
+		dx, dy := p2.X-p1.X, p2.Y-p1.Y
+		if (math.Abs(dx) >= math.Abs(dy) && dx < 0) || (math.Abs(dx) < math.Abs(dy) && dy < 0) {
+			p1, p2 = p2, p1
+			dx, dy = -dx, -dy
 		}
-		if start > end {
-			start, end = end, start
+
+		length := math.Hypot(dx, dy)
+		dir := models.Point{X: 1}
+		if length > 0 {
+			dir = models.Point{X: dx / length, Y: dy / length}
 		}
 
+		seg.p1, seg.p2 = p1, p2
 		infos = append(infos, &segmentInfo{
 			segment:     seg,
-			horizontal:  horizontal,
-			start:       start,
-			end:         end,
-			constant:    constant,
-			splitPoints: []float64{start, end},
+			origin:      p1,
+			dir:         dir,
+			length:      length,
+			splitPoints: []float64{0, length},
 		})
 	}
 
-	for i := 0; i < len(infos); i++ {
-		for j := i + 1; j < len(infos); j++ {
-			a, b := infos[i], infos[j]
-			if a.horizontal == b.horizontal {
-				continue
-			}
-			var h, v *segmentInfo
-			if a.horizontal {
-				h, v = a, b
-			} else {
-				h, v = b, a
+	// кандидаты на пересечение — сегменты, чьи bbox (с допуском) пересекаются
+	index := NewGridIndex(g.tol(lineCellSize))
+	for i, info := range infos {
+		index.InsertSegment(strconv.Itoa(i), info.segment.p1, info.segment.p2)
+	}
+	connect := g.tol(connectTolerance)
+	for i, info := range infos {
+		a, b := info.segment.p1, info.segment.p2
+		lo := models.Point{X: math.Min(a.X, b.X) - connect, Y: math.Min(a.Y, b.Y) - connect}
+		hi := models.Point{X: math.Max(a.X, b.X) + connect, Y: math.Max(a.Y, b.Y) + connect}
+		for _, id := range index.Search(lo, hi) {
+			if j, _ := strconv.Atoi(id); j > i {
+				g.tryAddIntersection(infos[i], infos[j])
 			}
-			g.tryAddIntersection(h, v)
 		}
 	}
 
@@ -294,7 +444,6 @@ func (g *GraphBuilder) splitSegments(segments []wallSegment) []wallSegment {
 
 	for _, info := range infos {
 		points := append([]float64{}, info.splitPoints...)
-		points = append(points, info.start, info.end)
 
 		sort.Float64s(points)
 		points = uniquePoints(points)
@@ -311,14 +460,8 @@ func (g *GraphBuilder) splitSegments(segments []wallSegment) []wallSegment {
 				continue
 			}
 
-			var p1, p2 models.Point
-			if info.horizontal {
-				p1 = models.Point{X: start, Y: info.constant}
-				p2 = models.Point{X: end, Y: info.constant}
-			} else {
-				p1 = models.Point{X: info.constant, Y: start}
-				p2 = models.Point{X: info.constant, Y: end}
-			}
+			p1 := info.pointAt(start)
+			p2 := info.pointAt(end)
 
 			counter[info.segment.id]++
 			lineID := info.segment.id
@@ -332,6 +475,7 @@ func (g *GraphBuilder) splitSegments(segments []wallSegment) []wallSegment {
 				p1:         p1,
 				p2:         p2,
 				properties: info.segment.properties,
+				misc:       info.segment.misc,
 			})
 		}
 	}
@@ -339,22 +483,40 @@ func (g *GraphBuilder) splitSegments(segments []wallSegment) []wallSegment {
 	return result
 }
 
-func (g *GraphBuilder) tryAddIntersection(h, v *segmentInfo) {
-	vx := v.constant
-	hy := h.constant
+// pointAt возвращает точку сегмента по параметру; концы берутся без погрешности округления.
+func (s *segmentInfo) pointAt(t float64) models.Point {
+	if almostEqual(t, 0) {
+		return s.segment.p1
+	}
+	if almostEqual(t, s.length) {
+		return s.segment.p2
+	}
+	return models.Point{X: s.origin.X + s.dir.X*t, Y: s.origin.Y + s.dir.Y*t}
+}
+
+// tryAddIntersection находит пересечение прямых двух сегментов и, если оно лежит
+// в пределах сегментов (с допуском), добавляет точку разреза в оба.
+func (g *GraphBuilder) tryAddIntersection(a, b *segmentInfo) {
+	denom := a.dir.X*b.dir.Y - a.dir.Y*b.dir.X
+	if math.Abs(denom) < 1e-9 {
+		return // параллельные
+	}
 
-	if vx < h.start-connectTolerance || vx > h.end+connectTolerance {
+	wx := b.origin.X - a.origin.X
+	wy := b.origin.Y - a.origin.Y
+	ta := (wx*b.dir.Y - wy*b.dir.X) / denom
+	tb := (wx*a.dir.Y - wy*a.dir.X) / denom
+
+	connect := g.tol(connectTolerance)
+	if ta < -connect || ta > a.length+connect {
 		return
 	}
-	if hy < v.start-connectTolerance || hy > v.end+connectTolerance {
+	if tb < -connect || tb > b.length+connect {
 		return
 	}
 
-	ix := clamp(vx, h.start, h.end)
-	iy := clamp(hy, v.start, v.end)
-
-	h.splitPoints = append(h.splitPoints, ix)
-	v.splitPoints = append(v.splitPoints, iy)
+	a.splitPoints = append(a.splitPoints, clamp(ta, 0, a.length))
+	b.splitPoints = append(b.splitPoints, clamp(tb, 0, b.length))
 }
 
 func uniquePoints(points []float64) []float64 {
@@ -398,20 +560,27 @@ func (g *GraphBuilder) mergeCloseVertices() {
 
 	rep := make(map[string]string, len(ids))
 
-	for i, id := range ids {
+	index := NewGridIndex(g.tol(vertexCellSize))
+	merge := g.tol(mergeTolerance)
+	for _, id := range ids {
+		v := g.vertices[id]
+		index.InsertPoint(id, models.Point{X: v.X, Y: v.Y})
+	}
+
+	for _, id := range ids {
 		if _, ok := rep[id]; ok {
 			continue
 		}
 		base := g.vertices[id]
 		rep[id] = id
 
-		for j := i + 1; j < len(ids); j++ {
-			otherID := ids[j]
+		// кандидаты в порядке ids; все меньшие ids уже обработаны и есть в rep
+		for _, otherID := range index.SearchRadius(models.Point{X: base.X, Y: base.Y}, merge) {
 			if _, ok := rep[otherID]; ok {
 				continue
 			}
 			other := g.vertices[otherID]
-			if distance(models.Point{X: base.X, Y: base.Y}, models.Point{X: other.X, Y: other.Y}) <= mergeTolerance {
+			if Distance(models.Point{X: base.X, Y: base.Y}, models.Point{X: other.X, Y: other.Y}) <= merge {
 				rep[otherID] = id
 				base.Lines = appendUnique(base.Lines, other.Lines...)
 				base.Areas = appendUnique(base.Areas, other.Areas...)
@@ -457,6 +626,7 @@ func (g *GraphBuilder) mergeCloseVertices() {
 			Lines:     []string{},
 			Areas:     append([]string{}, v.Areas...),
 			Selected:  v.Selected,
+			Visible:   v.Visible,
 			Properties: func() map[string]any {
 				if v.Properties == nil {
 					return nil
@@ -507,18 +677,27 @@ func (g *GraphBuilder) snapAxisAligned() {
 	}
 
 	aggMap := make(map[string]*agg)
+	snap := g.tol(axisSnapTolerance)
 
 	for _, line := range g.lines {
 		if len(line.Vertices) < 2 {
 			continue
 		}
+		if _, curved := LineArc(line); curved {
+			continue // хорды дуги не выравниваются по осям
+		}
 		v1 := g.vertices[line.Vertices[0]]
 		v2 := g.vertices[line.Vertices[1]]
 
 		dx := v1.X - v2.X
 		dy := v1.Y - v2.Y
+		// короткий диагональный отрезок (остаток разбиения наклонной стены) не выравнивается:
+		// иначе сдвинутся общие вершины и стена изогнется
+		if axisDeviation(models.Point{X: dx, Y: dy}) > angleSnapDegrees {
+			continue
+		}
 
-		if math.Abs(dy) <= axisSnapTolerance {
+		if math.Abs(dy) <= snap {
 			targetY := (v1.Y + v2.Y) / 2
 			for _, vid := range line.Vertices {
 				a := aggMap[vid]
@@ -529,7 +708,7 @@ func (g *GraphBuilder) snapAxisAligned() {
 				a.sumY += targetY
 				a.cntY++
 			}
-		} else if math.Abs(dx) <= axisSnapTolerance {
+		} else if math.Abs(dx) <= snap {
 			targetX := (v1.X + v2.X) / 2
 			for _, vid := range line.Vertices {
 				a := aggMap[vid]
@@ -627,3 +806,33 @@ func (g *GraphBuilder) SetTransform(f func(models.Point) models.Point) {
 	}
 	g.transform = f
 }
+
+// SetCurveTolerance задает допуск аппроксимации кривых и дуг в path стен.
+func (g *GraphBuilder) SetCurveTolerance(tolerance float64) {
+	if tolerance <= 0 {
+		tolerance = parser.DefaultCurveTolerance
+	}
+	g.curveTolerance = tolerance
+}
+
+// SetUnitScale задает масштаб стен относительно единиц SVG (калибровка). Допуски склейки,
+// выравнивания и распознавания комнат заданы в единицах SVG и умножаются на масштаб,
+// поэтому топология графа не зависит от калибровки.
+func (g *GraphBuilder) SetUnitScale(scale float64) {
+	if scale <= 0 {
+		scale = 1
+	}
+	g.unitScale = scale
+}
+
+// tol переводит допуск из единиц SVG в единицы сцены. Запас roundingSlack нужен, чтобы
+// расстояния, равные допуску в единицах SVG, не выпадали из него после масштабирования.
+func (g *GraphBuilder) tol(v float64) float64 {
+	return v*g.unitScale + roundingSlack
+}
+
+// areaTol переводит порог площади из квадратных единиц SVG в квадратные единицы сцены.
+// Запас roundingSlack не добавляется: пороги площади на порядки больше погрешности округления.
+func (g *GraphBuilder) areaTol(v float64) float64 {
+	return v * g.unitScale * g.unitScale
+}
@@ -0,0 +1,233 @@
+package graph
+
+import (
+	"math"
+	"sort"
+
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Geometry helpers
+// ============================================================
+
+// orientedRect — прямоугольник минимальной площади вокруг контура.
+// Dir — единичный вектор вдоль длинной стороны.
+type orientedRect struct {
+	Center models.Point
+	Dir    models.Point
+	Length float64
+	Width  float64
+}
+
+// convexHull строит выпуклую оболочку (монотонная цепь Эндрю), обход против часовой стрелки.
+func convexHull(points []models.Point) []models.Point {
+	pts := append([]models.Point{}, points...)
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i].X != pts[j].X {
+			return pts[i].X < pts[j].X
+		}
+		return pts[i].Y < pts[j].Y
+	})
+
+	unique := pts[:0]
+	for i, p := range pts {
+		if i == 0 || p != pts[i-1] {
+			unique = append(unique, p)
+		}
+	}
+	pts = unique
+	if len(pts) < 3 {
+		return pts
+	}
+
+	hull := make([]models.Point, 0, 2*len(pts))
+	for _, p := range pts {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(pts) - 2; i >= 0; i-- {
+		p := pts[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// minAreaRect — вращающиеся калиперы: одна из сторон оптимального прямоугольника
+// лежит на ребре выпуклой оболочки.
+func minAreaRect(points []models.Point) (orientedRect, bool) {
+	hull := convexHull(points)
+	switch len(hull) {
+	case 0:
+		return orientedRect{}, false
+	case 1:
+		return orientedRect{Center: hull[0], Dir: models.Point{X: 1}}, true
+	case 2:
+		length := Distance(hull[0], hull[1])
+		if length == 0 {
+			return orientedRect{Center: hull[0], Dir: models.Point{X: 1}}, true
+		}
+		return orientedRect{
+			Center: models.Point{X: (hull[0].X + hull[1].X) / 2, Y: (hull[0].Y + hull[1].Y) / 2},
+			Dir:    models.Point{X: (hull[1].X - hull[0].X) / length, Y: (hull[1].Y - hull[0].Y) / length},
+			Length: length,
+		}, true
+	}
+
+	best := orientedRect{}
+	bestArea := math.MaxFloat64
+	for i := range hull {
+		a, b := hull[i], hull[(i+1)%len(hull)]
+		edgeLen := Distance(a, b)
+		if edgeLen == 0 {
+			continue
+		}
+		ux, uy := (b.X-a.X)/edgeLen, (b.Y-a.Y)/edgeLen
+
+		minU, maxU := math.MaxFloat64, -math.MaxFloat64
+		minV, maxV := math.MaxFloat64, -math.MaxFloat64
+		for _, p := range hull {
+			u := p.X*ux + p.Y*uy
+			v := -p.X*uy + p.Y*ux
+			minU, maxU = math.Min(minU, u), math.Max(maxU, u)
+			minV, maxV = math.Min(minV, v), math.Max(maxV, v)
+		}
+
+		area := (maxU - minU) * (maxV - minV)
+		if area >= bestArea {
+			continue
+		}
+		bestArea = area
+
+		cu, cv := (minU+maxU)/2, (minV+maxV)/2
+		rect := orientedRect{
+			Center: models.Point{X: cu*ux - cv*uy, Y: cu*uy + cv*ux},
+			Dir:    models.Point{X: ux, Y: uy},
+			Length: maxU - minU,
+			Width:  maxV - minV,
+		}
+		if rect.Width > rect.Length {
+			rect.Dir = models.Point{X: -uy, Y: ux}
+			rect.Length, rect.Width = rect.Width, rect.Length
+		}
+		best = rect
+	}
+	return best, bestArea < math.MaxFloat64
+}
+
+// Footprint — прямоугольник минимальной площади вокруг точек: центр, направление длинной
+// стороны в градусах, длина и ширина (колонны, шахты, лестничные марши).
+func Footprint(points []models.Point) (center models.Point, angle, length, width float64, ok bool) {
+	rect, ok := minAreaRect(points)
+	if !ok {
+		return models.Point{}, 0, 0, 0, false
+	}
+	angle = math.Atan2(rect.Dir.Y, rect.Dir.X) * 180 / math.Pi
+	return rect.Center, angle, rect.Length, rect.Width, true
+}
+
+// axisDeviation — угол (в градусах) между направлением и ближайшей осью.
+func axisDeviation(dir models.Point) float64 {
+	angle := math.Abs(math.Atan2(dir.Y, dir.X)) * 180 / math.Pi
+	angle = math.Mod(angle, 90)
+	return math.Min(angle, 90-angle)
+}
+
+func cross(o, a, b models.Point) float64 {
+	return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+}
+
+// ============================================================
+// Polygon helpers
+// ============================================================
+
+const overlapSamples = 32 // Точек сетки по каждой оси при оценке перекрытия контуров
+
+// Distance — расстояние между точками.
+func Distance(p1, p2 models.Point) float64 {
+	dx := p1.X - p2.X
+	dy := p1.Y - p2.Y
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// SegmentDistance — расстояние от точки до отрезка a-b.
+func SegmentDistance(p, a, b models.Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0 {
+		return Distance(p, a)
+	}
+	t := clamp(((p.X-a.X)*dx+(p.Y-a.Y)*dy)/lenSq, 0, 1)
+	return Distance(p, models.Point{X: a.X + t*dx, Y: a.Y + t*dy})
+}
+
+// SignedArea — площадь по формуле шнурков; знак зависит от направления обхода.
+func SignedArea(points []models.Point) float64 {
+	var sum float64
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		sum += p.X*q.Y - q.X*p.Y
+	}
+	return sum / 2
+}
+
+// Perimeter — длина замкнутого контура.
+func Perimeter(points []models.Point) float64 {
+	var sum float64
+	for i, p := range points {
+		sum += Distance(p, points[(i+1)%len(points)])
+	}
+	return sum
+}
+
+// PointInPolygon — проверка четности пересечений луча.
+func PointInPolygon(p models.Point, polygon []models.Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Bounds — левый верхний и правый нижний углы bbox непустого набора точек.
+func Bounds(points []models.Point) (models.Point, models.Point) {
+	lo, hi := points[0], points[0]
+	for _, p := range points[1:] {
+		lo = models.Point{X: math.Min(lo.X, p.X), Y: math.Min(lo.Y, p.Y)}
+		hi = models.Point{X: math.Max(hi.X, p.X), Y: math.Max(hi.Y, p.Y)}
+	}
+	return lo, hi
+}
+
+// OverlapArea оценивает площадь пересечения контуров по сетке точек в общем bbox.
+func OverlapArea(a, b []models.Point) float64 {
+	minA, maxA := Bounds(a)
+	minB, maxB := Bounds(b)
+	lo := models.Point{X: math.Max(minA.X, minB.X), Y: math.Max(minA.Y, minB.Y)}
+	hi := models.Point{X: math.Min(maxA.X, maxB.X), Y: math.Min(maxA.Y, maxB.Y)}
+	if lo.X >= hi.X || lo.Y >= hi.Y {
+		return 0
+	}
+
+	dx := (hi.X - lo.X) / overlapSamples
+	dy := (hi.Y - lo.Y) / overlapSamples
+	hits := 0
+	for i := 0; i < overlapSamples; i++ {
+		for j := 0; j < overlapSamples; j++ {
+			p := models.Point{X: lo.X + (float64(i)+0.5)*dx, Y: lo.Y + (float64(j)+0.5)*dy}
+			if PointInPolygon(p, a) && PointInPolygon(p, b) {
+				hits++
+			}
+		}
+	}
+	return float64(hits) * dx * dy
+}
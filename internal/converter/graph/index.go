@@ -0,0 +1,210 @@
+package graph
+
+import (
+	"math"
+	"sort"
+
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Spatial index
+// ============================================================
+
+const vertexCellSize = 16.0 // Размер ячейки индекса вершин (больше всех допусков склейки)
+const lineCellSize = 200.0  // Размер ячейки индекса стен (порядок типичной длины стены)
+
+type gridCell struct {
+	x, y int
+}
+
+type gridItem struct {
+	id       string
+	min, max models.Point
+}
+
+// GridIndex — равномерная сетка для поиска точек и отрезков по bbox и ближайшего объекта.
+// Объект регистрируется во всех ячейках, которые пересекает его bbox.
+type GridIndex struct {
+	cellSize float64
+	cells    map[gridCell][]int
+	items    []gridItem
+	minCell  gridCell
+	maxCell  gridCell
+}
+
+// NewGridIndex создает пустой индекс с заданным размером ячейки.
+func NewGridIndex(cellSize float64) *GridIndex {
+	if cellSize <= 0 {
+		cellSize = lineCellSize
+	}
+	return &GridIndex{
+		cellSize: cellSize,
+		cells:    make(map[gridCell][]int),
+	}
+}
+
+// Len возвращает количество объектов в индексе.
+func (ix *GridIndex) Len() int {
+	return len(ix.items)
+}
+
+// InsertPoint добавляет точку.
+func (ix *GridIndex) InsertPoint(id string, p models.Point) {
+	ix.Insert(id, p, p)
+}
+
+// InsertSegment добавляет отрезок по его bbox.
+func (ix *GridIndex) InsertSegment(id string, a, b models.Point) {
+	ix.Insert(id,
+		models.Point{X: math.Min(a.X, b.X), Y: math.Min(a.Y, b.Y)},
+		models.Point{X: math.Max(a.X, b.X), Y: math.Max(a.Y, b.Y)})
+}
+
+// Insert добавляет объект с bbox [min, max].
+func (ix *GridIndex) Insert(id string, min, max models.Point) {
+	idx := len(ix.items)
+	ix.items = append(ix.items, gridItem{id: id, min: min, max: max})
+
+	lo, hi := ix.cellOf(min), ix.cellOf(max)
+	if idx == 0 {
+		ix.minCell, ix.maxCell = lo, hi
+	} else {
+		ix.minCell = gridCell{x: minInt(ix.minCell.x, lo.x), y: minInt(ix.minCell.y, lo.y)}
+		ix.maxCell = gridCell{x: maxInt(ix.maxCell.x, hi.x), y: maxInt(ix.maxCell.y, hi.y)}
+	}
+
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			key := gridCell{x: x, y: y}
+			ix.cells[key] = append(ix.cells[key], idx)
+		}
+	}
+}
+
+// Search возвращает id объектов, чей bbox пересекает [min, max], в порядке добавления.
+func (ix *GridIndex) Search(min, max models.Point) []string {
+	if len(ix.items) == 0 {
+		return nil
+	}
+
+	lo, hi := ix.cellOf(min), ix.cellOf(max)
+	lo = gridCell{x: maxInt(lo.x, ix.minCell.x), y: maxInt(lo.y, ix.minCell.y)}
+	hi = gridCell{x: minInt(hi.x, ix.maxCell.x), y: minInt(hi.y, ix.maxCell.y)}
+
+	seen := make(map[int]bool)
+	var found []int
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			for _, idx := range ix.cells[gridCell{x: x, y: y}] {
+				if seen[idx] {
+					continue
+				}
+				seen[idx] = true
+				item := ix.items[idx]
+				if item.max.X < min.X || item.min.X > max.X || item.max.Y < min.Y || item.min.Y > max.Y {
+					continue
+				}
+				found = append(found, idx)
+			}
+		}
+	}
+	sort.Ints(found)
+
+	ids := make([]string, len(found))
+	for i, idx := range found {
+		ids[i] = ix.items[idx].id
+	}
+	return ids
+}
+
+// SearchRadius возвращает id объектов, чей bbox пересекает квадрат со стороной 2*radius вокруг p.
+func (ix *GridIndex) SearchRadius(p models.Point, radius float64) []string {
+	return ix.Search(models.Point{X: p.X - radius, Y: p.Y - radius}, models.Point{X: p.X + radius, Y: p.Y + radius})
+}
+
+// Nearest находит объект с минимальным dist(id), обходя ячейки кольцами от точки p.
+// dist должна возвращать расстояние от p до объекта (не меньше расстояния до его bbox).
+// При равных расстояниях побеждает меньший id, чтобы результат не зависел от порядка.
+func (ix *GridIndex) Nearest(p models.Point, dist func(id string) float64) (string, float64, bool) {
+	if len(ix.items) == 0 {
+		return "", 0, false
+	}
+
+	center := ix.cellOf(p)
+	best, bestDist := "", math.MaxFloat64
+	seen := make(map[int]bool)
+
+	visit := func(cell gridCell) {
+		for _, idx := range ix.cells[cell] {
+			if seen[idx] {
+				continue
+			}
+			seen[idx] = true
+			id := ix.items[idx].id
+			d := dist(id)
+			if d < bestDist || (d == bestDist && id < best) {
+				best, bestDist = id, d
+			}
+		}
+	}
+
+	// первое кольцо, которое может пересечь занятую область сетки
+	k := maxInt(0, maxInt(
+		maxInt(ix.minCell.x-center.x, center.x-ix.maxCell.x),
+		maxInt(ix.minCell.y-center.y, center.y-ix.maxCell.y)))
+
+	for ; ; k++ {
+		x0, x1 := maxInt(center.x-k, ix.minCell.x), minInt(center.x+k, ix.maxCell.x)
+		y0, y1 := maxInt(center.y-k, ix.minCell.y), minInt(center.y+k, ix.maxCell.y)
+
+		for x := x0; x <= x1; x++ {
+			if center.y-k >= ix.minCell.y {
+				visit(gridCell{x: x, y: center.y - k})
+			}
+			if k > 0 && center.y+k <= ix.maxCell.y {
+				visit(gridCell{x: x, y: center.y + k})
+			}
+		}
+		for y := maxInt(y0, center.y-k+1); y <= minInt(y1, center.y+k-1); y++ {
+			if center.x-k >= ix.minCell.x {
+				visit(gridCell{x: center.x - k, y: y})
+			}
+			if k > 0 && center.x+k <= ix.maxCell.x {
+				visit(gridCell{x: center.x + k, y: y})
+			}
+		}
+
+		// все непросмотренные ячейки дальше k*cellSize от p
+		if best != "" && bestDist <= float64(k)*ix.cellSize {
+			break
+		}
+		if center.x-k <= ix.minCell.x && center.x+k >= ix.maxCell.x &&
+			center.y-k <= ix.minCell.y && center.y+k >= ix.maxCell.y {
+			break
+		}
+	}
+
+	return best, bestDist, best != ""
+}
+
+func (ix *GridIndex) cellOf(p models.Point) gridCell {
+	return gridCell{
+		x: int(math.Floor(p.X / ix.cellSize)),
+		y: int(math.Floor(p.Y / ix.cellSize)),
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// GridIndex
+// ============================================================
+
+func TestGridIndexMatchesLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ix := NewGridIndex(50)
+
+	type segment struct {
+		id   string
+		a, b models.Point
+	}
+	var segments []segment
+	for i := 0; i < 500; i++ {
+		a := models.Point{X: rng.Float64()*2000 - 500, Y: rng.Float64()*2000 - 500}
+		b := models.Point{X: a.X + rng.NormFloat64()*150, Y: a.Y + rng.NormFloat64()*150}
+		s := segment{id: fmt.Sprintf("s%03d", i), a: a, b: b}
+		segments = append(segments, s)
+		ix.InsertSegment(s.id, s.a, s.b)
+	}
+	byID := make(map[string]segment, len(segments))
+	for _, s := range segments {
+		byID[s.id] = s
+	}
+	segmentDist := func(p models.Point, s segment) float64 {
+		return SegmentDistance(p, s.a, s.b)
+	}
+
+	for q := 0; q < 300; q++ {
+		// часть запросов — за пределами занятой области сетки
+		p := models.Point{X: rng.Float64()*4000 - 1500, Y: rng.Float64()*4000 - 1500}
+		radius := rng.Float64() * 300
+
+		var want []string
+		for _, s := range segments {
+			if math.Max(s.a.X, s.b.X) < p.X-radius || math.Min(s.a.X, s.b.X) > p.X+radius ||
+				math.Max(s.a.Y, s.b.Y) < p.Y-radius || math.Min(s.a.Y, s.b.Y) > p.Y+radius {
+				continue
+			}
+			want = append(want, s.id)
+		}
+		if got := ix.SearchRadius(p, radius); !slices.Equal(got, want) {
+			t.Fatalf("SearchRadius(%v, %g) = %v, want %v", p, radius, got, want)
+		}
+
+		wantID, wantDist := "", math.MaxFloat64
+		for _, s := range segments {
+			if d := segmentDist(p, s); d < wantDist {
+				wantID, wantDist = s.id, d
+			}
+		}
+		gotID, gotDist, ok := ix.Nearest(p, func(id string) float64 { return segmentDist(p, byID[id]) })
+		if !ok || gotID != wantID || gotDist != wantDist {
+			t.Fatalf("Nearest(%v) = %s %g, want %s %g", p, gotID, gotDist, wantID, wantDist)
+		}
+	}
+}
+
+// ============================================================
+// Benchmarks
+// ============================================================
+
+// syntheticPlan — сетка n×n помещений: каждая сторона ячейки — отдельная стена,
+// концы смещены на доли единицы, чтобы работали склейка и выравнивание.
+func syntheticPlan(n int) []models.SVGElement {
+	const cell, thickness = 300.0, 10.0
+	rng := rand.New(rand.NewSource(1))
+	jitter := func() float64 { return rng.Float64() - 0.5 }
+
+	var walls []models.SVGElement
+	for i := 0; i <= n; i++ {
+		for j := 0; j < n; j++ {
+			walls = append(walls,
+				models.SVGElement{ID: fmt.Sprintf("H_%d_%d", i, j), Type: "wall", Tag: "rect", Geometry: models.RectGeometry{
+					X: float64(j)*cell + jitter(), Y: float64(i)*cell - thickness/2 + jitter(), Width: cell, Height: thickness,
+				}},
+				models.SVGElement{ID: fmt.Sprintf("V_%d_%d", i, j), Type: "wall", Tag: "rect", Geometry: models.RectGeometry{
+					X: float64(i)*cell - thickness/2 + jitter(), Y: float64(j)*cell + jitter(), Width: thickness, Height: cell,
+				}})
+		}
+	}
+	return walls
+}
+
+func BenchmarkBuildFromWalls10k(b *testing.B) {
+	walls := syntheticPlan(70) // 9940 стен
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewGraphBuilder().BuildFromWalls(walls); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNearestLine(b *testing.B) {
+	g := NewGraphBuilder()
+	if err := g.BuildFromWalls(syntheticPlan(70)); err != nil {
+		b.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	points := make([]models.Point, 1024)
+	for i := range points {
+		points[i] = models.Point{X: rng.Float64() * 21000, Y: rng.Float64() * 21000}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := points[i%len(points)]
+		g.NearestLine(p, func(_ models.Line, v1, v2 models.Vertex) float64 {
+			return SegmentDistance(p, models.Point{X: v1.X, Y: v1.Y}, models.Point{X: v2.X, Y: v2.Y})
+		})
+	}
+}
+
+func BenchmarkMergeCloseVertices(b *testing.B) {
+	walls := syntheticPlan(70)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		g := NewGraphBuilder()
+		for _, wall := range walls {
+			if err := g.addWall(wall); err != nil {
+				b.Fatal(err)
+			}
+		}
+		for _, seg := range g.segments {
+			v1, v2 := g.findOrCreateVertex(seg.p1), g.findOrCreateVertex(seg.p2)
+			g.lines[seg.id] = models.Line{ID: seg.id, Vertices: []string{v1, v2}}
+			g.attachLineToVertex(v1, seg.id)
+			g.attachLineToVertex(v2, seg.id)
+		}
+		b.StartTimer()
+		g.mergeCloseVertices()
+	}
+}
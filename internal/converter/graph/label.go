@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"container/heap"
+	"math"
+
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Pole of inaccessibility
+// ============================================================
+
+// labelCell — квадрат поиска: центр, полуразмер, расстояние центра до границы
+// (отрицательное снаружи) и верхняя оценка расстояния внутри квадрата.
+type labelCell struct {
+	center models.Point
+	half   float64
+	dist   float64
+	max    float64
+}
+
+func newLabelCell(center models.Point, half float64, polygon []models.Point) labelCell {
+	dist := signedBoundaryDistance(center, polygon)
+	return labelCell{center: center, half: half, dist: dist, max: dist + half*math.Sqrt2}
+}
+
+type labelQueue []labelCell
+
+func (q labelQueue) Len() int           { return len(q) }
+func (q labelQueue) Less(i, j int) bool { return q[i].max > q[j].max }
+func (q labelQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *labelQueue) Push(x any)        { *q = append(*q, x.(labelCell)) }
+func (q *labelQueue) Pop() any {
+	old := *q
+	cell := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return cell
+}
+
+// PoleOfInaccessibility — точка внутри многоугольника, наиболее удаленная от его границы,
+// и расстояние от нее до границы (место подписи комнаты). Поиск делит bounding box на квадраты
+// и отбрасывает те, в которых точка дальше найденной не может лежать; precision — допуск
+// расстояния в единицах контура.
+func PoleOfInaccessibility(polygon []models.Point, precision float64) (models.Point, float64) {
+	if len(polygon) == 0 {
+		return models.Point{}, 0
+	}
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, p := range polygon {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	size := math.Min(maxX-minX, maxY-minY)
+	if size <= 0 {
+		return models.Point{X: (minX + maxX) / 2, Y: (minY + maxY) / 2}, 0
+	}
+	if precision <= 0 {
+		precision = size / 100
+	}
+
+	// начальные кандидаты: центроид и центр bounding box
+	best := newLabelCell(polygonCentroid(polygon), 0, polygon)
+	if size <= precision { // контур уже допуска: уточнять нечего
+		return best.center, math.Max(best.dist, 0)
+	}
+	if box := newLabelCell(models.Point{X: (minX + maxX) / 2, Y: (minY + maxY) / 2}, 0, polygon); box.dist > best.dist {
+		best = box
+	}
+
+	// Начальная сетка не мельче 64 квадратов по длинной стороне: у узкой длинной полосы
+	// квадраты со стороной в ее ширину исчисляются миллионами.
+	cell := math.Max(size, math.Max(maxX-minX, maxY-minY)/64)
+	queue := &labelQueue{}
+	half := cell / 2
+	for x := minX; x < maxX; x += cell {
+		for y := minY; y < maxY; y += cell {
+			heap.Push(queue, newLabelCell(models.Point{X: x + half, Y: y + half}, half, polygon))
+		}
+	}
+
+	for queue.Len() > 0 {
+		cell := heap.Pop(queue).(labelCell)
+		if cell.dist > best.dist {
+			best = cell
+		}
+		if cell.max-best.dist <= precision {
+			continue
+		}
+		h := cell.half / 2
+		for _, d := range []models.Point{{X: -h, Y: -h}, {X: h, Y: -h}, {X: -h, Y: h}, {X: h, Y: h}} {
+			heap.Push(queue, newLabelCell(models.Point{X: cell.center.X + d.X, Y: cell.center.Y + d.Y}, h, polygon))
+		}
+	}
+	return best.center, math.Max(best.dist, 0)
+}
+
+// signedBoundaryDistance — расстояние от точки до границы многоугольника: положительное внутри.
+func signedBoundaryDistance(p models.Point, polygon []models.Point) float64 {
+	inside := false
+	minDist := math.MaxFloat64
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+		minDist = math.Min(minDist, SegmentDistance(p, a, b))
+	}
+	if !inside {
+		return -minDist
+	}
+	return minDist
+}
+
+// polygonCentroid — центр масс многоугольника; вырожденный — среднее вершин.
+func polygonCentroid(polygon []models.Point) models.Point {
+	var area, cx, cy float64
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[j], polygon[i]
+		f := a.X*b.Y - b.X*a.Y
+		area += f
+		cx += (a.X + b.X) * f
+		cy += (a.Y + b.Y) * f
+	}
+	if math.Abs(area) < 1e-9 {
+		var sx, sy float64
+		for _, p := range polygon {
+			sx, sy = sx+p.X, sy+p.Y
+		}
+		n := float64(len(polygon))
+		return models.Point{X: sx / n, Y: sy / n}
+	}
+	return models.Point{X: cx / (3 * area), Y: cy / (3 * area)}
+}
@@ -0,0 +1,26 @@
+package graph
+
+import (
+	"math"
+	"testing"
+
+	"api-gateway/internal/converter/models"
+)
+
+func TestPoleOfInaccessibility(t *testing.T) {
+	// Г-образная комната: точка подписи — в квадрате 200×200 у внутреннего угла, а не в центроиде
+	room := []models.Point{{X: 0, Y: 0}, {X: 400, Y: 0}, {X: 400, Y: 200}, {X: 200, Y: 200}, {X: 200, Y: 400}, {X: 0, Y: 400}}
+	pole, dist := PoleOfInaccessibility(room, 1)
+	if dist < 99 || !PointInPolygon(pole, room) {
+		t.Errorf("L-shaped room: pole %v at %g from boundary, want ~100 inside", pole, dist)
+	}
+
+	// Узкие полосы длиной 1000 не должны раскладываться на миллионы начальных квадратов
+	for _, width := range []float64{0.01, 0.0001} {
+		sliver := []models.Point{{X: 0, Y: 0}, {X: 1000, Y: 0}, {X: 1000, Y: width}, {X: 0, Y: width}}
+		pole, dist := PoleOfInaccessibility(sliver, 0.01)
+		if math.Abs(pole.X-500) > 1 || math.Abs(dist-width/2) > 1e-9 {
+			t.Errorf("sliver %g: pole %v at %g from boundary", width, pole, dist)
+		}
+	}
+}
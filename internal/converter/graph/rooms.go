@@ -0,0 +1,400 @@
+package graph
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Room detection
+// ============================================================
+
+const minRoomArea = 5000.0                  // Грани меньше 0.5 м² (шахты, колонны) не считаются комнатами
+const minRoomWidth = 40.0                   // Средняя ширина грани 2·S/P; меньше — щель между параллельными стенами
+const bridgeTolerance = 20.0                // Допуск положения концов разорванной стены относительно проема
+const gapTolerance = 2.0 * connectTolerance // Висячий конец стены замыкается на вершину не дальше этого
+
+// Opening — проем (дверь, окно) в координатах сцены. Если стена в месте проема
+// разорвана, концы разрыва соединяются мнимым ребром, чтобы контур комнаты замкнулся.
+type Opening struct {
+	ID     string
+	Center models.Point
+	Angle  float64 // направление стены в градусах
+	Width  float64
+}
+
+// Room — замкнутая грань графа стен: контур комнаты по осям стен.
+type Room struct {
+	Vertices []string // кольцо id вершин без повтора первой
+	Points   []models.Point
+	Area     float64  // площадь за вычетом вложенных контуров (колонн, шахт)
+	Label    string   // подпись из SVG, попавшая внутрь контура
+	Bridges  []string // id проемов, замкнувших контур
+}
+
+type edgeKey struct {
+	a, b string
+}
+
+func makeEdgeKey(a, b string) edgeKey {
+	if a > b {
+		a, b = b, a
+	}
+	return edgeKey{a: a, b: b}
+}
+
+// DetectRooms находит комнаты как внутренние грани планарного графа стен.
+// Разрывы стен в местах проемов замыкаются, висячие стены отбрасываются, из подписей
+// внутри грани выбирается название комнаты. Комнаты упорядочены сверху вниз, слева направо.
+func (g *GraphBuilder) DetectRooms(openings []Opening, labels []models.TextLabel) []Room {
+	adj := make(map[string]map[string]bool)
+	addEdge := func(a, b string) {
+		if adj[a] == nil {
+			adj[a] = make(map[string]bool)
+		}
+		if adj[b] == nil {
+			adj[b] = make(map[string]bool)
+		}
+		adj[a][b] = true
+		adj[b][a] = true
+	}
+
+	for _, id := range sortedLineIDs(g.lines) {
+		line := g.lines[id]
+		if len(line.Vertices) < 2 || line.Vertices[0] == line.Vertices[1] {
+			continue
+		}
+		_, ok1 := g.vertices[line.Vertices[0]]
+		_, ok2 := g.vertices[line.Vertices[1]]
+		if ok1 && ok2 {
+			addEdge(line.Vertices[0], line.Vertices[1])
+		}
+	}
+
+	bridges := make(map[edgeKey]string)
+	for _, opening := range openings {
+		if a, b, ok := g.bridgeOpening(opening, adj); ok {
+			addEdge(a, b)
+			bridges[makeEdgeKey(a, b)] = opening.ID
+		}
+	}
+
+	g.closeGaps(adj)
+	pruneDangling(adj)
+
+	var rooms, outlines []Room
+	for _, face := range g.traceFaces(adj) {
+		points := make([]models.Point, len(face))
+		for i, id := range face {
+			v := g.vertices[id]
+			points[i] = models.Point{X: v.X, Y: v.Y}
+		}
+		area := SignedArea(points)
+		if area < 0 {
+			// внешний контур связной компоненты: может оказаться колонной внутри комнаты
+			outlines = append(outlines, Room{Vertices: face, Points: points, Area: -area})
+			continue
+		}
+		room := Room{Vertices: face, Points: points, Area: area}
+		for i := range face {
+			if id, ok := bridges[makeEdgeKey(face[i], face[(i+1)%len(face)])]; ok {
+				room.Bridges = appendUnique(room.Bridges, id)
+			}
+		}
+		rooms = append(rooms, room)
+	}
+
+	// вложенные компоненты вычитаются из наименьшей охватывающей грани
+	for _, outline := range outlines {
+		best := -1
+		for i, room := range rooms {
+			if room.Area > outline.Area && PointInPolygon(outline.Points[0], room.Points) &&
+				(best < 0 || room.Area < rooms[best].Area) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			rooms[best].Area -= outline.Area
+		}
+	}
+
+	var out []Room
+	for _, room := range rooms {
+		if room.Area < g.areaTol(minRoomArea) || 2*room.Area/Perimeter(room.Points) < g.tol(minRoomWidth) {
+			continue
+		}
+		out = append(out, room)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		bi, bj := boundsOf(out[i].Points), boundsOf(out[j].Points)
+		if bi.Y != bj.Y {
+			return bi.Y < bj.Y
+		}
+		return bi.X < bj.X
+	})
+
+	matchLabels(out, labels)
+	return out
+}
+
+// AttachAreaVertices регистрирует area в списке areas существующих вершин.
+func (g *GraphBuilder) AttachAreaVertices(vertexIDs []string, areaID string) {
+	for _, id := range vertexIDs {
+		vertex, ok := g.vertices[id]
+		if !ok {
+			continue
+		}
+		vertex.Areas = appendUnique(vertex.Areas, areaID)
+		g.vertices[id] = vertex
+	}
+}
+
+// bridgeOpening ищет концы разорванной стены по обе стороны проема. Если проем лежит
+// на сплошной стене, мост не нужен. Хотя бы один конец должен быть висячим.
+func (g *GraphBuilder) bridgeOpening(opening Opening, adj map[string]map[string]bool) (string, string, bool) {
+	if opening.Width <= 0 {
+		return "", "", false
+	}
+	_, dist, ok := g.lineIndex.Nearest(opening.Center, func(id string) float64 {
+		line := g.lines[id]
+		v1, v2 := g.vertices[line.Vertices[0]], g.vertices[line.Vertices[1]]
+		return SegmentDistance(opening.Center, models.Point{X: v1.X, Y: v1.Y}, models.Point{X: v2.X, Y: v2.Y})
+	})
+	if ok && dist < opening.Width/4 {
+		return "", "", false
+	}
+
+	rad := opening.Angle * math.Pi / 180
+	dir := models.Point{X: math.Cos(rad), Y: math.Sin(rad)}
+	tolerance := g.tol(bridgeTolerance)
+	reach := opening.Width/2 + tolerance
+
+	type candidate struct {
+		id     string
+		t, n   float64
+		hanged bool
+	}
+	var left, right []candidate
+	for _, id := range g.vertexIndex.SearchRadius(opening.Center, reach) {
+		v := g.vertices[id]
+		dx, dy := v.X-opening.Center.X, v.Y-opening.Center.Y
+		t := dx*dir.X + dy*dir.Y
+		n := math.Abs(dx*dir.Y - dy*dir.X)
+		if n > tolerance || math.Abs(t) > reach || len(adj[id]) == 0 {
+			continue
+		}
+		c := candidate{id: id, t: t, n: n, hanged: len(adj[id]) == 1}
+		if t < 0 {
+			left = append(left, c)
+		} else if t > 0 {
+			right = append(right, c)
+		}
+	}
+
+	bestA, bestB, bestCost := "", "", math.MaxFloat64
+	for _, a := range left {
+		for _, b := range right {
+			if !a.hanged && !b.hanged || adj[a.id][b.id] {
+				continue
+			}
+			cost := a.n + b.n + math.Abs(b.t-a.t-opening.Width)
+			if cost < bestCost {
+				bestA, bestB, bestCost = a.id, b.id, cost
+			}
+		}
+	}
+	return bestA, bestB, bestA != ""
+}
+
+// closeGaps соединяет висячие концы стен с ближайшей вершиной в пределах gapTolerance:
+// стена, доведенная до грани соседней стены, а не до ее оси, иначе оставляет контур открытым.
+func (g *GraphBuilder) closeGaps(adj map[string]map[string]bool) {
+	var hanging []string
+	for id, next := range adj {
+		if len(next) == 1 {
+			hanging = append(hanging, id)
+		}
+	}
+	sort.Strings(hanging)
+
+	for _, id := range hanging {
+		if len(adj[id]) != 1 {
+			continue // уже соединен с другим висячим концом
+		}
+		v := g.vertices[id]
+		p := models.Point{X: v.X, Y: v.Y}
+
+		best, bestDist := "", g.tol(gapTolerance)
+		for _, other := range g.vertexIndex.SearchRadius(p, g.tol(gapTolerance)) {
+			if other == id || adj[id][other] || len(adj[other]) == 0 {
+				continue
+			}
+			w := g.vertices[other]
+			if d := Distance(p, models.Point{X: w.X, Y: w.Y}); d <= bestDist {
+				best, bestDist = other, d
+			}
+		}
+		if best != "" {
+			adj[id][best] = true
+			adj[best][id] = true
+		}
+	}
+}
+
+// pruneDangling удаляет висячие ребра (перегородки, не замыкающие контур).
+func pruneDangling(adj map[string]map[string]bool) {
+	var queue []string
+	for id, next := range adj {
+		if len(next) <= 1 {
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for other := range adj[id] {
+			delete(adj[other], id)
+			if len(adj[other]) == 1 {
+				queue = append(queue, other)
+			}
+		}
+		delete(adj, id)
+	}
+}
+
+// traceFaces обходит все грани: из ребра (u→v) переходим в ребро, ближайшее к (v→u)
+// по часовой стрелке. Внутренние грани получаются с положительной площадью, внешние — с отрицательной.
+func (g *GraphBuilder) traceFaces(adj map[string]map[string]bool) [][]string {
+	ids := make([]string, 0, len(adj))
+	for id := range adj {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	order := make(map[string][]string, len(adj))
+	for _, id := range ids {
+		v := g.vertices[id]
+		next := make([]string, 0, len(adj[id]))
+		for other := range adj[id] {
+			next = append(next, other)
+		}
+		angle := func(other string) float64 {
+			w := g.vertices[other]
+			return math.Atan2(w.Y-v.Y, w.X-v.X)
+		}
+		sort.Slice(next, func(i, j int) bool {
+			ai, aj := angle(next[i]), angle(next[j])
+			if ai != aj {
+				return ai < aj
+			}
+			return next[i] < next[j]
+		})
+		order[id] = next
+	}
+
+	visited := make(map[[2]string]bool)
+	var faces [][]string
+	for _, start := range ids {
+		for _, first := range order[start] {
+			if visited[[2]string{start, first}] {
+				continue
+			}
+			var face []string
+			u, v := start, first
+			for !visited[[2]string{u, v}] {
+				visited[[2]string{u, v}] = true
+				face = append(face, u)
+				next := order[v]
+				i := indexOf(next, u)
+				u, v = v, next[(i-1+len(next))%len(next)]
+			}
+			if len(face) >= 3 {
+				faces = append(faces, face)
+			}
+		}
+	}
+	return faces
+}
+
+// matchLabels выбирает для каждой комнаты подпись внутри контура, ближайшую к центру.
+// Подписи без букв (площадь, номер помещения) используются, только если других нет.
+func matchLabels(rooms []Room, labels []models.TextLabel) {
+	used := make(map[int]bool)
+	for i := range rooms {
+		center := averageOf(rooms[i].Points)
+		best, bestScore := -1, math.MaxFloat64
+		for j, label := range labels {
+			if used[j] || !PointInPolygon(label.Position, rooms[i].Points) {
+				continue
+			}
+			score := Distance(label.Position, center)
+			if !hasLetters(label.Text) {
+				score += 1e9
+			}
+			if score < bestScore {
+				best, bestScore = j, score
+			}
+		}
+		if best >= 0 {
+			used[best] = true
+			rooms[i].Label = labels[best].Text
+		}
+	}
+}
+
+func hasLetters(s string) bool {
+	// единицы площади вида "м²" не считаются названием
+	s = strings.NewReplacer("м²", "", "m²", "", "кв.м", "", "sq", "").Replace(s)
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Polygon helpers
+// ============================================================
+
+func boundsOf(points []models.Point) models.Point {
+	min := points[0]
+	for _, p := range points[1:] {
+		min.X = math.Min(min.X, p.X)
+		min.Y = math.Min(min.Y, p.Y)
+	}
+	return min
+}
+
+func averageOf(points []models.Point) models.Point {
+	var sx, sy float64
+	for _, p := range points {
+		sx += p.X
+		sy += p.Y
+	}
+	n := float64(len(points))
+	return models.Point{X: sx / n, Y: sy / n}
+}
+
+func indexOf(list []string, target string) int {
+	for i, item := range list {
+		if item == target {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedLineIDs(lines map[string]models.Line) []string {
+	ids := make([]string, 0, len(lines))
+	for id := range lines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+
+	"api-gateway/internal/converter/analysis"
+	"api-gateway/internal/converter/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// ============================================================
+// Analyze Handler
+// ============================================================
+
+// AnalyzeScene считает экспликацию помещений react-planner JSON: площади, периметры,
+// проемы и смежность комнат, летние помещения с коэффициентами, длины стен по толщине.
+func AnalyzeScene(c fiber.Ctx) error {
+	log.Printf("[ANALYZE] Received request")
+	log.Printf("[ANALYZE] Content-Length: %d", len(c.Body()))
+
+	if len(c.Body()) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "body required",
+		})
+	}
+
+	var scene models.Scene
+	if err := json.Unmarshal(c.Body(), &scene); err != nil {
+		log.Printf("[ANALYZE] Decode error: %v", err)
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid JSON payload",
+		})
+	}
+
+	opts := analysis.Options{Layer: c.Query("layer"), Coefficients: analysis.DefaultCoefficients()}
+	for kind := range opts.Coefficients {
+		raw := c.Query(kind + "_coefficient")
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": kind + "_coefficient must be a non-negative number",
+			})
+		}
+		opts.Coefficients[kind] = value
+	}
+
+	report, err := analysis.Analyze(&scene, opts)
+	if err != nil {
+		log.Printf("[ANALYZE] Analyze error: %v", err)
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(report)
+}
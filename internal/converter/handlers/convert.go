@@ -2,10 +2,16 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"api-gateway/internal/converter/mapper"
+	"api-gateway/internal/converter/parser"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -14,51 +20,372 @@ import (
 // Convert Handler
 // ============================================================
 
-// ConvertSVG конвертирует SVG в react-planner JSON
-func ConvertSVG(c fiber.Ctx) error {
-	log.Printf("[CONVERTER] Received request")
-	log.Printf("[CONVERTER] Content-Type: %s", c.Get("Content-Type"))
-	log.Printf("[CONVERTER] Content-Length: %d", len(c.Body()))
+// ConvertSVG конвертирует SVG в react-planner JSON. defaultRules используются,
+// если в запросе не переданы собственные правила классификации; catalog сопоставляет
+// сантехнику и мебель items каталога react-planner.
+func ConvertSVG(defaultRules *parser.RuleSet, catalog *mapper.Catalog) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		log.Printf("[CONVERTER] Received request")
+		log.Printf("[CONVERTER] Content-Type: %s", c.Get("Content-Type"))
+		log.Printf("[CONVERTER] Content-Length: %d", len(c.Body()))
 
-	// Получаем файл из multipart/form-data
+		sources, err := readSVGFiles(c)
+		if err != nil {
+			return errorJSON(c, err)
+		}
+
+		rules, err := requestRules(c, defaultRules)
+		if err != nil {
+			return errorJSON(c, err)
+		}
+
+		// Конвертируем
+		log.Printf("[CONVERTER] Starting conversion, documents: %d", len(sources))
+		converter := mapper.New()
+		converter.SetRules(rules)
+		converter.SetCatalog(catalog)
+		if raw := c.FormValue("curve_tolerance"); raw != "" {
+			tolerance, err := strconv.ParseFloat(raw, 64)
+			if err != nil || tolerance <= 0 {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "curve_tolerance must be a positive number",
+				})
+			}
+			converter.SetCurveTolerance(tolerance)
+		}
+		if raw := c.FormValue("hole_snap"); raw != "" {
+			snap, err := strconv.ParseFloat(raw, 64)
+			if err != nil || snap < 0 {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "hole_snap must be a non-negative number",
+				})
+			}
+			converter.SetHoleSnap(snap)
+		}
+
+		calibration, err := requestCalibration(c)
+		if err != nil {
+			return errorJSON(c, err)
+		}
+		converter.SetCalibration(calibration)
+
+		placement, err := requestPlacement(c)
+		if err != nil {
+			return errorJSON(c, err)
+		}
+		converter.SetPlacement(placement)
+
+		if mode := c.FormValue("detect_rooms"); mode != "" {
+			if mode != mapper.RoomDetectionAuto && mode != mapper.RoomDetectionOff {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "detect_rooms must be auto or off",
+				})
+			}
+			converter.SetRoomDetection(mode)
+		}
+
+		floors, err := requestFloors(c)
+		if err != nil {
+			return errorJSON(c, err)
+		}
+		converter.SetFloors(floors)
+
+		scene, err := converter.ConvertFloors(sources)
+		if errors.Is(err, mapper.ErrCalibration) || errors.Is(err, mapper.ErrFloors) {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err != nil {
+			log.Printf("[CONVERTER] Conversion error: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		log.Printf("[CONVERTER] Conversion successful")
+		return c.JSON(scene)
+	}
+}
+
+// ClassifySVG — dry-run классификации: какое правило сработало для каждого элемента
+// (с правилами каталога items, как при /convert).
+func ClassifySVG(defaultRules *parser.RuleSet, catalog *mapper.Catalog) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		data, err := readSVGFile(c)
+		if err != nil {
+			return errorJSON(c, err)
+		}
+
+		rules, err := requestRules(c, defaultRules)
+		if err != nil {
+			return errorJSON(c, err)
+		}
+		if rules, err = catalog.Rules(rules); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid rules: " + err.Error(),
+			})
+		}
+
+		result, skipped, err := parser.ClassifySVG(bytes.NewReader(data), rules)
+		if err != nil {
+			log.Printf("[CONVERTER] Classify error: %v", err)
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		matched := 0
+		for _, item := range result {
+			if item.Rule != "" {
+				matched++
+			}
+		}
+
+		response := fiber.Map{
+			"elements":  result,
+			"total":     len(result),
+			"matched":   matched,
+			"unmatched": len(result) - matched,
+		}
+		if len(skipped) > 0 {
+			response["skipped"] = skipped
+		}
+		return c.JSON(response)
+	}
+}
+
+// ============================================================
+// Helpers
+// ============================================================
+
+// readSVGFile читает файл из multipart/form-data поля file.
+func readSVGFile(c fiber.Ctx) ([]byte, error) {
 	file, err := c.FormFile("file")
 	if err != nil {
 		log.Printf("[CONVERTER] FormFile error: %v", err)
-		return c.Status(400).JSON(fiber.Map{
-			"error": "file required in multipart/form-data",
-		})
+		return nil, fiber.NewError(400, "file required in multipart/form-data")
 	}
 
 	log.Printf("[CONVERTER] File received: %s, size: %d", file.Filename, file.Size)
 
-	// Открываем файл
 	f, err := file.Open()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "failed to open file",
-		})
+		return nil, fiber.NewError(500, "failed to open file")
 	}
 	defer f.Close()
 
-	// Читаем содержимое
 	data, err := io.ReadAll(f)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "failed to read file",
-		})
+		return nil, fiber.NewError(500, "failed to read file")
+	}
+	return data, nil
+}
+
+// readSVGFiles читает все файлы поля file: каждый файл — отдельный этаж, имя слоя — имя файла
+// без расширения. Для одного файла имя не задается (слой default, как раньше).
+func readSVGFiles(c fiber.Ctx) ([]mapper.FloorSource, error) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		return nil, fiber.NewError(400, "file required in multipart/form-data")
+	}
+
+	files := form.File["file"]
+	sources := make([]mapper.FloorSource, 0, len(files))
+	for _, file := range files {
+		log.Printf("[CONVERTER] File received: %s, size: %d", file.Filename, file.Size)
+
+		f, err := file.Open()
+		if err != nil {
+			return nil, fiber.NewError(500, "failed to open file")
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fiber.NewError(500, "failed to read file")
+		}
+
+		src := mapper.FloorSource{Data: bytes.NewReader(data)}
+		if len(files) > 1 {
+			src.Name = strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// requestRules берет правила из поля rules (JSON строка или файл), иначе — правила сервиса.
+func requestRules(c fiber.Ctx, defaultRules *parser.RuleSet) (*parser.RuleSet, error) {
+	raw := []byte(c.FormValue("rules"))
+	if len(raw) == 0 {
+		if file, err := c.FormFile("rules"); err == nil {
+			f, err := file.Open()
+			if err != nil {
+				return nil, fiber.NewError(400, "failed to open rules file")
+			}
+			defer f.Close()
+			if raw, err = io.ReadAll(f); err != nil {
+				return nil, fiber.NewError(400, "failed to read rules file")
+			}
+		}
+	}
+	if len(raw) == 0 {
+		return defaultRules, nil
 	}
 
-	// Конвертируем
-	log.Printf("[CONVERTER] Starting conversion, data size: %d bytes", len(data))
-	converter := mapper.New()
-	scene, err := converter.Convert(bytes.NewReader(data))
+	rules, err := parser.ParseRules(raw)
 	if err != nil {
-		log.Printf("[CONVERTER] Conversion error: %v", err)
-		return c.Status(500).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return nil, fiber.NewError(400, "invalid rules: "+err.Error())
 	}
+	return rules, nil
+}
 
-	log.Printf("[CONVERTER] Conversion successful")
-	return c.JSON(scene)
+// requestCalibration читает калибровку масштаба: scale_reference ("Wall_03=420cm"),
+// pixels_per_unit + unit или document_units=true.
+func requestCalibration(c fiber.Ctx) (mapper.Calibration, error) {
+	var cal mapper.Calibration
+
+	if raw := c.FormValue("scale_reference"); raw != "" {
+		id, length, err := mapper.ParseReference(raw)
+		if err != nil {
+			return cal, fiber.NewError(400, "invalid scale_reference: "+err.Error())
+		}
+		cal.ReferenceID = id
+		cal.ReferenceLength = length.Value
+		cal.Unit = length.Unit
+	}
+
+	if raw := c.FormValue("pixels_per_unit"); raw != "" {
+		if cal.ReferenceID != "" {
+			return cal, fiber.NewError(400, "use either scale_reference or pixels_per_unit")
+		}
+		ppu, err := strconv.ParseFloat(raw, 64)
+		if err != nil || ppu <= 0 {
+			return cal, fiber.NewError(400, "pixels_per_unit must be a positive number")
+		}
+		cal.PixelsPerUnit = ppu
+		if unit := c.FormValue("unit"); unit != "" {
+			cal.Unit = unit
+		}
+	}
+
+	if raw := c.FormValue("document_units"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return cal, fiber.NewError(400, "document_units must be a boolean")
+		}
+		cal.DocumentUnits = enabled
+	}
+
+	if _, ok := parser.CentimetersPerUnit(cal.Unit); !ok {
+		return cal, fiber.NewError(400, "unknown unit: "+cal.Unit)
+	}
+	return cal, nil
+}
+
+// requestPlacement читает размещение плана на холсте: canvas_width/canvas_height или fit=auto,
+// margin, mirror_x, mirror_y, align_axis, origin. Незаданные поля — как в DefaultPlacement.
+func requestPlacement(c fiber.Ctx) (mapper.Placement, error) {
+	p := mapper.DefaultPlacement()
+
+	floats := []struct {
+		field string
+		dst   *float64
+	}{
+		{"canvas_width", &p.CanvasWidth},
+		{"canvas_height", &p.CanvasHeight},
+		{"margin", &p.Margin},
+	}
+	for _, f := range floats {
+		if raw := c.FormValue(f.field); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return p, fiber.NewError(400, f.field+" must be a number")
+			}
+			*f.dst = v
+		}
+	}
+
+	bools := []struct {
+		field string
+		dst   *bool
+	}{
+		{"mirror_x", &p.MirrorX},
+		{"mirror_y", &p.MirrorY},
+		{"align_axis", &p.AlignAxis},
+	}
+	for _, f := range bools {
+		if raw := c.FormValue(f.field); raw != "" {
+			v, err := strconv.ParseBool(raw)
+			if err != nil {
+				return p, fiber.NewError(400, f.field+" must be a boolean")
+			}
+			*f.dst = v
+		}
+	}
+
+	switch fit := c.FormValue("fit"); fit {
+	case "", "fixed":
+	case "auto":
+		p.AutoFit = true
+	default:
+		return p, fiber.NewError(400, "fit must be auto or fixed")
+	}
+	if origin := c.FormValue("origin"); origin != "" {
+		p.Origin = origin
+	}
+
+	if err := p.Validate(); err != nil {
+		return p, fiber.NewError(400, err.Error())
+	}
+	return p, nil
+}
+
+// requestFloors читает разбиение на этажи: floor_groups (auto — группы floor-N/Этаж N/level-N,
+// иначе regex по имени группы <g>), floor_height (см) и altitudes (отметки этажей через запятую, см).
+func requestFloors(c fiber.Ctx) (mapper.Floors, error) {
+	f := mapper.DefaultFloors()
+
+	if raw := c.FormValue("floor_groups"); raw != "" {
+		pattern := raw
+		if raw == "auto" {
+			pattern = mapper.FloorGroupPattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, fiber.NewError(400, "invalid floor_groups: "+err.Error())
+		}
+		f.Groups = re
+	}
+
+	if raw := c.FormValue("floor_height"); raw != "" {
+		height, err := strconv.ParseFloat(raw, 64)
+		if err != nil || height < 0 {
+			return f, fiber.NewError(400, "floor_height must be a non-negative number")
+		}
+		f.Height = height
+	}
+
+	if raw := c.FormValue("altitudes"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			altitude, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return f, fiber.NewError(400, "altitudes must be comma-separated numbers")
+			}
+			f.Altitudes = append(f.Altitudes, altitude)
+		}
+	}
+	return f, nil
+}
+
+// errorJSON отдает ошибку в JSON, сохраняя HTTP код из *fiber.Error.
+func errorJSON(c fiber.Ctx, err error) error {
+	code := 500
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		code = fiberErr.Code
+	}
+	return c.Status(code).JSON(fiber.Map{
+		"error": err.Error(),
+	})
 }
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+
+	"api-gateway/internal/converter/compliance"
+	"api-gateway/internal/converter/diff"
+	"api-gateway/internal/converter/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// ============================================================
+// Diff Handler
+// ============================================================
+
+// diffRequest — тело /diff: исходная и отредактированная сцены, необязательно этаж ниже.
+type diffRequest struct {
+	Original *models.Scene `json:"original"`
+	Edited   *models.Scene `json:"edited"`
+	Below    *models.Scene `json:"below,omitempty"`
+}
+
+// diffResponse — изменения и проверка перепланировки по нормам региона.
+type diffResponse struct {
+	*diff.Report
+	Compliance *compliance.Report `json:"compliance"`
+}
+
+// DiffScenes сравнивает исходный и отредактированный react-planner JSON: стены, проемы
+// и комнаты (добавлены, удалены, сдвинуты, объединены, разделены) с изменением площадей,
+// и проверяет изменения по правилам региона (?region, по умолчанию — профиль сервиса).
+func DiffScenes(profiles *compliance.Profiles) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		log.Printf("[DIFF] Received request")
+		log.Printf("[DIFF] Content-Length: %d", len(c.Body()))
+
+		if len(c.Body()) == 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "body required",
+			})
+		}
+
+		var req diffRequest
+		if err := json.Unmarshal(c.Body(), &req); err != nil {
+			log.Printf("[DIFF] Decode error: %v", err)
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid JSON payload",
+			})
+		}
+		if req.Original == nil || req.Edited == nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "original and edited scenes are required",
+			})
+		}
+
+		opts := diff.Options{Layer: c.Query("layer")}
+		if raw := c.Query("tolerance"); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil || value < 0 {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "tolerance must be a non-negative number",
+				})
+			}
+			opts.Tolerance = value
+		}
+
+		profile, ok := profiles.Get(c.Query("region"))
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "unknown region " + c.Query("region"),
+			})
+		}
+
+		report, err := diff.Compare(req.Original, req.Edited, opts)
+		if err != nil {
+			log.Printf("[DIFF] Compare error: %v", err)
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		checks, err := compliance.Check(req.Original, req.Edited, report, profile,
+			compliance.Options{Layer: opts.Layer, Below: req.Below})
+		if err != nil {
+			log.Printf("[DIFF] Compliance error: %v", err)
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(diffResponse{Report: report, Compliance: checks})
+	}
+}
@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"api-gateway/internal/converter/mapper"
 	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/raster"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -14,35 +21,190 @@ import (
 // Render Handler
 // ============================================================
 
-// RenderSVG конвертирует react-planner JSON обратно в SVG
-func RenderSVG(c fiber.Ctx) error {
-	log.Printf("[RENDER] Received request")
-	log.Printf("[RENDER] Content-Type: %s", c.Get("Content-Type"))
-	log.Printf("[RENDER] Content-Length: %d", len(c.Body()))
+// RenderSVG конвертирует react-planner JSON обратно в SVG. ?mode=layer (по умолчанию) выводит
+// слой ?layer или выбранный слой, mode=zip — каждый слой отдельным SVG в zip архиве,
+// mode=stack — все этажи на одном листе. ?theme выбирает оформление (по умолчанию — тема сервиса),
+// ?annotate — слои подписей через запятую (размеры, помещения, проемы, стрелка севера, линейка).
+// ?format=png или заголовок Accept: image/png растеризуют результат (?dpi, ?background).
+func RenderSVG(themes *mapper.Themes) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		log.Printf("[RENDER] Received request")
+		log.Printf("[RENDER] Content-Type: %s", c.Get("Content-Type"))
+		log.Printf("[RENDER] Content-Length: %d", len(c.Body()))
 
-	if len(c.Body()) == 0 {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "body required",
-		})
+		if len(c.Body()) == 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "body required",
+			})
+		}
+
+		theme, ok := themes.Get(c.Query("theme"))
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "unknown theme " + c.Query("theme"),
+			})
+		}
+		annotations, err := mapper.ParseAnnotations(c.Query("annotate"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		png, err := rasterOptions(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		var scene models.Scene
+		if err := json.Unmarshal(c.Body(), &scene); err != nil {
+			log.Printf("[RENDER] Decode error: %v", err)
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid JSON payload",
+			})
+		}
+
+		renderer := mapper.NewRenderer()
+		renderer.SetTheme(theme)
+		renderer.SetAnnotations(annotations)
+		switch mode := c.Query("mode", "layer"); mode {
+		case "layer":
+			svg, err := renderer.RenderLayer(&scene, c.Query("layer"))
+			if errors.Is(err, mapper.ErrLayerNotFound) {
+				return c.Status(400).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			if err != nil {
+				log.Printf("[RENDER] Render error: %v", err)
+				return c.Status(500).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+
+			return sendImage(c, svg, png)
+
+		case "stack":
+			svg, err := renderer.RenderStack(&scene)
+			if err != nil {
+				log.Printf("[RENDER] Render error: %v", err)
+				return c.Status(500).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+
+			return sendImage(c, svg, png)
+
+		case "zip":
+			layers, err := renderer.RenderLayers(&scene)
+			if err != nil {
+				log.Printf("[RENDER] Render error: %v", err)
+				return c.Status(500).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+
+			data, err := zipLayers(layers, png)
+			if errors.Is(err, raster.ErrTooLarge) {
+				return c.Status(400).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			if err != nil {
+				log.Printf("[RENDER] Zip error: %v", err)
+				return c.Status(500).JSON(fiber.Map{
+					"error": "failed to build archive",
+				})
+			}
+
+			c.Set("Content-Type", "application/zip")
+			c.Set("Content-Disposition", `attachment; filename="layers.zip"`)
+			return c.Send(data)
+
+		default:
+			return c.Status(400).JSON(fiber.Map{
+				"error": "mode must be layer, zip or stack",
+			})
+		}
 	}
+}
 
-	var scene models.Scene
-	if err := json.Unmarshal(c.Body(), &scene); err != nil {
-		log.Printf("[RENDER] Decode error: %v", err)
+// rasterOptions читает ?format, ?dpi и ?background; nil — ответ в SVG.
+// Без ?format PNG выбирается заголовком Accept: image/png.
+func rasterOptions(c fiber.Ctx) (*raster.Options, error) {
+	format := c.Query("format")
+	if format == "" && strings.Contains(c.Get("Accept"), "image/png") {
+		format = "png"
+	}
+	switch format {
+	case "", "svg":
+		return nil, nil
+	case "png":
+	default:
+		return nil, fmt.Errorf("format must be svg or png")
+	}
+
+	opts := &raster.Options{DPI: raster.DefaultDPI, Background: c.Query("background", "#fff")}
+	if raw := c.Query("dpi"); raw != "" {
+		dpi, err := strconv.ParseFloat(raw, 64)
+		if err != nil || dpi <= 0 || dpi > raster.MaxDPI {
+			return nil, fmt.Errorf("dpi must be a number in (0, %d]", raster.MaxDPI)
+		}
+		opts.DPI = dpi
+	}
+	return opts, nil
+}
+
+// sendImage отдает SVG как есть или растеризованным в PNG.
+func sendImage(c fiber.Ctx, svg string, png *raster.Options) error {
+	if png == nil {
+		c.Set("Content-Type", "image/svg+xml")
+		return c.SendString(svg)
+	}
+
+	data, err := raster.EncodePNG(svg, *png)
+	if errors.Is(err, raster.ErrTooLarge) {
 		return c.Status(400).JSON(fiber.Map{
-			"error": "invalid JSON payload",
+			"error": err.Error(),
 		})
 	}
-
-	renderer := mapper.NewRenderer()
-	svg, err := renderer.Render(&scene)
 	if err != nil {
-		log.Printf("[RENDER] Render error: %v", err)
+		log.Printf("[RENDER] Raster error: %v", err)
 		return c.Status(500).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	c.Set("Content-Type", "image/svg+xml")
-	return c.SendString(svg)
+	c.Set("Content-Type", "image/png")
+	return c.Send(data)
+}
+
+// zipLayers упаковывает слои в архив: <id слоя>.svg или <id слоя>.png, снизу вверх.
+func zipLayers(layers []mapper.RenderedLayer, png *raster.Options) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, layer := range layers {
+		name := strings.NewReplacer("/", "_", `\`, "_").Replace(layer.ID)
+		data := []byte(layer.SVG)
+		ext := ".svg"
+		if png != nil {
+			var err error
+			if data, err = raster.EncodePNG(layer.SVG, *png); err != nil {
+				return nil, err
+			}
+			ext = ".png"
+		}
+		f, err := w.Create(name + ext)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
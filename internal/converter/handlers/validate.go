@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+
+	"api-gateway/internal/converter/models"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// ============================================================
+// Validate Handler
+// ============================================================
+
+// validateResponse — результат проверки; scene — исправленная сцена при repair=true.
+type validateResponse struct {
+	*models.ValidationResult
+	Scene *models.Scene `json:"scene,omitempty"`
+}
+
+// ValidateScene проверяет структурную целостность react-planner JSON. С ?repair=true
+// сначала восстанавливает обратные ссылки и возвращает исправленную сцену.
+func ValidateScene(c fiber.Ctx) error {
+	log.Printf("[VALIDATE] Received request")
+	log.Printf("[VALIDATE] Content-Length: %d", len(c.Body()))
+
+	if len(c.Body()) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "body required",
+		})
+	}
+
+	var scene models.Scene
+	if err := json.Unmarshal(c.Body(), &scene); err != nil {
+		log.Printf("[VALIDATE] Decode error: %v", err)
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid JSON payload",
+		})
+	}
+
+	repair := false
+	switch c.Query("repair") {
+	case "", "false":
+	case "true":
+		repair = true
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"error": "repair must be true or false",
+		})
+	}
+
+	var repairs []models.Issue
+	if repair {
+		repairs = models.RepairScene(&scene)
+	}
+	result := models.ValidateScene(&scene)
+	result.Repairs = repairs
+	log.Printf("[VALIDATE] errors: %d, warnings: %d, repairs: %d", len(result.Errors), len(result.Warnings), len(repairs))
+
+	resp := validateResponse{ValidationResult: result}
+	if repair {
+		resp.Scene = &scene
+	}
+	return c.JSON(resp)
+}
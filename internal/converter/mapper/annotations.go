@@ -0,0 +1,441 @@
+package mapper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"api-gateway/internal/converter/analysis"
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Annotations
+// ============================================================
+
+// Слои подписей /render (?annotate=exterior,rooms,...).
+const (
+	AnnotateExterior = "exterior" // наружные размерные цепочки вдоль стен
+	AnnotateInterior = "interior" // внутренние размеры помещений по контуру
+	AnnotateRooms    = "rooms"    // название и площадь помещения
+	AnnotateOpenings = "openings" // ширина дверей и окон
+	AnnotateNorth    = "north"    // стрелка севера
+	AnnotateScale    = "scale"    // масштабная линейка
+	AnnotateAll      = "all"
+)
+
+// Annotations — какие слои подписей выводить поверх плана.
+type Annotations struct {
+	Exterior bool
+	Interior bool
+	Rooms    bool
+	Openings bool
+	North    bool
+	Scale    bool
+}
+
+// Размеры подписей в см: переводятся в единицы вывода по единицам сцены и масштабу вывода.
+const (
+	annotationFont     = 18.0 // размер шрифта
+	dimensionOffset    = 60.0 // отступ наружной цепочки от грани стены
+	dimensionInset     = 30.0 // отступ внутреннего размера от контура помещения
+	dimensionTick      = 8.0  // полуразмер засечки
+	dimensionMin       = 20.0 // короче — размер не подписывается
+	exteriorProbe      = 20.0 // насколько за грань стены проверяется, есть ли там помещение
+	annotationMargin   = 0.03 // отступ стрелки севера и линейки от края листа, доля холста
+	annotationGlyph    = 0.06 // размер стрелки севера, доля холста
+	scaleBarMaxPortion = 0.25 // линейка не длиннее этой доли ширины холста
+)
+
+// scaleBarLengths — длины масштабной линейки, см.
+var scaleBarLengths = []float64{50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+// ParseAnnotations разбирает список слоев подписей через запятую; all — все слои.
+func ParseAnnotations(list string) (Annotations, error) {
+	var a Annotations
+	for _, name := range strings.Split(list, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case AnnotateExterior:
+			a.Exterior = true
+		case AnnotateInterior:
+			a.Interior = true
+		case AnnotateRooms:
+			a.Rooms = true
+		case AnnotateOpenings:
+			a.Openings = true
+		case AnnotateNorth:
+			a.North = true
+		case AnnotateScale:
+			a.Scale = true
+		case AnnotateAll:
+			a = Annotations{Exterior: true, Interior: true, Rooms: true, Openings: true, North: true, Scale: true}
+		default:
+			return Annotations{}, fmt.Errorf("unknown annotation %q", strings.TrimSpace(name))
+		}
+	}
+	return a, nil
+}
+
+// any сообщает, что выбран хотя бы один слой подписей.
+func (a Annotations) any() bool {
+	return a.Exterior || a.Interior || a.Rooms || a.Openings || a.North || a.Scale
+}
+
+// annotator — параметры вывода подписей одного слоя.
+type annotator struct {
+	theme *Theme
+	frame renderFrame
+	unit  float64 // единиц сцены в 1 см
+	out   float64 // единиц вывода в 1 см
+}
+
+func (r *Renderer) renderAnnotations(scene *models.Scene, layer models.Layer, meta map[string]any, box [4]float64, frame renderFrame) []string {
+	if !r.annotations.any() {
+		return nil
+	}
+	cmPerUnit, ok := parser.CentimetersPerUnit(scene.Unit)
+	if !ok || cmPerUnit <= 0 {
+		cmPerUnit = 1
+	}
+	an := annotator{theme: r.theme, frame: frame, unit: 1 / cmPerUnit, out: frame.scale / cmPerUnit}
+
+	var outlines [][]models.Point
+	for _, id := range models.SortedKeys(layer.Areas) {
+		if points := r.collectAreaPoints(layer.Areas[id], layer.Vertices); len(points) >= 3 {
+			outlines = append(outlines, points)
+		}
+	}
+
+	var out []string
+	if r.annotations.Exterior {
+		out = append(out, an.exterior(layer, outlines)...)
+	}
+	if r.annotations.Interior {
+		for _, outline := range outlines {
+			out = append(out, an.interior(outline)...)
+		}
+	}
+	if r.annotations.Openings {
+		out = append(out, an.openings(layer)...)
+	}
+	if r.annotations.Rooms {
+		out = append(out, an.rooms(scene, layer)...)
+	}
+
+	if r.annotations.North {
+		north, _ := meta["north"].(float64)
+		out = append(out, an.northArrow(box, north))
+	}
+	if r.annotations.Scale {
+		if bar := an.scaleBar(box); bar != "" {
+			out = append(out, bar)
+		}
+	}
+	return out
+}
+
+// ============================================================
+// Dimension chains
+// ============================================================
+
+// exterior — размерные цепочки наружных стен: стена наружная, если по одну сторону от нее
+// помещение, а по другую нет. Цепочка разбита концами стены и краями проемов.
+func (an annotator) exterior(layer models.Layer, outlines [][]models.Point) []string {
+	if len(outlines) == 0 {
+		return nil
+	}
+	inside := func(p models.Point) bool {
+		for _, outline := range outlines {
+			if graph.PointInPolygon(p, outline) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var out []string
+	for _, id := range models.SortedKeys(layer.Lines) {
+		line := layer.Lines[id]
+		if len(line.Vertices) < 2 {
+			continue
+		}
+		if _, isArc := graph.LineArc(line); isArc {
+			continue
+		}
+		v1, ok1 := layer.Vertices[line.Vertices[0]]
+		v2, ok2 := layer.Vertices[line.Vertices[1]]
+		length := math.Hypot(v2.X-v1.X, v2.Y-v1.Y)
+		if !ok1 || !ok2 || length == 0 {
+			continue
+		}
+		a, b := models.Point{X: v1.X, Y: v1.Y}, models.Point{X: v2.X, Y: v2.Y}
+		ux, uy := (b.X-a.X)/length, (b.Y-a.Y)/length
+		face := models.LengthProperty(line.Properties, "thickness", 0) / 2
+
+		// наружная сторона: за ней нет помещения, а с другой стороны есть
+		mid := models.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+		probe := face + exteriorProbe*an.unit
+		left := inside(models.Point{X: mid.X - uy*probe, Y: mid.Y + ux*probe})
+		right := inside(models.Point{X: mid.X + uy*probe, Y: mid.Y - ux*probe})
+		if left == right {
+			continue
+		}
+		normal := models.Point{X: -uy, Y: ux}
+		if left {
+			normal = models.Point{X: uy, Y: -ux}
+		}
+
+		// засечки: концы стены и края проемов на ней
+		stops := []float64{0, length}
+		for _, holeID := range models.SortedKeys(layer.Holes) {
+			hole := layer.Holes[holeID]
+			if hole.Line != id {
+				continue
+			}
+			width := models.LengthProperty(hole.Properties, "width", 0)
+			center := clamp(hole.Offset, 0, 1) * length
+			stops = append(stops, clamp(center-width/2, 0, length), clamp(center+width/2, 0, length))
+		}
+		sort.Float64s(stops)
+
+		at := func(s float64) models.Point { return models.Point{X: a.X + ux*s, Y: a.Y + uy*s} }
+		for i := 1; i < len(stops); i++ {
+			if stops[i]-stops[i-1] < 1e-6 {
+				continue
+			}
+			out = append(out, an.dimension(at(stops[i-1]), at(stops[i]), normal, face, face+dimensionOffset*an.unit)...)
+		}
+	}
+	return out
+}
+
+// interior — внутренние размеры помещения вдоль сторон его контура.
+func (an annotator) interior(outline []models.Point) []string {
+	var signed float64
+	for i, j := 0, len(outline)-1; i < len(outline); j, i = i, i+1 {
+		signed += outline[j].X*outline[i].Y - outline[i].X*outline[j].Y
+	}
+
+	var out []string
+	for i := range outline {
+		a, b := outline[i], outline[(i+1)%len(outline)]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		if length < dimensionMin*an.unit {
+			continue
+		}
+		// внутрь контура: влево от стороны при обходе против часовой стрелки
+		normal := models.Point{X: -(b.Y - a.Y) / length, Y: (b.X - a.X) / length}
+		if signed < 0 {
+			normal = models.Point{X: -normal.X, Y: -normal.Y}
+		}
+		out = append(out, an.dimension(a, b, normal, 0, dimensionInset*an.unit)...)
+	}
+	return out
+}
+
+// dimension рисует размер отрезка a-b: выносные линии от from до offset по нормали normal
+// (единицы сцены), размерную линию с засечками и длину в см над ней.
+func (an annotator) dimension(a, b, normal models.Point, from, offset float64) []string {
+	shift := func(p models.Point, d float64) models.Point {
+		return an.frame.point(p.X+normal.X*d, p.Y+normal.Y*d)
+	}
+	pa, pb := shift(a, offset), shift(b, offset)
+	lengthCm := math.Hypot(b.X-a.X, b.Y-a.Y) / an.unit
+
+	tick := dimensionTick * an.out
+	dir := models.Point{X: pb.X - pa.X, Y: pb.Y - pa.Y}
+	if l := math.Hypot(dir.X, dir.Y); l > 0 {
+		dir = models.Point{X: dir.X / l, Y: dir.Y / l}
+	}
+	// засечка под 45° к размерной линии
+	slash := models.Point{X: (dir.X - dir.Y) * tick / math.Sqrt2, Y: (dir.Y + dir.X) * tick / math.Sqrt2}
+
+	var d strings.Builder
+	if offset > from {
+		overshoot := offset + dimensionTick*an.unit
+		fmt.Fprintf(&d, "M %s L %s M %s L %s ",
+			formatPoint(shift(a, from)), formatPoint(shift(a, overshoot)),
+			formatPoint(shift(b, from)), formatPoint(shift(b, overshoot)))
+	}
+	fmt.Fprintf(&d, "M %s L %s", formatPoint(pa), formatPoint(pb))
+	for _, p := range []models.Point{pa, pb} {
+		fmt.Fprintf(&d, " M %s L %s",
+			formatPoint(models.Point{X: p.X - slash.X, Y: p.Y - slash.Y}),
+			formatPoint(models.Point{X: p.X + slash.X, Y: p.Y + slash.Y}))
+	}
+	out := []string{fmt.Sprintf(`<path class="dimension" d="%s" %s />`, d.String(), an.theme.line("annotation"))}
+
+	if lengthCm >= dimensionMin {
+		label := shift(models.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}, offset+annotationFont*0.4*an.unit)
+		out = append(out, an.text(label, angleOf(pa, pb), fmt.Sprintf("%.0f", lengthCm)))
+	}
+	return out
+}
+
+// ============================================================
+// Labels
+// ============================================================
+
+// openings — ширина проема в см у грани стены; у двери — со стороны, противоположной открыванию.
+func (an annotator) openings(layer models.Layer) []string {
+	var out []string
+	for _, id := range models.SortedKeys(layer.Holes) {
+		hole := layer.Holes[id]
+		line, ok := layer.Lines[hole.Line]
+		if !ok || len(line.Vertices) < 2 {
+			continue
+		}
+		v1, ok1 := layer.Vertices[line.Vertices[0]]
+		v2, ok2 := layer.Vertices[line.Vertices[1]]
+		length := math.Hypot(v2.X-v1.X, v2.Y-v1.Y)
+		if !ok1 || !ok2 || length == 0 {
+			continue
+		}
+		ux, uy := (v2.X-v1.X)/length, (v2.Y-v1.Y)/length
+		offset := clamp(hole.Offset, 0, 1)
+		center := models.Point{X: v1.X + (v2.X-v1.X)*offset, Y: v1.Y + (v2.Y-v1.Y)*offset}
+
+		side := 1.0
+		if swing, _ := hole.Properties["swing"].(string); swing == SwingLeft {
+			side = -1
+		}
+		thickness := models.LengthProperty(hole.Properties, "thickness", models.LengthProperty(line.Properties, "thickness", 10))
+		d := side * (thickness/2 + annotationFont*0.8*an.unit)
+		label := an.frame.point(center.X-uy*d, center.Y+ux*d)
+		a, b := an.frame.point(v1.X, v1.Y), an.frame.point(v2.X, v2.Y)
+
+		width := models.LengthProperty(hole.Properties, "width", 0) / an.unit
+		out = append(out, an.text(label, angleOf(a, b), fmt.Sprintf("%.0f", width)))
+	}
+	return out
+}
+
+// rooms — название и площадь помещения (как в /analyze) в полюсе недоступности его контура:
+// точке, наиболее удаленной от стен, где подпись не пересекает контур.
+func (an annotator) rooms(scene *models.Scene, layer models.Layer) []string {
+	areas := make(map[string]float64)
+	names := make(map[string]string)
+	if report, err := analysis.Analyze(scene, analysis.Options{Layer: layer.ID}); err == nil {
+		for _, room := range report.Rooms {
+			areas[room.ID], names[room.ID] = room.Area, room.Name
+		}
+		for _, balcony := range report.Balconies {
+			areas[balcony.ID], names[balcony.ID] = balcony.Area, balcony.Name
+		}
+	}
+
+	var out []string
+	for _, id := range models.SortedKeys(layer.Areas) {
+		area := layer.Areas[id]
+		var outline []models.Point
+		for _, vid := range area.Vertices {
+			if v, ok := layer.Vertices[vid]; ok {
+				outline = append(outline, models.Point{X: v.X, Y: v.Y})
+			}
+		}
+		if len(outline) < 3 {
+			continue
+		}
+		pole, _ := graph.PoleOfInaccessibility(outline, an.unit)
+		p := an.frame.point(pole.X, pole.Y)
+
+		name := names[id]
+		if name == "" {
+			name = area.Name
+		}
+		lines := []string{name}
+		if value, ok := areas[id]; ok {
+			lines = append(lines, fmt.Sprintf("%.1f м²", value))
+		}
+		size := annotationFont * an.out
+		top := p.Y - size*float64(len(lines)-1)*0.6
+		for i, text := range lines {
+			if text == "" {
+				continue
+			}
+			out = append(out, an.text(models.Point{X: p.X, Y: top + size*1.2*float64(i)}, 0, text))
+		}
+	}
+	return out
+}
+
+// text — подпись по центру точки p, повернутая на angle градусов (в координатах вывода).
+func (an annotator) text(p models.Point, angle float64, text string) string {
+	transform := ""
+	if angle != 0 {
+		transform = fmt.Sprintf(` transform="rotate(%s %s %s)"`, formatFloat(angle), formatFloat(p.X), formatFloat(p.Y))
+	}
+	return fmt.Sprintf(`<text class="annotation" x="%s" y="%s" text-anchor="middle" dominant-baseline="middle"%s %s>%s</text>`,
+		formatFloat(p.X), formatFloat(p.Y), transform, an.theme.textAttrs("sans-serif", annotationFont*an.out), escapeAttr(text))
+}
+
+// angleOf — угол отрезка в координатах вывода, приведенный к (-90, 90], чтобы текст не был перевернут.
+func angleOf(a, b models.Point) float64 {
+	angle := math.Atan2(b.Y-a.Y, b.X-a.X) * 180 / math.Pi
+	if angle > 90 {
+		angle -= 180
+	} else if angle <= -90 {
+		angle += 180
+	}
+	return math.Round(angle*100) / 100
+}
+
+// ============================================================
+// North arrow & scale bar
+// ============================================================
+
+// northArrow — стрелка севера в правом верхнем углу листа box (minX, minY, width, height);
+// north — направление на север в градусах по часовой стрелке от верха листа (meta.north).
+func (an annotator) northArrow(box [4]float64, north float64) string {
+	size := math.Min(box[2], box[3]) * annotationGlyph
+	margin := math.Min(box[2], box[3]) * annotationMargin
+	cx, cy := box[0]+box[2]-margin-size/2, box[1]+margin+size/2
+
+	rad := north * math.Pi / 180
+	at := func(x, y float64) models.Point {
+		return models.Point{X: cx + x*math.Cos(rad) - y*math.Sin(rad), Y: cy + x*math.Sin(rad) + y*math.Cos(rad)}
+	}
+	h := size / 2
+	tip, left, right, tail := at(0, -h), at(-h*0.4, h), at(h*0.4, h), at(0, h*0.5)
+	label := at(0, -h-size*0.25)
+	return fmt.Sprintf(`<g class="north-arrow"><path d="M %s L %s L %s L %s Z" %s /><text x="%s" y="%s" text-anchor="middle" %s>N</text></g>`,
+		formatPoint(tip), formatPoint(left), formatPoint(tail), formatPoint(right), an.theme.paint("annotation"),
+		formatFloat(label.X), formatFloat(label.Y), an.theme.textAttrs("sans-serif", size*0.4))
+}
+
+// scaleBar — масштабная линейка в левом нижнем углу листа: самая длинная из scaleBarLengths,
+// которая помещается в четверть ширины, из двух делений.
+func (an annotator) scaleBar(box [4]float64) string {
+	if an.out <= 0 {
+		return ""
+	}
+	lengthCm := 0.0
+	for _, l := range scaleBarLengths {
+		if l*an.out <= box[2]*scaleBarMaxPortion {
+			lengthCm = l
+		}
+	}
+	if lengthCm == 0 {
+		return ""
+	}
+	margin := math.Min(box[2], box[3]) * annotationMargin
+	length := lengthCm * an.out
+	height := length / 20
+	x, y := box[0]+margin, box[1]+box[3]-margin-height
+
+	paint := an.theme.paint("annotation")
+	stroke := strokeOr(an.theme.style("annotation").Stroke, "#000")
+	size := annotationFont * an.out
+	text := an.theme.textAttrs("sans-serif", size)
+	return fmt.Sprintf(`<g class="scale-bar"><rect x="%s" y="%s" width="%s" height="%s" %s />`+
+		`<rect x="%s" y="%s" width="%s" height="%s" fill="%s" stroke="%s" />`+
+		`<text x="%s" y="%s" text-anchor="middle" %s>0</text><text x="%s" y="%s" text-anchor="middle" %s>%s м</text></g>`,
+		formatFloat(x), formatFloat(y), formatFloat(length), formatFloat(height), paint,
+		formatFloat(x), formatFloat(y), formatFloat(length/2), formatFloat(height), escapeAttr(stroke), escapeAttr(stroke),
+		formatFloat(x), formatFloat(y-size*0.4), text,
+		formatFloat(x+length), formatFloat(y-size*0.4), text, formatFloat(lengthCm/100))
+}
@@ -0,0 +1,76 @@
+package mapper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+)
+
+// Изогнутая стена примыкает к прямым: после слияния вершин начало дуги оказывается
+// чуть раньше Start, и сектор не должен превращаться в дополнение до 360°.
+func TestCurvedWallJoinedToStraightWalls(t *testing.T) {
+	const sx, sy, r = 518.7, 104.24, 237.0
+	cx, cy := sx, sy+r
+	ex, ey := cx+r, cy
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="1000" height="800">
+<rect id="Wall_01" x="100" y="%g" width="%g" height="10"/>
+<path id="Wall_02" d="M %g %g A %g %g 0 0 1 %g %g L %g %g A %g %g 0 0 0 %g %g Z"/>
+<rect id="Wall_03" x="%g" y="%g" width="10" height="200"/>
+</svg>`, sy-5, sx-100,
+		sx, sy-5, r+5, r+5, ex+5, ey, ex-5, ey, r-5, r-5, sx, sy+5,
+		ex-5, ey)
+
+	scene, err := New().Convert(strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	arcs := 0
+	for _, layer := range scene.Layers {
+		for _, line := range layer.Lines {
+			if _, ok := graph.LineArc(line); ok {
+				arcs++
+			}
+			// без исходной разметки стена рисуется по геометрии сцены
+			delete(line.Misc, SourceMiscKey)
+		}
+	}
+	if arcs == 0 {
+		t.Fatal("curved wall was not recognized")
+	}
+
+	out, err := NewRenderer().Render(scene)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	m := regexp.MustCompile(`<path id="Wall_02" d="M \S+ \S+ A \S+ \S+ 0 (\d) `).FindStringSubmatch(out)
+	if m == nil {
+		t.Fatalf("curved wall not rendered:\n%s", out)
+	}
+	if m[1] != "0" {
+		t.Errorf("quarter-circle wall rendered with large-arc flag %s", m[1])
+	}
+}
+
+func TestPointToArcDistanceAtStart(t *testing.T) {
+	for start := 0.0; start < 360; start += 1 {
+		arc := graph.Arc{Center: models.Point{X: 100, Y: 100}, Radius: 50, Start: start, Sweep: 90}
+		at := func(angle float64) models.Vertex {
+			p := arc.PointAt(angle)
+			return models.Vertex{X: p.X, Y: p.Y}
+		}
+		v1, v2 := at(start), at(start+10)
+
+		opposite := arc.PointAt(start + 180)
+		if _, _, onArc := pointToArcDistance(opposite, arc, v1, v2); onArc {
+			t.Errorf("start %g: opposite point treated as on chord", start)
+		}
+		middle := arc.PointAt(start + 5)
+		if _, offset, onArc := pointToArcDistance(middle, arc, v1, v2); !onArc || offset < 0.49 || offset > 0.51 {
+			t.Errorf("start %g: chord midpoint onArc=%v offset=%g", start, onArc, offset)
+		}
+	}
+}
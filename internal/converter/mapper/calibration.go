@@ -0,0 +1,99 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Scale calibration
+// ============================================================
+
+// ErrCalibration — калибровку из запроса нельзя применить к документу.
+var ErrCalibration = errors.New("calibration")
+
+// Calibration описывает перевод единиц SVG в сантиметры сцены. Используется первый
+// заданный способ: эталонный элемент, PixelsPerUnit, физические размеры документа.
+// Пустая калибровка сохраняет прежнее поведение: 1 единица SVG = 1 см.
+type Calibration struct {
+	ReferenceID     string  // id элемента с известной длиной (например, Wall_03)
+	ReferenceLength float64 // длина эталона в Unit
+	PixelsPerUnit   float64 // сколько единиц SVG приходится на одну Unit
+	Unit            string  // единица для ReferenceLength и PixelsPerUnit: cm (по умолчанию), mm, m, in, ft
+	DocumentUnits   bool    // взять масштаб из width/height и viewBox документа (210mm и т.п.)
+}
+
+// ParseReference разбирает эталон вида "Wall_03=420cm" (или "Wall_03:4.2m").
+func ParseReference(s string) (id string, length parser.Length, err error) {
+	sep := strings.IndexAny(s, "=:")
+	if sep <= 0 {
+		return "", parser.Length{}, fmt.Errorf("reference must look like <id>=<length>, got %q", s)
+	}
+	id = strings.TrimSpace(s[:sep])
+	length, err = parser.ParseLength(s[sep+1:])
+	if err != nil {
+		return "", parser.Length{}, err
+	}
+	if length.Value <= 0 {
+		return "", parser.Length{}, fmt.Errorf("reference length must be positive")
+	}
+	return id, length, nil
+}
+
+// IsZero сообщает, что калибровка не задана.
+func (cal Calibration) IsZero() bool {
+	return cal.ReferenceID == "" && cal.PixelsPerUnit == 0 && !cal.DocumentUnits
+}
+
+// resolve возвращает масштаб (см на единицу SVG) и способ, которым он получен.
+func (cal Calibration) resolve(doc *parser.Document, curveTolerance float64) (float64, string, error) {
+	unitCm, ok := parser.CentimetersPerUnit(cal.Unit)
+	if !ok {
+		return 0, "", fmt.Errorf("unknown unit %q", cal.Unit)
+	}
+
+	switch {
+	case cal.ReferenceID != "":
+		if cal.ReferenceLength <= 0 {
+			return 0, "", fmt.Errorf("reference length must be positive")
+		}
+		elem, ok := findElement(doc.Elements, cal.ReferenceID)
+		if !ok {
+			return 0, "", fmt.Errorf("reference element %s not found", cal.ReferenceID)
+		}
+		length, err := graph.MeasureWall(elem, curveTolerance)
+		if err != nil {
+			return 0, "", err
+		}
+		return cal.ReferenceLength * unitCm / length, "reference", nil
+
+	case cal.PixelsPerUnit != 0:
+		if cal.PixelsPerUnit < 0 {
+			return 0, "", fmt.Errorf("pixels per unit must be positive")
+		}
+		return unitCm / cal.PixelsPerUnit, "pixels_per_unit", nil
+
+	case cal.DocumentUnits:
+		scale, ok := doc.PhysicalScale()
+		if !ok {
+			return 0, "", fmt.Errorf("SVG width/height have no physical units (mm, cm, in, pt, pc)")
+		}
+		return scale, "document", nil
+	}
+
+	return 1, "none", nil
+}
+
+func findElement(elements []models.SVGElement, id string) (models.SVGElement, bool) {
+	for _, elem := range elements {
+		if elem.ID == id {
+			return elem, true
+		}
+	}
+	return models.SVGElement{}, false
+}
@@ -0,0 +1,66 @@
+package mapper
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"api-gateway/internal/converter/models"
+)
+
+// topology — число стен, вершин, комнат и распределение степеней вершин слоя.
+func topology(scene *models.Scene) string {
+	var out string
+	for _, id := range models.SortedKeys(scene.Layers) {
+		layer := scene.Layers[id]
+		degree := make(map[string]int)
+		for _, line := range layer.Lines {
+			for _, v := range line.Vertices {
+				degree[v]++
+			}
+		}
+		counts := make(map[int]int)
+		for _, d := range degree {
+			counts[d]++
+		}
+		out += fmt.Sprintf("%s: lines=%d vertices=%d holes=%d areas=%d degrees=%v\n",
+			id, len(layer.Lines), len(layer.Vertices), len(layer.Holes), len(layer.Areas), counts)
+	}
+	return out
+}
+
+// Калибровка меняет только масштаб: стены, узлы и комнаты должны совпадать.
+func TestCalibrationKeepsTopology(t *testing.T) {
+	files, err := filepath.Glob("../../../source/*/svg/*.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skip("no sample plans")
+	}
+
+	convert := func(file string, cal Calibration) string {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := New()
+		c.SetCalibration(cal)
+		scene, err := c.Convert(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		return topology(scene)
+	}
+
+	for _, file := range files {
+		want := convert(file, Calibration{})
+		for _, ppu := range []float64{0.2, 5, 37.8} {
+			if got := convert(file, Calibration{PixelsPerUnit: ppu}); got != want {
+				t.Errorf("%s, pixels_per_unit=%g:\n got %s\nwant %s", file, ppu, got, want)
+			}
+		}
+	}
+}
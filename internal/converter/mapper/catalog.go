@@ -0,0 +1,251 @@
+package mapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Item catalog
+// ============================================================
+
+// ItemRuleType — тип элемента SVG, который становится item каталога react-planner.
+const ItemRuleType = "item"
+
+// CatalogItem — item каталога react-planner и признаки его элементов в SVG.
+// Достаточно совпадения id или class. Размеры в плане берутся из геометрии элемента.
+type CatalogItem struct {
+	Type      string  `json:"type"`                 // тип item в каталоге react-planner: toilet, bathtub...
+	Name      string  `json:"name,omitempty"`       // имя item (по умолчанию — Type)
+	ID        string  `json:"id,omitempty"`         // regex по id элемента
+	Class     string  `json:"class,omitempty"`      // CSS класс элемента
+	Height    float64 `json:"height,omitempty"`     // высота, см
+	Altitude  float64 `json:"altitude,omitempty"`   // отметка низа над полом, см
+	AlignWall bool    `json:"align_wall,omitempty"` // повернуть вдоль ближайшей стены, задней стороной к ней
+
+	idRe *regexp.Regexp
+}
+
+// Catalog — таблица сопоставления элементов SVG и items каталога и типоразмеры проемов.
+// Порядок важен: побеждает первый.
+type Catalog struct {
+	Items    []CatalogItem    `json:"items"`
+	Openings []CatalogOpening `json:"openings,omitempty"`
+}
+
+// fixtureID — id вида "Toilet_01", "Sink 2", "wc": ключевое слово, затем конец или разделитель.
+func fixtureID(words string) string {
+	return `(?i)^(` + words + `)([_\s-]|$)`
+}
+
+// DefaultCatalog — сантехника, кухня и мебель, которые встречаются в исходных планах.
+func DefaultCatalog() *Catalog {
+	c := &Catalog{Items: []CatalogItem{
+		{Type: "toilet", ID: fixtureID("toilet|wc|унитаз"), Class: "toilet", Height: 80, AlignWall: true},
+		{Type: "bathtub", ID: fixtureID("bathtub|bath|ванна"), Class: "bathtub", Height: 60, AlignWall: true},
+		{Type: "shower", ID: fixtureID("shower|душ|душевая"), Class: "shower", Height: 220, AlignWall: true},
+		{Type: "sink", ID: fixtureID("sink|washbasin|раковина|мойка"), Class: "sink", Height: 85, AlignWall: true},
+		{Type: "stove", ID: fixtureID("stove|cooker|hob|плита"), Class: "stove", Height: 85, AlignWall: true},
+		{Type: "bed", ID: fixtureID("bed|кровать"), Class: "bed", Height: 50, AlignWall: true},
+		{Type: "wardrobe", ID: fixtureID("wardrobe|closet|шкаф"), Class: "wardrobe", Height: 220, AlignWall: true},
+	}, Openings: defaultOpenings()}
+	if err := c.compile(); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// LoadCatalog читает таблицу items из JSON файла.
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalog(data)
+}
+
+// ParseCatalog разбирает JSON вида {"items": [...], "openings": [...]} или просто массив items.
+// Без openings используются типоразмеры проемов по умолчанию.
+func ParseCatalog(data []byte) (*Catalog, error) {
+	var c Catalog
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &c.Items); err != nil {
+			return nil, fmt.Errorf("decode catalog: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("decode catalog: %w", err)
+	}
+
+	if len(c.Items) == 0 && len(c.Openings) == 0 {
+		return nil, fmt.Errorf("catalog is empty")
+	}
+	if len(c.Openings) == 0 {
+		c.Openings = defaultOpenings()
+	}
+	if err := c.compile(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *Catalog) compile() error {
+	for i := range c.Items {
+		item := &c.Items[i]
+		if item.Type == "" {
+			return fmt.Errorf("catalog item %d: type required", i)
+		}
+		if item.ID == "" && item.Class == "" {
+			return fmt.Errorf("catalog item %s: id or class required", item.Type)
+		}
+		if item.Height < 0 || item.Altitude < 0 {
+			return fmt.Errorf("catalog item %s: height and altitude must be non-negative", item.Type)
+		}
+		if item.ID != "" {
+			re, err := regexp.Compile(item.ID)
+			if err != nil {
+				return fmt.Errorf("catalog item %s: id: %w", item.Type, err)
+			}
+			item.idRe = re
+		}
+	}
+	for i := range c.Openings {
+		if err := c.Openings[i].compile(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ruleName — имя правила классификации для item каталога.
+func (item *CatalogItem) ruleName() string {
+	return "catalog-" + item.Type
+}
+
+// Rules дополняет правила классификации правилами каталога (после правил base, чтобы
+// комнаты "Bathroom_room" оставались комнатами). nil base — правила по умолчанию.
+func (c *Catalog) Rules(base *parser.RuleSet) (*parser.RuleSet, error) {
+	if c == nil {
+		return base, nil
+	}
+	if base == nil {
+		base = parser.DefaultRules()
+	}
+
+	var extra []parser.Rule
+	for _, item := range c.Items {
+		if item.ID != "" {
+			extra = append(extra, parser.Rule{Name: item.ruleName(), Type: ItemRuleType, ID: item.ID})
+		}
+		if item.Class != "" {
+			extra = append(extra, parser.Rule{Name: item.ruleName(), Type: ItemRuleType, Class: item.Class})
+		}
+	}
+	return base.With(extra...)
+}
+
+// Match находит item каталога для элемента: по сработавшему правилу каталога,
+// иначе (элемент размечен собственным правилом с type=item) — по id и class.
+func (c *Catalog) Match(elem models.SVGElement) (*CatalogItem, bool) {
+	if c == nil {
+		return nil, false
+	}
+	for i := range c.Items {
+		if elem.Rule == c.Items[i].ruleName() {
+			return &c.Items[i], true
+		}
+	}
+	classes := strings.Fields(elem.Class)
+	for i := range c.Items {
+		item := &c.Items[i]
+		if item.idRe != nil && item.idRe.MatchString(elem.ID) {
+			return item, true
+		}
+		for _, class := range classes {
+			if item.Class != "" && class == item.Class {
+				return item, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ============================================================
+// Catalog items
+// ============================================================
+
+// createCatalogItems создает items каталога: центр и размеры — по прямоугольнику элемента
+// вдоль ближайшей стены (или осей сцены), задняя сторона item (локальная -y) — к стене.
+func (c *Converter) createCatalogItems(elems []models.SVGElement, target map[string]models.Item) {
+	for _, elem := range elems {
+		entry, ok := c.catalog.Match(elem)
+		if !ok {
+			continue
+		}
+		points, err := c.getElementPoints(elem)
+		if err != nil || len(points) == 0 {
+			continue
+		}
+
+		rotation := 0.0
+		if entry.AlignWall {
+			centroid := averagePoint(points)
+			if lineID, angle := c.findNearestWallAngle(centroid); lineID != "" {
+				rotation = normalizeAngle(angle)
+				if c.wallInFront(lineID, centroid, rotation) {
+					rotation = normalizeAngle(rotation + 180)
+				}
+			}
+		}
+		width, depth, cx, cy := bboxAlongAxis(points, rotation)
+
+		name := entry.Name
+		if name == "" {
+			name = entry.Type
+		}
+		item := models.Item{
+			ID:         elem.ID,
+			Name:       name,
+			Type:       entry.Type,
+			Prototype:  "items",
+			X:          cx,
+			Y:          cy,
+			Rotation:   rotation,
+			Visible:    true,
+			Properties: catalogItemProperties(width, depth, entry.Height, entry.Altitude),
+		}
+		if src, ok := c.sources[elem.ID]; ok {
+			attachItemSources(&item, []models.SVGElement{src})
+		}
+		target[elem.ID] = item
+	}
+}
+
+// wallInFront сообщает, что стена lineID лежит со стороны локальной +y item с поворотом rotation.
+func (c *Converter) wallInFront(lineID string, p models.Point, rotation float64) bool {
+	line := c.builder.GetLines()[lineID]
+	vertices := c.builder.GetVertices()
+	v1, v2 := vertices[line.Vertices[0]], vertices[line.Vertices[1]]
+	_, t := pointToLineDistance(p, v1, v2)
+
+	rad := rotation * math.Pi / 180
+	dx := v1.X + t*(v2.X-v1.X) - p.X
+	dy := v1.Y + t*(v2.Y-v1.Y) - p.Y
+	return -dx*math.Sin(rad)+dy*math.Cos(rad) > 0
+}
+
+func catalogItemProperties(width, depth, height, altitude float64) map[string]any {
+	return map[string]any{
+		"width":    map[string]any{"length": width},
+		"depth":    map[string]any{"length": depth},
+		"height":   map[string]any{"length": height},
+		"altitude": map[string]any{"length": altitude},
+	}
+}
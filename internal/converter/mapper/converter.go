@@ -16,41 +16,211 @@ import (
 // ============================================================
 
 type Converter struct {
-	elements      []models.SVGElement
-	builder       *graph.GraphBuilder
-	bbox          *boundingBox
-	transformFunc func(models.Point) models.Point
+	elements       []models.SVGElement
+	sources        map[string]models.SVGElement // элементы в исходных координатах SVG
+	builder        *graph.GraphBuilder
+	transformFunc  func(models.Point) models.Point
+	curveTolerance float64
+	rules          *parser.RuleSet
+	calibration    Calibration
+	placement      Placement
+	roomDetection  string
+	floors         Floors
+	catalog        *Catalog
+	holeSnap       float64
+	balconies      [][]models.Point // контуры балконов этажа: окружение проемов
 }
 
+const (
+	RoomDetectionAuto = "auto" // комнаты из граней графа стен, если в SVG нет размеченных комнат
+	RoomDetectionOff  = "off"
+)
+
 func New() *Converter {
 	return &Converter{
-		builder: graph.NewGraphBuilder(),
+		builder:        graph.NewGraphBuilder(),
+		curveTolerance: parser.DefaultCurveTolerance,
+		placement:      DefaultPlacement(),
+		roomDetection:  RoomDetectionAuto,
+		floors:         DefaultFloors(),
+		catalog:        DefaultCatalog(),
+		holeSnap:       DefaultHoleSnap,
+	}
+}
+
+// SetCurveTolerance задает допуск аппроксимации кривых и дуг (в единицах SVG).
+func (c *Converter) SetCurveTolerance(tolerance float64) {
+	if tolerance <= 0 {
+		tolerance = parser.DefaultCurveTolerance
 	}
+	c.curveTolerance = tolerance
+	c.builder.SetCurveTolerance(tolerance)
+}
+
+// SetRules задает правила классификации элементов (nil — правила по умолчанию).
+func (c *Converter) SetRules(rules *parser.RuleSet) {
+	c.rules = rules
+}
+
+// SetCalibration задает перевод единиц SVG в сантиметры сцены.
+func (c *Converter) SetCalibration(cal Calibration) {
+	c.calibration = cal
+}
+
+// SetPlacement задает размещение плана на холсте сцены.
+func (c *Converter) SetPlacement(p Placement) {
+	c.placement = p
+}
+
+// SetRoomDetection задает режим поиска комнат по замкнутым контурам стен (auto, off).
+func (c *Converter) SetRoomDetection(mode string) {
+	c.roomDetection = mode
+}
+
+// SetCatalog задает таблицу items каталога react-planner (nil — items каталога не создаются).
+func (c *Converter) SetCatalog(catalog *Catalog) {
+	c.catalog = catalog
+}
+
+// SetFloors задает разбиение на этажи и их отметки.
+func (c *Converter) SetFloors(f Floors) {
+	c.floors = f
+}
+
+// SetHoleSnap задает, на сколько (в единицах сцены) проем может отстоять от грани стены;
+// более далекие проемы не добавляются в сцену. Отрицательное значение — допуск по умолчанию.
+func (c *Converter) SetHoleSnap(distance float64) {
+	if distance < 0 {
+		distance = DefaultHoleSnap
+	}
+	c.holeSnap = distance
 }
 
 // Convert SVG → react-planner JSON
 func (c *Converter) Convert(r io.Reader) (*models.Scene, error) {
-	const sceneWidth = 3000.0
-	const sceneHeight = 2000.0
+	return c.ConvertFloors([]FloorSource{{Data: r}})
+}
 
-	// Парсинг SVG
-	elements, err := parser.ParseSVG(r)
+// ConvertFloors собирает сцену из SVG этажей: каждый документ (или каждая группа этажа,
+// если задан Floors.Groups) становится слоем layer-N с отметкой altitude и порядком order.
+// Для одного этажа meta сцены такая же, как раньше; для нескольких — meta.layers по id слоя.
+func (c *Converter) ConvertFloors(sources []FloorSource) (*models.Scene, error) {
+	if err := c.placement.Validate(); err != nil {
+		return nil, fmt.Errorf("placement: %w", err)
+	}
+	if err := c.floors.Validate(); err != nil {
+		return nil, fmt.Errorf("floors: %w", err)
+	}
+	if c.roomDetection != RoomDetectionAuto && c.roomDetection != RoomDetectionOff {
+		return nil, fmt.Errorf("unknown room detection mode %q", c.roomDetection)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no SVG documents")
+	}
+
+	rules, err := c.catalog.Rules(c.rules)
 	if err != nil {
-		return nil, fmt.Errorf("parse SVG: %w", err)
+		return nil, fmt.Errorf("catalog: %w", err)
 	}
-	c.elements = elements
 
-	// Bounding box для трансформаций (отзеркалить и нормализовать в (0,0))
-	box, err := calculateBoundingBox(elements)
+	// Парсинг SVG и разбиение на этажи
+	var plans []floorPlan
+	var invalid []parser.SkippedElement
+	skipped := 0
+	for _, src := range sources {
+		doc, err := parser.ParseDocument(src.Data, rules)
+		if err != nil {
+			if src.Name != "" {
+				return nil, fmt.Errorf("parse SVG %s: %w", src.Name, err)
+			}
+			return nil, fmt.Errorf("parse SVG: %w", err)
+		}
+		invalid = append(invalid, doc.Skipped...)
+		if c.floors.Groups == nil {
+			plans = append(plans, floorPlan{name: src.Name, doc: doc})
+			continue
+		}
+		split, n, err := splitFloors(doc, c.floors.Groups)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFloors, err)
+		}
+		plans = append(plans, split...)
+		skipped += n
+	}
+	if len(c.floors.Altitudes) > 0 && len(c.floors.Altitudes) != len(plans) {
+		return nil, fmt.Errorf("%w: %d altitudes for %d floors", ErrFloors, len(c.floors.Altitudes), len(plans))
+	}
+
+	scene := &models.Scene{
+		Unit:          "cm",
+		Layers:        make(map[string]models.Layer, len(plans)),
+		SelectedLayer: "layer-1",
+		Grids:         defaultGrids(),
+		Groups:        map[string]any{},
+		Meta:          map[string]any{},
+		Guides:        defaultGuides(),
+	}
+	layersMeta := make(map[string]any, len(plans))
+
+	for i, plan := range plans {
+		layer, placement, meta, err := c.convertFloor(plan.doc)
+		if err != nil {
+			if len(plans) > 1 {
+				return nil, fmt.Errorf("floor %s: %w", floorName(plan.name, i, len(plans)), err)
+			}
+			return nil, err
+		}
+		layer.ID = fmt.Sprintf("layer-%d", i+1)
+		layer.Altitude = c.floors.altitude(i)
+		layer.Order = i
+		layer.Name = floorName(plan.name, i, len(plans))
+		scene.Layers[layer.ID] = layer
+		mergeCanvas(scene, placement)
+
+		if len(plans) == 1 {
+			scene.Meta = meta
+		} else {
+			layersMeta[layer.ID] = meta
+		}
+	}
+	if len(plans) > 1 {
+		scene.Meta["layers"] = layersMeta
+	}
+	if skipped > 0 {
+		scene.Meta["skippedElements"] = skipped
+	}
+	if len(invalid) > 0 {
+		scene.Meta["invalidElements"] = invalid
+	}
+
+	return scene, nil
+}
+
+// convertFloor строит слой из документа одного этажа. meta — калибровка, преобразования
+// и исходный документ этажа (для /render).
+func (c *Converter) convertFloor(doc *parser.Document) (models.Layer, placementResult, map[string]any, error) {
+	var placement placementResult
+
+	// Калибровка: все дальнейшие расчеты идут в сантиметрах
+	scale, source, err := c.calibration.resolve(doc, c.curveTolerance)
 	if err != nil {
-		return nil, fmt.Errorf("bbox: %w", err)
+		return models.Layer{}, placement, nil, fmt.Errorf("%w: %v", ErrCalibration, err)
 	}
-	c.bbox = box
-	c.transformFunc = c.mirrorTransform(sceneWidth, sceneHeight)
-	c.builder.SetTransform(c.transformFunc)
+	c.sources = make(map[string]models.SVGElement, len(doc.Elements))
+	for _, elem := range doc.Elements {
+		c.sources[elem.ID] = elem
+	}
+	elements, texts := doc.Elements, doc.Texts
+	if scale != 1 {
+		if elements, err = parser.TransformElements(elements, parser.Scale(scale, scale)); err != nil {
+			return models.Layer{}, placement, nil, fmt.Errorf("calibration: %w", err)
+		}
+		texts = parser.TransformTexts(texts, parser.Scale(scale, scale))
+	}
+	c.elements = elements
 
 	// Разделяем элементы по типам
-	var walls, doors, windows, rooms, balconies []models.SVGElement
+	var walls, doors, windows, rooms, balconies, fixtures, structural []models.SVGElement
 	for _, elem := range elements {
 		switch elem.Type {
 		case "wall":
@@ -63,42 +233,60 @@ func (c *Converter) Convert(r io.Reader) (*models.Scene, error) {
 			rooms = append(rooms, elem)
 		case "balcony":
 			balconies = append(balconies, elem)
+		case ItemRuleType:
+			fixtures = append(fixtures, elem)
+		case TypeColumn, TypeShaft, TypeStair:
+			structural = append(structural, elem)
 		}
 	}
 
+	// Размещение на холсте (поворот, отражение, сдвиг) по bounding box всех элементов
+	placement, err = c.placement.resolve(elements, walls, c.curveTolerance)
+	if err != nil {
+		return models.Layer{}, placement, nil, fmt.Errorf("placement: %w", err)
+	}
+	c.transformFunc = placement.matrix.Apply
+	c.builder.SetTransform(c.transformFunc)
+	c.builder.SetUnitScale(scale)
+
 	// Строим граф стен
 	if err := c.builder.BuildFromWalls(walls); err != nil {
-		return nil, fmt.Errorf("build walls graph: %w", err)
+		return models.Layer{}, placement, nil, fmt.Errorf("build walls graph: %w", err)
 	}
 
 	// Создаем holes (двери + окна)
 	holes := make(map[string]models.Hole)
-	for _, door := range doors {
-		if hole := c.createHole(door, "door"); hole != nil {
-			holes[door.ID] = *hole
+	var holeIssues []any
+	c.balconies = c.balconyOutlines(balconies, rooms)
+	for _, elem := range append(append([]models.SVGElement{}, doors...), windows...) {
+		hole, issue := c.createHole(elem, elem.Type)
+		if hole != nil {
+			holes[elem.ID] = *hole
 		}
-	}
-	for _, window := range windows {
-		if hole := c.createHole(window, "window"); hole != nil {
-			holes[window.ID] = *hole
+		if issue != nil {
+			holeIssues = append(holeIssues, issue)
 		}
 	}
+	holeIssues = append(holeIssues, holeOverlaps(holes, c.builder.GetLines(), c.builder.GetVertices())...)
+
+	// Колонны, шахты и лестницы — неподвижные items вне графа стен
+	items := make(map[string]models.Item)
+	obstacles := c.createStructuralItems(structural, items)
 
 	// Создаем areas (комнаты + балконы)
 	areas := make(map[string]models.Area)
-	items := make(map[string]models.Item)
 	for _, room := range rooms {
 		c.createArea(room, "room", areas)
 	}
+	if len(rooms) == 0 && c.roomDetection == RoomDetectionAuto {
+		c.createDetectedAreas(holes, obstacles, parser.TransformTexts(texts, placement.matrix), areas)
+	}
 	c.createBalconyItems(balconies, items)
+	c.createCatalogItems(fixtures, items)
 
-	// Собираем scene
+	// Собираем слой
 	layer := models.Layer{
-		ID:       "layer-1",
-		Altitude: 0,
-		Order:    0,
 		Opacity:  1,
-		Name:     "default",
 		Visible:  true,
 		Vertices: c.builder.GetVertices(),
 		Lines:    c.builder.GetLines(),
@@ -107,34 +295,48 @@ func (c *Converter) Convert(r io.Reader) (*models.Scene, error) {
 		Items:    items,
 		Selected: models.ElementsSet{Vertices: []string{}, Lines: []string{}, Holes: []string{}, Areas: []string{}, Items: []string{}},
 	}
+	attachSources(&layer, c.sources)
 
-	scene := &models.Scene{
-		Unit:          "cm",
-		Layers:        map[string]models.Layer{"layer-1": layer},
-		SelectedLayer: "layer-1",
-		Grids:         defaultGrids(),
-		Groups:        map[string]any{},
-		Width:         sceneWidth,
-		Height:        sceneHeight,
-		Meta:          map[string]any{},
-		Guides:        defaultGuides(),
+	meta := map[string]any{}
+	if !c.calibration.IsZero() {
+		meta["calibration"] = map[string]any{"source": source, "scale": scale}
 	}
 
-	return scene, nil
+	// Преобразование исходные координаты SVG → сцена и обратное к нему
+	transform := placement.matrix.Multiply(parser.Scale(scale, scale))
+	meta["transform"] = matrixMeta(transform)
+	if inverse, ok := transform.Invert(); ok {
+		meta["inverseTransform"] = matrixMeta(inverse)
+	}
+	sourceMeta := documentMeta(doc)
+	if unmapped := unmappedSources(layer, doc.Elements); len(unmapped) > 0 {
+		sourceMeta["unmapped"] = unmapped
+	}
+	if len(doc.Texts) > 0 {
+		sourceMeta["texts"] = textsMeta(doc.Texts)
+	}
+	meta["source"] = sourceMeta
+	if len(holeIssues) > 0 {
+		meta["holeIssues"] = holeIssues
+	}
+	if placement.rotation != 0 {
+		meta["rotation"] = placement.rotation
+	}
+
+	return layer, placement, meta, nil
 }
 
 // createHole создает hole из элемента (дверь/окно)
-func (c *Converter) createHole(elem models.SVGElement, holeType string) *models.Hole {
-	// Получаем центр проема
-	center := c.getElementCenter(elem)
-	if center == nil {
-		return nil
-	}
-
-	// Ищем ближайшую стену
-	lineID, offset := c.findNearestLine(*center)
+func (c *Converter) createHole(elem models.SVGElement, holeType string) (*models.Hole, map[string]any) {
+	// Размеры проема по его геометрии (толщина стены подставится после выбора стены)
+	props := holeProperties(elem, holeType, 0, c.curveTolerance)
+	width := models.LengthProperty(props, "width", 0)
+	thickness := models.LengthProperty(props, "thickness", 0)
+
+	// Ищем стену
+	lineID, offset, issue := c.placeHole(elem, width, thickness)
 	if lineID == "" {
-		return nil
+		return nil, issue
 	}
 
 	lineThickness := c.getLineThickness(lineID)
@@ -146,13 +348,27 @@ func (c *Converter) createHole(elem models.SVGElement, holeType string) *models.
 		Prototype:  "holes",
 		Line:       lineID,
 		Offset:     offset,
-		Properties: holeProperties(elem, holeType, lineThickness),
+		Visible:    true,
+		Properties: holeProperties(elem, holeType, lineThickness, c.curveTolerance),
+	}
+	if preset, ok := c.catalog.MatchOpening(holeType, elem, width, c.opensToBalcony(lineID, offset)); ok {
+		hole.Type = preset.Type
+		hole.Properties["kind"] = holeType
+		hole.Properties["height"] = map[string]any{"length": preset.Height}
+		hole.Properties["altitude"] = map[string]any{"length": preset.Altitude}
+	}
+	if holeType == "door" {
+		if hinge, swing, ok := c.doorSwing(elem, lineID, offset, width); ok {
+			hole.Properties["hinge"] = hinge
+			hole.Properties["swing"] = swing
+			hole.Properties["flip_orizzontal"] = hinge == HingeEnd
+		}
 	}
 
 	// Привязываем hole к линии
 	c.builder.AttachHoleToLine(lineID, elem.ID)
 
-	return hole
+	return hole, issue
 }
 
 // createArea создает area из элемента (комната/балкон)
@@ -176,6 +392,7 @@ func (c *Converter) createArea(elem models.SVGElement, areaType string, target m
 		Prototype:  "areas",
 		Vertices:   vertexIDs,
 		Holes:      []string{},
+		Visible:    true,
 		Properties: props,
 	}
 
@@ -191,6 +408,75 @@ func (c *Converter) createArea(elem models.SVGElement, areaType string, target m
 	target[elem.ID] = area
 }
 
+// createDetectedAreas создает комнаты из замкнутых контуров графа стен (план без размеченных комнат).
+// Разрывы стен в дверных и оконных проемах и у колонн и шахт в линии стены (obstacles) замыкаются,
+// названия берутся из подписей внутри контура.
+func (c *Converter) createDetectedAreas(holes map[string]models.Hole, obstacles []graph.Opening, labels []models.TextLabel, target map[string]models.Area) {
+	lines := c.builder.GetLines()
+	vertices := c.builder.GetVertices()
+
+	var openings []graph.Opening
+	for _, id := range models.SortedKeys(holes) {
+		hole := holes[id]
+		line, ok := lines[hole.Line]
+		if !ok {
+			continue
+		}
+		center := c.getElementCenter(c.elementByID(id))
+		if center == nil {
+			continue
+		}
+		v1, v2 := vertices[line.Vertices[0]], vertices[line.Vertices[1]]
+		openings = append(openings, graph.Opening{
+			ID:     id,
+			Center: *center,
+			Angle:  math.Atan2(v2.Y-v1.Y, v2.X-v1.X) * 180 / math.Pi,
+			Width:  models.LengthProperty(hole.Properties, "width", 0),
+		})
+	}
+
+	openings = append(openings, obstacles...)
+
+	n := 0
+	for _, room := range c.builder.DetectRooms(openings, labels) {
+		id := ""
+		for id == "" || target[id].ID != "" || c.sources[id].ID != "" {
+			n++
+			id = fmt.Sprintf("room_%d", n)
+		}
+
+		name := room.Label
+		if name == "" {
+			name = fmt.Sprintf("Room %d", n)
+		}
+		props := defaultAreaProperties()
+		props["name"] = name
+
+		area := models.Area{
+			ID:         id,
+			Name:       name,
+			Type:       "area",
+			Prototype:  "areas",
+			Vertices:   append(append([]string{}, room.Vertices...), room.Vertices[0]),
+			Holes:      []string{},
+			Visible:    true,
+			Properties: props,
+		}
+		c.builder.AttachAreaVertices(room.Vertices, id)
+		area.Misc = map[string]any{SourceMiscKey: map[string]any{
+			"detected":    true,
+			"fingerprint": areaFingerprint(area, vertices),
+		}}
+
+		target[id] = area
+	}
+}
+
+func (c *Converter) elementByID(id string) models.SVGElement {
+	elem, _ := findElement(c.elements, id)
+	return elem
+}
+
 // createBalconyItems группирует все balcony элементы в один item (как в demo).
 func (c *Converter) createBalconyItems(elems []models.SVGElement, target map[string]models.Item) {
 	if len(elems) == 0 {
@@ -256,6 +542,14 @@ func (c *Converter) createBalconyItems(elems []models.SVGElement, target map[str
 		Properties: defaultBalconyProperties(width, depth),
 	}
 
+	sources := make([]models.SVGElement, 0, len(elems))
+	for _, elem := range elems {
+		if src, ok := c.sources[elem.ID]; ok {
+			sources = append(sources, src)
+		}
+	}
+	attachItemSources(&item, sources)
+
 	target[itemID] = item
 }
 
@@ -272,8 +566,8 @@ func (c *Converter) getElementCenter(elem models.SVGElement) *models.Point {
 		}
 		t := c.transformFunc(p)
 		return &t
-	case models.PathGeometry:
-		points, err := parser.ParsePath(geom.D)
+	case models.PathGeometry, models.PolygonGeometry:
+		points, err := geometryPoints(geom, c.curveTolerance)
 		if err != nil || len(points) == 0 {
 			return nil
 		}
@@ -295,8 +589,8 @@ func (c *Converter) getElementCenter(elem models.SVGElement) *models.Point {
 
 func (c *Converter) getElementPoints(elem models.SVGElement) ([]models.Point, error) {
 	switch geom := elem.Geometry.(type) {
-	case models.PathGeometry:
-		points, err := parser.ParsePath(geom.D)
+	case models.PathGeometry, models.PolygonGeometry:
+		points, err := geometryPoints(geom, c.curveTolerance)
 		if err != nil {
 			return nil, err
 		}
@@ -320,35 +614,43 @@ func (c *Converter) getElementPoints(elem models.SVGElement) ([]models.Point, er
 	return nil, fmt.Errorf("unknown geometry type")
 }
 
+// geometryPoints возвращает точки контура path/polygon в координатах SVG.
+func geometryPoints(geometry interface{}, curveTolerance float64) ([]models.Point, error) {
+	switch geom := geometry.(type) {
+	case models.PathGeometry:
+		return parser.ParsePathTolerance(geom.D, curveTolerance)
+	case models.PolygonGeometry:
+		return append([]models.Point{}, geom.Points...), nil
+	}
+	return nil, fmt.Errorf("unknown geometry type")
+}
+
 func (c *Converter) findNearestLine(p models.Point) (string, float64) {
-	var nearestLineID string
-	var nearestOffset float64
-	minDist := math.MaxFloat64
+	nearestLineID, ok := c.builder.NearestLine(p, func(line models.Line, v1, v2 models.Vertex) float64 {
+		dist, _ := pointToWallDistance(p, line, v1, v2)
+		return dist
+	})
+	if !ok {
+		return "", 0
+	}
 
+	line := c.builder.GetLines()[nearestLineID]
 	vertices := c.builder.GetVertices()
-	lines := c.builder.GetLines()
+	_, nearestOffset := pointToWallDistance(p, line, vertices[line.Vertices[0]], vertices[line.Vertices[1]])
 
-	for lineID, line := range lines {
-		if len(line.Vertices) < 2 {
-			continue
-		}
-
-		v1, ok1 := vertices[line.Vertices[0]]
-		v2, ok2 := vertices[line.Vertices[1]]
-		if !ok1 || !ok2 {
-			continue
-		}
+	return nearestLineID, nearestOffset
+}
 
-		// Расстояние от точки до линии
-		dist, offset := pointToLineDistance(p, v1, v2)
-		if dist < minDist {
-			minDist = dist
-			nearestLineID = lineID
-			nearestOffset = offset
+// pointToWallDistance — расстояние от точки до линии стены и offset проекции
+// (для хорд изогнутой стены — до дуги).
+func pointToWallDistance(p models.Point, line models.Line, v1, v2 models.Vertex) (float64, float64) {
+	dist, offset := pointToLineDistance(p, v1, v2)
+	if arc, ok := graph.LineArc(line); ok {
+		if d, o, onArc := pointToArcDistance(p, arc, v1, v2); onArc {
+			dist, offset = d, o
 		}
 	}
-
-	return nearestLineID, nearestOffset
+	return dist, offset
 }
 
 func pointToLineDistance(p models.Point, v1, v2 models.Vertex) (float64, float64) {
@@ -376,6 +678,20 @@ func pointToLineDistance(p models.Point, v1, v2 models.Vertex) (float64, float64
 	return dist, offset
 }
 
+// pointToArcDistance измеряет расстояние до дуги в пределах хорды v1-v2 и offset
+// как долю угла хорды. onArc=false, если проекция точки не попадает на участок дуги.
+func pointToArcDistance(p models.Point, arc graph.Arc, v1, v2 models.Vertex) (float64, float64, bool) {
+	u1 := arc.Along(models.Point{X: v1.X, Y: v1.Y})
+	u2 := arc.Along(models.Point{X: v2.X, Y: v2.Y})
+	up := arc.Along(p)
+	if u1 == u2 || up < math.Min(u1, u2) || up > math.Max(u1, u2) {
+		return 0, 0, false
+	}
+
+	dist := math.Abs(math.Hypot(p.X-arc.Center.X, p.Y-arc.Center.Y) - arc.Radius)
+	return dist, (up - u1) / (u2 - u1), true
+}
+
 // ============================================================
 // Defaults
 // ============================================================
@@ -433,7 +749,7 @@ func (c *Converter) getLineThickness(lineID string) float64 {
 }
 
 // Вычисляет свойства проемов на основе геометрии (ширина/толщина), остальное — дефолты.
-func holeProperties(elem models.SVGElement, holeType string, lineThickness float64) map[string]any {
+func holeProperties(elem models.SVGElement, holeType string, lineThickness, curveTolerance float64) map[string]any {
 	width := 80.0
 	thickness := 30.0
 
@@ -447,32 +763,18 @@ func holeProperties(elem models.SVGElement, holeType string, lineThickness float
 		if t > 0 {
 			thickness = t
 		}
-	case models.PathGeometry:
-		points, err := parser.ParsePath(geom.D)
-		if err == nil && len(points) > 0 {
-			minX, maxX := points[0].X, points[0].X
-			minY, maxY := points[0].Y, points[0].Y
-			for _, p := range points {
-				if p.X < minX {
-					minX = p.X
-				}
-				if p.X > maxX {
-					maxX = p.X
-				}
-				if p.Y < minY {
-					minY = p.Y
-				}
-				if p.Y > maxY {
-					maxY = p.Y
-				}
-			}
-			w := math.Max(maxX-minX, maxY-minY)
-			t := math.Min(maxX-minX, maxY-minY)
-			if w > 0 {
-				width = w
+	case models.PathGeometry, models.PolygonGeometry:
+		points, err := geometryPoints(geom, curveTolerance)
+		if err != nil || len(points) == 0 {
+			break
+		}
+		// Габарит по прямоугольнику минимальной площади: на наклонной стене bbox занижает ширину
+		if _, _, length, depth, ok := graph.Footprint(points); ok {
+			if length > 0 {
+				width = length
 			}
-			if t > 0 {
-				thickness = t
+			if depth > 0 {
+				thickness = depth
 			}
 		}
 	}
@@ -521,7 +823,8 @@ type boundingBox struct {
 	maxY float64
 }
 
-func calculateBoundingBox(elems []models.SVGElement) (*boundingBox, error) {
+// calculateBoundingBox считает bbox элементов после применения матрицы m.
+func calculateBoundingBox(elems []models.SVGElement, curveTolerance float64, m parser.Matrix) (*boundingBox, error) {
 	box := &boundingBox{
 		minX: math.MaxFloat64,
 		maxX: -math.MaxFloat64,
@@ -530,6 +833,7 @@ func calculateBoundingBox(elems []models.SVGElement) (*boundingBox, error) {
 	}
 
 	update := func(p models.Point) {
+		p = m.Apply(p)
 		if p.X < box.minX {
 			box.minX = p.X
 		}
@@ -548,9 +852,11 @@ func calculateBoundingBox(elems []models.SVGElement) (*boundingBox, error) {
 		switch geom := elem.Geometry.(type) {
 		case models.RectGeometry:
 			update(models.Point{X: geom.X, Y: geom.Y})
+			update(models.Point{X: geom.X + geom.Width, Y: geom.Y})
 			update(models.Point{X: geom.X + geom.Width, Y: geom.Y + geom.Height})
-		case models.PathGeometry:
-			points, err := parser.ParsePath(geom.D)
+			update(models.Point{X: geom.X, Y: geom.Y + geom.Height})
+		case models.PathGeometry, models.PolygonGeometry:
+			points, err := geometryPoints(geom, curveTolerance)
 			if err != nil {
 				return nil, err
 			}
@@ -563,25 +869,6 @@ func calculateBoundingBox(elems []models.SVGElement) (*boundingBox, error) {
 	return box, nil
 }
 
-func (c *Converter) mirrorTransform(sceneWidth, sceneHeight float64) func(models.Point) models.Point {
-	if c.bbox == nil {
-		return func(p models.Point) models.Point { return p }
-	}
-
-	box := *c.bbox
-	width := box.maxX - box.minX
-	height := box.maxY - box.minY
-
-	return func(p models.Point) models.Point {
-		// Зеркалим по Y, затем центрируем на сцену
-		x := p.X - box.minX
-		y := height - (p.Y - box.minY)
-		x += (sceneWidth - width) / 2
-		y += (sceneHeight - height) / 2
-		return models.Point{X: x, Y: y}
-	}
-}
-
 func (c *Converter) applyTransform(points []models.Point) []models.Point {
 	tf := c.transformFunc
 	out := make([]models.Point, 0, len(points))
@@ -622,41 +909,21 @@ func averagePoint(points []models.Point) models.Point {
 
 // findNearestWallAngle возвращает id стены и угол в градусах (atan2) ближайшей линии к точке.
 func (c *Converter) findNearestWallAngle(p models.Point) (string, float64) {
-	lines := c.builder.GetLines()
-	verts := c.builder.GetVertices()
-
-	minDist := math.MaxFloat64
-	var chosen string
-	var angle float64
-
-	for id, line := range lines {
-		if len(line.Vertices) < 2 {
-			continue
-		}
-		v1, ok1 := verts[line.Vertices[0]]
-		v2, ok2 := verts[line.Vertices[1]]
-		if !ok1 || !ok2 {
-			continue
-		}
-		dx := v2.X - v1.X
-		dy := v2.Y - v1.Y
-		lenSq := dx*dx + dy*dy
-		if lenSq == 0 {
-			continue
-		}
-		// distance point-line
-		t := ((p.X-v1.X)*dx + (p.Y-v1.Y)*dy) / lenSq
-		t = math.Max(0, math.Min(1, t))
-		projX := v1.X + t*dx
-		projY := v1.Y + t*dy
-		dist := math.Hypot(p.X-projX, p.Y-projY)
-		if dist < minDist {
-			minDist = dist
-			chosen = id
-			angle = math.Atan2(dy, dx) * 180 / math.Pi
+	chosen, ok := c.builder.NearestLine(p, func(line models.Line, v1, v2 models.Vertex) float64 {
+		if v1.X == v2.X && v1.Y == v2.Y {
+			return math.MaxFloat64
 		}
+		dist, _ := pointToLineDistance(p, v1, v2)
+		return dist
+	})
+	if !ok {
+		return "", 0
 	}
-	return chosen, angle
+
+	line := c.builder.GetLines()[chosen]
+	vertices := c.builder.GetVertices()
+	v1, v2 := vertices[line.Vertices[0]], vertices[line.Vertices[1]]
+	return chosen, math.Atan2(v2.Y-v1.Y, v2.X-v1.X) * 180 / math.Pi
 }
 
 // bboxAlongAxis вычисляет размеры и центр прямоугольника точек в системе координат, повернутой на angleDeg.
@@ -0,0 +1,134 @@
+package mapper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Floors
+// ============================================================
+
+// DefaultFloorHeight — высота этажа по умолчанию, см.
+const DefaultFloorHeight = 300
+
+// FloorGroupPattern — группы <g> этажей по умолчанию: "floor-1", "Этаж 2", "level_0"...
+const FloorGroupPattern = `(?i)^(floor|этаж|level|storey)[\s_-]*-?\d+$`
+
+// ErrFloors — этажи из запроса нельзя сопоставить документам.
+var ErrFloors = errors.New("floors")
+
+// Floors описывает разбиение плана на этажи (слои сцены).
+type Floors struct {
+	Groups    *regexp.Regexp // группы <g> этажей в одном SVG; nil — документ целиком один этаж
+	Height    float64        // высота этажа, см: отметка этажа = номер × Height
+	Altitudes []float64      // явные отметки этажей, см (вместо Height)
+}
+
+// DefaultFloors — один этаж на документ, высота этажа 3 м.
+func DefaultFloors() Floors {
+	return Floors{Height: DefaultFloorHeight}
+}
+
+// Validate проверяет параметры этажей.
+func (f Floors) Validate() error {
+	if f.Height < 0 {
+		return fmt.Errorf("floor height must not be negative")
+	}
+	return nil
+}
+
+// altitude — отметка этажа с номером i (от нуля), см.
+func (f Floors) altitude(i int) float64 {
+	if i < len(f.Altitudes) {
+		return f.Altitudes[i]
+	}
+	return float64(i) * f.Height
+}
+
+// FloorSource — исходный SVG этажа. Name становится именем слоя.
+type FloorSource struct {
+	Name string
+	Data io.Reader
+}
+
+// floorPlan — документ одного этажа после разбиения.
+type floorPlan struct {
+	name string
+	doc  *parser.Document
+}
+
+// splitFloors делит документ по группам этажей. Этаж элемента — самая внешняя группа,
+// имя которой подходит под шаблон; элементы и подписи вне групп этажей пропускаются.
+// Этажи идут в порядке первого появления в документе; skipped — число пропущенных элементов.
+func splitFloors(doc *parser.Document, re *regexp.Regexp) (plans []floorPlan, skipped int, err error) {
+	index := make(map[string]int)
+	floorOf := func(groups []string) (*parser.Document, bool) {
+		name := ""
+		for i := len(groups) - 1; i >= 0; i-- {
+			if re.MatchString(groups[i]) {
+				name = groups[i]
+				break
+			}
+		}
+		if name == "" {
+			return nil, false
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(plans)
+			index[name] = i
+			plans = append(plans, floorPlan{name: name, doc: &parser.Document{
+				Width:   doc.Width,
+				Height:  doc.Height,
+				ViewBox: doc.ViewBox,
+			}})
+		}
+		return plans[i].doc, true
+	}
+
+	for _, elem := range doc.Elements {
+		floor, ok := floorOf(elem.Groups)
+		if !ok {
+			skipped++
+			continue
+		}
+		floor.Elements = append(floor.Elements, elem)
+	}
+	for _, text := range doc.Texts {
+		if floor, ok := floorOf(text.Groups); ok {
+			floor.Texts = append(floor.Texts, text)
+		}
+	}
+
+	if len(plans) == 0 {
+		return nil, 0, fmt.Errorf("no floor groups match %q", re.String())
+	}
+	return plans, skipped, nil
+}
+
+// floorName — имя слоя: имя файла или группы этажа, для единственного этажа без имени — default.
+func floorName(name string, i, total int) string {
+	if name != "" {
+		return name
+	}
+	if total == 1 {
+		return "default"
+	}
+	return fmt.Sprintf("floor %d", i+1)
+}
+
+// mergeCanvas — холст сцены вмещает холсты всех этажей.
+func mergeCanvas(scene *models.Scene, placement placementResult) {
+	if placement.width > scene.Width {
+		scene.Width = placement.width
+	}
+	if placement.height > scene.Height {
+		scene.Height = placement.height
+	}
+}
@@ -0,0 +1,289 @@
+package mapper
+
+import (
+	"math"
+	"sort"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Hole placement
+// ============================================================
+
+// DefaultHoleSnap — на сколько проем может отстоять от грани стены, в единицах сцены.
+const DefaultHoleSnap = 15.0
+
+// Замечания размещения проемов (meta.holeIssues).
+const (
+	HoleClamped  = "clamped"  // проем выходил за конец стены и сдвинут внутрь
+	HoleTooWide  = "too_wide" // проем шире стены, поставлен по ее середине
+	HoleOverlap  = "overlap"  // проемы на одной стене перекрываются
+	HoleDetached = "detached" // рядом нет стены, проем не добавлен в сцену
+)
+
+const (
+	holeAxisTolerance = 30.0 // допуск угла между проемом и стеной, градусы
+	holeAxisRatio     = 1.2  // проем с отношением сторон меньше — без выраженного направления
+)
+
+// holeCandidate — стена, на которую можно поставить проем.
+type holeCandidate struct {
+	line     string
+	offset   float64
+	dist     float64
+	coverage float64 // доля ширины проема в пределах стены
+	parallel bool
+}
+
+// better — стена вдоль проема, затем большая доля проема на стене, затем ближайшая.
+func (a holeCandidate) better(b holeCandidate) bool {
+	if a.parallel != b.parallel {
+		return a.parallel
+	}
+	if math.Abs(a.coverage-b.coverage) > 1e-6 {
+		return a.coverage > b.coverage
+	}
+	if a.dist != b.dist {
+		return a.dist < b.dist
+	}
+	return a.line < b.line
+}
+
+// placeHole выбирает стену и offset проема. Кандидаты — стены, от грани которых центр проема
+// не дальше holeSnap; из частей стены, разрезанной в узлах, выбирается та, на которой лежит
+// большая часть проема. offset ограничивается так, чтобы проем целиком помещался на линии.
+// issue — замечание для meta.holeIssues; пустой lineID — проем не размещен.
+func (c *Converter) placeHole(elem models.SVGElement, width, thickness float64) (lineID string, offset float64, issue map[string]any) {
+	center := c.getElementCenter(elem)
+	if center == nil {
+		return "", 0, nil
+	}
+	nearestID, _ := c.findNearestLine(*center)
+	if nearestID == "" {
+		return "", 0, nil
+	}
+
+	lines := c.builder.GetLines()
+	vertices := c.builder.GetVertices()
+	nearest := lines[nearestID]
+	nearestDist, _ := pointToWallDistance(*center, nearest, vertices[nearest.Vertices[0]], vertices[nearest.Vertices[1]])
+	axis, oriented := c.holeAxis(elem)
+
+	var best holeCandidate
+	radius := nearestDist + width/2 + thickness + c.holeSnap
+	for _, id := range c.builder.LineIndex().SearchRadius(*center, radius) {
+		line := lines[id]
+		v1, v2 := vertices[line.Vertices[0]], vertices[line.Vertices[1]]
+		dist, t := pointToWallDistance(*center, line, v1, v2)
+		if dist > (models.LengthProperty(line.Properties, "thickness", 0)+thickness)/2+c.holeSnap {
+			continue
+		}
+
+		candidate := holeCandidate{line: id, offset: t, dist: dist, coverage: 1, parallel: true}
+		if oriented {
+			diff := math.Abs(normalizeAngle(axis - math.Atan2(v2.Y-v1.Y, v2.X-v1.X)*180/math.Pi))
+			candidate.parallel = math.Min(diff, 180-diff) <= holeAxisTolerance
+		}
+		if _, isArc := graph.LineArc(line); !isArc {
+			candidate.coverage = holeCoverage(t, width, math.Hypot(v2.X-v1.X, v2.Y-v1.Y))
+		}
+		if best.line == "" || candidate.better(best) {
+			best = candidate
+		}
+	}
+
+	if best.line == "" {
+		return "", 0, map[string]any{
+			"id":       elem.ID,
+			"code":     HoleDetached,
+			"line":     nearestID,
+			"distance": models.Round(nearestDist, 2),
+		}
+	}
+
+	// изогнутая стена — цепочка коротких хорд: проем не ограничивается хордой
+	line := lines[best.line]
+	if _, isArc := graph.LineArc(line); isArc {
+		return best.line, best.offset, nil
+	}
+	v1, v2 := vertices[line.Vertices[0]], vertices[line.Vertices[1]]
+	length := math.Hypot(v2.X-v1.X, v2.Y-v1.Y)
+	offset, code := clampHoleOffset(best.offset, width, length)
+	switch code {
+	case HoleClamped:
+		issue = map[string]any{
+			"id":    elem.ID,
+			"code":  code,
+			"line":  best.line,
+			"shift": models.Round(math.Abs(offset-best.offset)*length, 2),
+		}
+	case HoleTooWide:
+		issue = map[string]any{
+			"id":     elem.ID,
+			"code":   code,
+			"line":   best.line,
+			"width":  models.Round(width, 2),
+			"length": models.Round(length, 2),
+		}
+	}
+	return best.line, offset, issue
+}
+
+// holeAxis — направление длинной стороны проема в градусах; oriented=false для проемов
+// без выраженного направления (квадратный контур двери с дугой открывания).
+func (c *Converter) holeAxis(elem models.SVGElement) (float64, bool) {
+	points, err := c.getElementPoints(elem)
+	if err != nil || len(points) == 0 {
+		return 0, false
+	}
+	_, angle, length, width, ok := graph.Footprint(points)
+	if !ok || length < width*holeAxisRatio {
+		return 0, false
+	}
+	return angle, true
+}
+
+// holeCoverage — доля ширины проема с центром в offset t, лежащая в пределах линии длины length.
+func holeCoverage(t, width, length float64) float64 {
+	if width <= 0 {
+		return 1
+	}
+	from := math.Max(t*length-width/2, 0)
+	to := math.Min(t*length+width/2, length)
+	return math.Max(0, math.Min(1, (to-from)/width))
+}
+
+// clampHoleOffset сдвигает центр проема так, чтобы проем помещался на линии:
+// offset в [width/2, length-width/2] / length. Проем шире линии ставится по середине.
+func clampHoleOffset(t, width, length float64) (float64, string) {
+	if length <= 0 || width <= 0 {
+		return t, ""
+	}
+	if width >= length {
+		return 0.5, HoleTooWide
+	}
+	margin := width / 2 / length
+	switch {
+	case t < margin:
+		return margin, HoleClamped
+	case t > 1-margin:
+		return 1 - margin, HoleClamped
+	}
+	return t, ""
+}
+
+// holeOverlaps находит перекрывающиеся проемы на одной линии.
+func holeOverlaps(holes map[string]models.Hole, lines map[string]models.Line, vertices map[string]models.Vertex) []any {
+	type span struct {
+		id       string
+		from, to float64
+	}
+	byLine := make(map[string][]span)
+	for _, id := range models.SortedKeys(holes) {
+		hole := holes[id]
+		line, ok := lines[hole.Line]
+		if !ok {
+			continue
+		}
+		v1, v2 := vertices[line.Vertices[0]], vertices[line.Vertices[1]]
+		length := math.Hypot(v2.X-v1.X, v2.Y-v1.Y)
+		width := models.LengthProperty(hole.Properties, "width", 0)
+		byLine[hole.Line] = append(byLine[hole.Line], span{
+			id:   id,
+			from: hole.Offset*length - width/2,
+			to:   hole.Offset*length + width/2,
+		})
+	}
+
+	var out []any
+	for _, lineID := range models.SortedKeys(byLine) {
+		spans := byLine[lineID]
+		sort.SliceStable(spans, func(i, j int) bool { return spans[i].from < spans[j].from })
+		for i := range spans {
+			for j := i + 1; j < len(spans) && spans[j].from < spans[i].to; j++ {
+				out = append(out, map[string]any{
+					"id":      spans[i].id,
+					"code":    HoleOverlap,
+					"line":    lineID,
+					"with":    spans[j].id,
+					"overlap": models.Round(math.Min(spans[i].to, spans[j].to)-spans[j].from, 2),
+				})
+			}
+		}
+	}
+	return out
+}
+
+// ============================================================
+// Door swing
+// ============================================================
+
+// Петля и сторона открывания двери (properties.hinge, properties.swing) относительно
+// направления линии стены v1 → v2: start — петля у конца проема со стороны v1,
+// left — дверь открывается влево от направления линии (в координатах сцены, y вверх).
+const (
+	HingeStart = "start"
+	HingeEnd   = "end"
+	SwingLeft  = "left"
+	SwingRight = "right"
+)
+
+const doorSwingReach = 0.3 // контур двери выходит за грань стены хотя бы на эту долю ширины проема
+
+// doorSwing определяет петлю и сторону открывания по контуру двери: дуге открывания или
+// открытому полотну (наклонный прямоугольник). Дальняя от стены точка контура — конец полотна,
+// петля — ближайшая к нему вдоль стены точка контура у стены. ok=false — контур не выходит
+// за стену (прямоугольник в проеме).
+func (c *Converter) doorSwing(elem models.SVGElement, lineID string, offset, width float64) (hinge, swing string, ok bool) {
+	points, err := c.getElementPoints(elem)
+	if err != nil || len(points) < 3 {
+		return "", "", false
+	}
+	line := c.builder.GetLines()[lineID]
+	vertices := c.builder.GetVertices()
+	v1, v2 := vertices[line.Vertices[0]], vertices[line.Vertices[1]]
+	length := math.Hypot(v2.X-v1.X, v2.Y-v1.Y)
+	if length == 0 {
+		return "", "", false
+	}
+	ux, uy := (v2.X-v1.X)/length, (v2.Y-v1.Y)/length
+	cx, cy := v1.X+(v2.X-v1.X)*offset, v1.Y+(v2.Y-v1.Y)*offset
+
+	// s — вдоль стены от центра проема, d — по нормали влево от направления линии
+	s := make([]float64, len(points))
+	d := make([]float64, len(points))
+	tip, near := 0, math.MaxFloat64
+	for i, p := range points {
+		s[i] = (p.X-cx)*ux + (p.Y-cy)*uy
+		d[i] = -(p.X-cx)*uy + (p.Y-cy)*ux
+		if math.Abs(d[i]) > math.Abs(d[tip]) {
+			tip = i
+		}
+		near = math.Min(near, math.Abs(d[i]))
+	}
+	reach := math.Abs(d[tip])
+	if reach-models.LengthProperty(line.Properties, "thickness", 0)/2 < doorSwingReach*width {
+		return "", "", false
+	}
+
+	hingeS, best := 0.0, math.MaxFloat64
+	for i := range points {
+		if math.Abs(d[i])-near > 0.2*(reach-near) {
+			continue
+		}
+		if dist := math.Abs(s[i] - s[tip]); dist < best {
+			hingeS, best = s[i], dist
+		}
+	}
+
+	hinge, swing = HingeStart, SwingLeft
+	if hingeS > 0 {
+		hinge = HingeEnd
+	}
+	if d[tip] < 0 {
+		swing = SwingRight
+	}
+	return hinge, swing, true
+}
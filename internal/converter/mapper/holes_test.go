@@ -0,0 +1,25 @@
+package mapper
+
+import (
+	"math"
+	"testing"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// Дверь на наклонной стене: ширина — длина проема вдоль стены, а не сторона bbox.
+func TestHolePropertiesOnDiagonalWall(t *testing.T) {
+	elem := models.SVGElement{ID: "Door_03", Type: "door", Tag: "path",
+		Geometry: models.PathGeometry{D: "M1142,1199l5,5,35-39-5-4Z"}}
+
+	props := holeProperties(elem, "door", 0, parser.DefaultCurveTolerance)
+	width := models.LengthProperty(props, "width", 0)
+	thickness := models.LengthProperty(props, "thickness", 0)
+	if want := math.Hypot(35, 39); math.Abs(width-want) > 1 {
+		t.Errorf("width = %.2f, want %.2f", width, want)
+	}
+	if want := math.Hypot(5, 5); math.Abs(thickness-want) > 1 {
+		t.Errorf("thickness = %.2f, want %.2f", thickness, want)
+	}
+}
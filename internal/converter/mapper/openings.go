@@ -0,0 +1,159 @@
+package mapper
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Opening presets
+// ============================================================
+
+// CatalogOpening — типоразмер проема: тип hole в каталоге react-planner и высоты по умолчанию.
+// Проем сопоставляется по виду, ширине и окружению; id и class, если заданы, тоже должны совпасть
+// (достаточно одного из них).
+type CatalogOpening struct {
+	Type     string  `json:"type"`                // тип hole в каталоге react-planner: door, door-double, sliding-door, window...
+	Kind     string  `json:"kind"`                // door или window — какие проемы SVG сопоставляются
+	ID       string  `json:"id,omitempty"`        // regex по id элемента
+	Class    string  `json:"class,omitempty"`     // CSS класс элемента
+	MinWidth float64 `json:"min_width,omitempty"` // ширина проема от, см
+	MaxWidth float64 `json:"max_width,omitempty"` // ширина проема до, см
+	Balcony  bool    `json:"balcony,omitempty"`   // только проемы на балкон или лоджию
+	Height   float64 `json:"height"`              // высота проема, см
+	Altitude float64 `json:"altitude"`            // отметка низа над полом, см
+
+	idRe *regexp.Regexp
+}
+
+// balconyPattern — комнаты-балконы и лоджии по id.
+var balconyPattern = regexp.MustCompile(`(?i)balcon|loggia|балкон|лодж`)
+
+const balconyProbe = 20.0 // насколько за грань стены проверяется окружение проема
+
+// defaultOpenings — типовые двери и окна жилых квартир.
+func defaultOpenings() []CatalogOpening {
+	openings := []CatalogOpening{
+		{Type: "door", Kind: "door", Balcony: true, Height: 220},
+		{Type: "sliding-door", Kind: "door", ID: `(?i)sliding|slide|купе|раздвиж`, Class: "sliding-door", Height: 215},
+		{Type: "door-double", Kind: "door", MinWidth: 110, Height: 215},
+		{Type: "door", Kind: "door", Height: 215},
+		{Type: "window", Kind: "window", ID: `(?i)french|panoram|французск|панорам`, Class: "french-window", Height: 220},
+		{Type: "window", Kind: "window", MaxWidth: 70, Height: 60, Altitude: 130},
+		{Type: "window", Kind: "window", Height: 140, Altitude: 90},
+	}
+	for i := range openings {
+		if err := openings[i].compile(i); err != nil {
+			panic(err)
+		}
+	}
+	return openings
+}
+
+func (o *CatalogOpening) compile(i int) error {
+	if o.Type == "" {
+		return fmt.Errorf("catalog opening %d: type required", i)
+	}
+	if o.Kind != "door" && o.Kind != "window" {
+		return fmt.Errorf("catalog opening %s: kind must be door or window", o.Type)
+	}
+	if o.Height <= 0 || o.Altitude < 0 {
+		return fmt.Errorf("catalog opening %s: height must be positive and altitude non-negative", o.Type)
+	}
+	if o.MaxWidth > 0 && o.MaxWidth < o.MinWidth {
+		return fmt.Errorf("catalog opening %s: max_width is less than min_width", o.Type)
+	}
+	if o.ID != "" {
+		re, err := regexp.Compile(o.ID)
+		if err != nil {
+			return fmt.Errorf("catalog opening %s: id: %w", o.Type, err)
+		}
+		o.idRe = re
+	}
+	return nil
+}
+
+// matches сообщает, что проем вида kind шириной width подходит под типоразмер.
+func (o *CatalogOpening) matches(kind string, elem models.SVGElement, width float64, balcony bool) bool {
+	if o.Kind != kind || (o.Balcony && !balcony) {
+		return false
+	}
+	if width < o.MinWidth || (o.MaxWidth > 0 && width > o.MaxWidth) {
+		return false
+	}
+	if o.idRe == nil && o.Class == "" {
+		return true
+	}
+	if o.idRe != nil && o.idRe.MatchString(elem.ID) {
+		return true
+	}
+	for _, class := range strings.Fields(elem.Class) {
+		if o.Class != "" && class == o.Class {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchOpening находит типоразмер для проема вида kind (door, window) шириной width;
+// balcony — проем ведет на балкон или лоджию.
+func (c *Catalog) MatchOpening(kind string, elem models.SVGElement, width float64, balcony bool) (*CatalogOpening, bool) {
+	if c == nil {
+		return nil, false
+	}
+	for i := range c.Openings {
+		if c.Openings[i].matches(kind, elem, width, balcony) {
+			return &c.Openings[i], true
+		}
+	}
+	return nil, false
+}
+
+// ============================================================
+// Opening context
+// ============================================================
+
+// balconyOutlines — контуры балконов и комнат-балконов (лоджий) этажа в координатах сцены.
+func (c *Converter) balconyOutlines(balconies, rooms []models.SVGElement) [][]models.Point {
+	var out [][]models.Point
+	for _, elem := range append(append([]models.SVGElement{}, balconies...), rooms...) {
+		if elem.Type == "room" && !balconyPattern.MatchString(elem.ID) {
+			continue
+		}
+		if points, err := c.getElementPoints(elem); err == nil && len(points) >= 3 {
+			out = append(out, points)
+		}
+	}
+	return out
+}
+
+// opensToBalcony сообщает, что по одну из сторон проема на линии lineID лежит балкон.
+func (c *Converter) opensToBalcony(lineID string, offset float64) bool {
+	if len(c.balconies) == 0 {
+		return false
+	}
+	line := c.builder.GetLines()[lineID]
+	vertices := c.builder.GetVertices()
+	v1, v2 := vertices[line.Vertices[0]], vertices[line.Vertices[1]]
+	length := math.Hypot(v2.X-v1.X, v2.Y-v1.Y)
+	if length == 0 {
+		return false
+	}
+	reach := models.LengthProperty(line.Properties, "thickness", 0)/2 + balconyProbe
+	nx, ny := -(v2.Y-v1.Y)/length*reach, (v2.X-v1.X)/length*reach
+	cx, cy := v1.X+(v2.X-v1.X)*offset, v1.Y+(v2.Y-v1.Y)*offset
+
+	for _, side := range []models.Point{{X: cx + nx, Y: cy + ny}, {X: cx - nx, Y: cy - ny}} {
+		for _, outline := range c.balconies {
+			if graph.PointInPolygon(side, outline) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package mapper
+
+import (
+	"fmt"
+	"math"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Scene placement
+// ============================================================
+
+const (
+	OriginCenter     = "center"      // центр плана в центре холста
+	OriginTopLeft    = "top-left"    // левый верхний угол плана в (margin, margin)
+	OriginBottomLeft = "bottom-left" // левый нижний угол плана в (margin, height-margin)
+	OriginNone       = "none"        // без сдвига: только поворот и отражение
+)
+
+// Placement описывает, как план размещается на холсте сцены.
+// Порядок преобразований: поворот к главной оси стен, отражение, сдвиг к origin.
+type Placement struct {
+	CanvasWidth  float64 // размер холста; игнорируется при AutoFit
+	CanvasHeight float64
+	AutoFit      bool    // холст = bbox плана + Margin с каждой стороны
+	Margin       float64 // отступ для AutoFit и origin top-left/bottom-left
+	MirrorX      bool    // отразить по горизонтали (x → -x)
+	MirrorY      bool    // отразить по вертикали (y → -y)
+	AlignAxis    bool    // повернуть план так, чтобы преобладающее направление стен стало горизонталью
+	Origin       string  // center, top-left, bottom-left, none
+}
+
+// DefaultPlacement — прежнее поведение: холст 3000x2000, отражение по Y, план по центру.
+func DefaultPlacement() Placement {
+	return Placement{
+		CanvasWidth:  3000,
+		CanvasHeight: 2000,
+		Margin:       100,
+		MirrorY:      true,
+		Origin:       OriginCenter,
+	}
+}
+
+// Validate проверяет параметры размещения.
+func (p Placement) Validate() error {
+	switch p.Origin {
+	case OriginCenter, OriginTopLeft, OriginBottomLeft, OriginNone:
+	default:
+		return fmt.Errorf("unknown origin %q", p.Origin)
+	}
+	if !p.AutoFit && (p.CanvasWidth <= 0 || p.CanvasHeight <= 0) {
+		return fmt.Errorf("canvas size must be positive")
+	}
+	if p.Margin < 0 {
+		return fmt.Errorf("margin must not be negative")
+	}
+	return nil
+}
+
+// placementResult — итоговая матрица (единицы после калибровки → сцена) и размер холста.
+type placementResult struct {
+	matrix   parser.Matrix
+	width    float64
+	height   float64
+	rotation float64
+}
+
+// resolve строит матрицу размещения для элементов (уже в сантиметрах).
+func (p Placement) resolve(elements []models.SVGElement, walls []models.SVGElement, curveTolerance float64) (placementResult, error) {
+	result := placementResult{width: p.CanvasWidth, height: p.CanvasHeight}
+
+	m := parser.Identity()
+	if p.AlignAxis {
+		result.rotation = -graph.DominantAxis(walls, curveTolerance)
+		m = parser.Rotate(result.rotation)
+	}
+	sx, sy := 1.0, 1.0
+	if p.MirrorX {
+		sx = -1
+	}
+	if p.MirrorY {
+		sy = -1
+	}
+	m = parser.Scale(sx, sy).Multiply(m)
+
+	box, err := calculateBoundingBox(elements, curveTolerance, m)
+	if err != nil {
+		return result, err
+	}
+	if box.minX > box.maxX {
+		// пустой план — холст без сдвига
+		box = &boundingBox{}
+	}
+	width, height := box.maxX-box.minX, box.maxY-box.minY
+
+	if p.AutoFit {
+		// погрешность поворота не должна добавлять лишний сантиметр холста
+		result.width = math.Ceil(width + 2*p.Margin - 1e-6)
+		result.height = math.Ceil(height + 2*p.Margin - 1e-6)
+	}
+
+	var tx, ty float64
+	switch p.Origin {
+	case OriginCenter:
+		tx = result.width/2 - (box.minX+box.maxX)/2
+		ty = result.height/2 - (box.minY+box.maxY)/2
+	case OriginTopLeft:
+		tx = p.Margin - box.minX
+		ty = p.Margin - box.minY
+	case OriginBottomLeft:
+		tx = p.Margin - box.minX
+		ty = result.height - p.Margin - box.maxY
+	}
+	result.matrix = parser.Translate(tx, ty).Multiply(m)
+
+	return result, nil
+}
+
+// matrixMeta сериализует матрицу для Scene.Meta.
+func matrixMeta(m parser.Matrix) map[string]any {
+	return map[string]any{"a": m.A, "b": m.B, "c": m.C, "d": m.D, "e": m.E, "f": m.F}
+}
+
+// MatrixFromMeta читает матрицу, записанную в Scene.Meta (в том числе после JSON round-trip).
+func MatrixFromMeta(v any) (parser.Matrix, bool) {
+	raw, ok := v.(map[string]any)
+	if !ok {
+		return parser.Matrix{}, false
+	}
+	var values [6]float64
+	for i, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		f, ok := raw[key].(float64)
+		if !ok {
+			return parser.Matrix{}, false
+		}
+		values[i] = f
+	}
+	return parser.Matrix{A: values[0], B: values[1], C: values[2], D: values[3], E: values[4], F: values[5]}, true
+}
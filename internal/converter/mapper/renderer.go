@@ -6,46 +6,66 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
+	"api-gateway/internal/converter/graph"
 	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
 )
 
 // ============================================================
 // Renderer
 // ============================================================
 
-type Renderer struct{}
+type Renderer struct {
+	theme       *Theme
+	annotations Annotations
+}
 
 func NewRenderer() *Renderer {
-	return &Renderer{}
+	return &Renderer{theme: debugTheme()}
+}
+
+// SetTheme задает оформление вывода; nil — тема debug.
+func (r *Renderer) SetTheme(theme *Theme) {
+	if theme == nil {
+		theme = debugTheme()
+	}
+	r.theme = theme
 }
 
-// Render собирает SVG из react-planner scene JSON.
+// SetAnnotations включает слои подписей: размеры, названия помещений, стрелку севера, линейку.
+func (r *Renderer) SetAnnotations(annotations Annotations) {
+	r.annotations = annotations
+}
+
+// Render собирает SVG из react-planner scene JSON. Для сцен из /convert (meta.inverseTransform)
+// SVG выводится в системе координат исходного документа, а элементы, которые не редактировали,
+// — в исходной геометрии и с исходными id.
 func (r *Renderer) Render(scene *models.Scene) (string, error) {
+	return r.RenderLayer(scene, "")
+}
+
+// RenderLayer выводит один слой сцены. Пустой layerID — выбранный слой (selectedLayer) или первый.
+func (r *Renderer) RenderLayer(scene *models.Scene, layerID string) (string, error) {
 	if scene == nil {
 		return "", fmt.Errorf("scene is nil")
 	}
 
-	layer, err := r.pickLayer(scene)
+	layer, err := r.pickLayer(scene, layerID)
 	if err != nil {
 		return "", err
 	}
 
-	width, height := r.sceneSize(scene, layer)
-
-	var elements []string
-	elements = append(elements, r.renderWalls(layer)...)
-	elements = append(elements, r.renderAreas(layer)...)
-	elements = append(elements, r.renderHoles(layer)...)
-	elements = append(elements, r.renderBalconies(layer)...)
+	width, height, box, elements := r.renderLayer(scene, layer)
 
 	var builder strings.Builder
 	builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
-	builder.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`,
-		formatFloat(width), formatFloat(height), formatFloat(width), formatFloat(height)))
+	builder.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="%s">`,
+		width, height, formatViewBox(box)))
 	builder.WriteString("\n")
 
-	for _, elem := range elements {
+	for _, elem := range r.sheet(box, elements) {
 		builder.WriteString("  ")
 		builder.WriteString(elem)
 		builder.WriteString("\n")
@@ -55,28 +75,319 @@ func (r *Renderer) Render(scene *models.Scene) (string, error) {
 	return builder.String(), nil
 }
 
+// RenderedLayer — SVG одного слоя.
+type RenderedLayer struct {
+	ID       string
+	Name     string
+	Altitude float64
+	SVG      string
+}
+
+// RenderLayers выводит каждый слой отдельным SVG, снизу вверх по altitude и order.
+func (r *Renderer) RenderLayers(scene *models.Scene) ([]RenderedLayer, error) {
+	if scene == nil {
+		return nil, fmt.Errorf("scene is nil")
+	}
+	if len(scene.Layers) == 0 {
+		return nil, fmt.Errorf("scene has no layers")
+	}
+
+	var out []RenderedLayer
+	for _, layer := range sortedLayers(scene) {
+		svg, err := r.RenderLayer(scene, layer.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, RenderedLayer{ID: layer.ID, Name: layer.Name, Altitude: layer.Altitude, SVG: svg})
+	}
+	return out, nil
+}
+
+// Отступы сводного листа этажей, в единицах сцены.
+const (
+	stackGap      = 100 // между этажами
+	stackCaption  = 80  // полоса подписи над этажом
+	stackFontSize = 48
+)
+
+// RenderStack выводит все слои на одном листе: верхний этаж сверху, над каждым — подпись
+// с именем и отметкой. Каждый этаж — вложенный <svg> размером с холст сцены.
+func (r *Renderer) RenderStack(scene *models.Scene) (string, error) {
+	if scene == nil {
+		return "", fmt.Errorf("scene is nil")
+	}
+	if len(scene.Layers) == 0 {
+		return "", fmt.Errorf("scene has no layers")
+	}
+
+	layers := sortedLayers(scene)
+	cmPerUnit, ok := parser.CentimetersPerUnit(scene.Unit)
+	if !ok {
+		cmPerUnit = 1
+	}
+
+	var body []string
+	totalWidth, y := 0.0, 0.0
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		slotWidth, slotHeight := r.sceneSize(scene, layer)
+		_, _, _, elements := r.renderLayer(scene, layer)
+
+		caption := fmt.Sprintf("%s (%+.2f m)", layer.Name, layer.Altitude*cmPerUnit/100)
+		body = append(body, fmt.Sprintf(`<text x="0" y="%s" %s>%s</text>`,
+			formatFloat(y+stackFontSize), r.theme.textAttrs("sans-serif", stackFontSize), escapeAttr(caption)))
+		y += stackCaption
+
+		// холст сцены в системе координат вывода слоя: этажи одного масштаба и без отражения
+		minX, minY, maxX, maxY := canvasBounds(slotWidth, slotHeight, newRenderFrame(layerMeta(scene, layer.ID)))
+		body = append(body, fmt.Sprintf(`<svg id="%s" x="0" y="%s" width="%s" height="%s" viewBox="%s %s %s %s" data-altitude="%s">`,
+			escapeAttr(layer.ID), formatFloat(y), formatFloat(slotWidth), formatFloat(slotHeight),
+			formatFloat(minX), formatFloat(minY), formatFloat(maxX-minX), formatFloat(maxY-minY), formatFloat(layer.Altitude)))
+		for _, elem := range elements {
+			body = append(body, "  "+elem)
+		}
+		body = append(body, `</svg>`)
+
+		totalWidth = math.Max(totalWidth, slotWidth)
+		y += slotHeight
+		if i > 0 {
+			y += stackGap
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	builder.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`,
+		formatFloat(totalWidth), formatFloat(y), formatFloat(totalWidth), formatFloat(y)))
+	builder.WriteString("\n")
+	for _, elem := range r.sheet([4]float64{0, 0, totalWidth, y}, body) {
+		builder.WriteString("  ")
+		builder.WriteString(elem)
+		builder.WriteString("\n")
+	}
+	builder.WriteString(`</svg>`)
+	return builder.String(), nil
+}
+
+// sheet дополняет элементы листа фоном и узорами штриховки темы.
+func (r *Renderer) sheet(box [4]float64, elements []string) []string {
+	var out []string
+	if defs := r.theme.defs(); defs != "" {
+		out = append(out, defs)
+	}
+	if bg := r.theme.background(box); bg != "" {
+		out = append(out, bg)
+	}
+	return append(out, elements...)
+}
+
+// renderLayer возвращает атрибуты корневого <svg> и элементы слоя. Комнаты выводятся
+// первыми, чтобы их заливка не перекрывала стены.
+func (r *Renderer) renderLayer(scene *models.Scene, layer models.Layer) (string, string, [4]float64, []string) {
+	meta := layerMeta(scene, layer.ID)
+	frame := newRenderFrame(meta)
+	width, height, box := r.canvas(scene, layer, meta, frame)
+
+	var elements []string
+	elements = append(elements, r.renderAreas(layer, frame)...)
+	elements = append(elements, r.renderWalls(layer, frame)...)
+	elements = append(elements, r.renderHoles(layer, frame)...)
+	elements = append(elements, r.renderItems(layer, frame)...)
+	elements = append(elements, r.renderUnmapped(meta, frame)...)
+	elements = append(elements, r.renderTexts(meta, frame)...)
+	elements = append(elements, r.renderAnnotations(scene, layer, meta, box, frame)...)
+	return width, height, box, elements
+}
+
 // ============================================================
 // Layer selection & sizing
 // ============================================================
 
-func (r *Renderer) pickLayer(scene *models.Scene) (models.Layer, error) {
-	if len(scene.Layers) == 0 {
-		return models.Layer{}, fmt.Errorf("scene has no layers")
+// ErrLayerNotFound — в сцене нет запрошенного слоя.
+var ErrLayerNotFound = models.ErrLayerNotFound
+
+func (r *Renderer) pickLayer(scene *models.Scene, layerID string) (models.Layer, error) {
+	id, err := scene.PickLayer(layerID)
+	if err != nil {
+		return models.Layer{}, err
+	}
+	// id слоя — ключ в scene.layers, даже если поле id не заполнено (по нему ищется meta.layers)
+	layer := scene.Layers[id]
+	if layer.ID == "" {
+		layer.ID = id
+	}
+	return layer, nil
+}
+
+// sortedLayers — слои снизу вверх: по altitude, затем order и id.
+func sortedLayers(scene *models.Scene) []models.Layer {
+	layers := make([]models.Layer, 0, len(scene.Layers))
+	for id, layer := range scene.Layers {
+		if layer.ID == "" {
+			layer.ID = id
+		}
+		layers = append(layers, layer)
+	}
+	sort.Slice(layers, func(i, j int) bool {
+		a, b := layers[i], layers[j]
+		if a.Altitude != b.Altitude {
+			return a.Altitude < b.Altitude
+		}
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		return a.ID < b.ID
+	})
+	return layers
+}
+
+// layerMeta — meta этажа из многоэтажной сцены (meta.layers[id]), иначе meta всей сцены.
+func layerMeta(scene *models.Scene, layerID string) map[string]any {
+	if layers, ok := scene.Meta["layers"].(map[string]any); ok {
+		if meta, ok := layers[layerID].(map[string]any); ok {
+			return meta
+		}
+	}
+	return scene.Meta
+}
+
+// canvas возвращает атрибуты width и height корневого <svg> и его viewBox (minX, minY, width, height).
+// В системе координат исходного документа берутся его размеры из meta.source; meta приходит
+// от клиента, поэтому значения разбираются и выводятся заново, а при ошибке берется холст сцены.
+func (r *Renderer) canvas(scene *models.Scene, layer models.Layer, meta map[string]any, frame renderFrame) (string, string, [4]float64) {
+	width, height := r.sceneSize(scene, layer)
+	if !frame.source {
+		return formatFloat(width), formatFloat(height), [4]float64{0, 0, width, height}
 	}
 
-	if scene.SelectedLayer != "" {
-		if layer, ok := scene.Layers[scene.SelectedLayer]; ok {
-			return layer, nil
+	source, _ := meta["source"].(map[string]any)
+	rawWidth, _ := source["width"].(string)
+	rawHeight, _ := source["height"].(string)
+	rawViewBox, _ := source["viewBox"].(string)
+	srcWidth, okW := parseCanvasLength(rawWidth)
+	srcHeight, okH := parseCanvasLength(rawHeight)
+
+	box, ok := parseViewBox(rawViewBox)
+	if !ok {
+		// холст сцены в исходных координатах
+		minX, minY, maxX, maxY := canvasBounds(width, height, frame)
+		box = [4]float64{minX, minY, maxX - minX, maxY - minY}
+		if okW && okH {
+			// без viewBox исходные единицы — px от (0,0)
+			box = [4]float64{0, 0, srcWidth.Value, srcHeight.Value}
 		}
 	}
 
-	var ids []string
-	for id := range scene.Layers {
-		ids = append(ids, id)
+	if !okW || !okH {
+		return formatFloat(box[2]), formatFloat(box[3]), box
 	}
-	sort.Strings(ids)
+	return formatFloat(srcWidth.Value) + srcWidth.Unit, formatFloat(srcHeight.Value) + srcHeight.Unit, box
+}
 
-	return scene.Layers[ids[0]], nil
+// parseCanvasLength разбирает width/height исходного документа; годится только конечная положительная длина.
+func parseCanvasLength(s string) (parser.Length, bool) {
+	length, err := parser.ParseLength(s)
+	if err != nil || !positiveFinite(length.Value) {
+		return parser.Length{}, false
+	}
+	return length, true
+}
+
+// parseViewBox разбирает "minX minY width height" (через пробелы или запятые).
+func parseViewBox(s string) ([4]float64, bool) {
+	var box [4]float64
+	parts := strings.FieldsFunc(s, func(c rune) bool { return c == ',' || unicode.IsSpace(c) })
+	if len(parts) != 4 {
+		return box, false
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return box, false
+		}
+		box[i] = v
+	}
+	return box, positiveFinite(box[2]) && positiveFinite(box[3])
+}
+
+func positiveFinite(v float64) bool {
+	return v > 0 && !math.IsInf(v, 0)
+}
+
+// formatViewBox — значение атрибута viewBox.
+func formatViewBox(box [4]float64) string {
+	return formatFloat(box[0]) + " " + formatFloat(box[1]) + " " + formatFloat(box[2]) + " " + formatFloat(box[3])
+}
+
+// canvasBounds — bounding box холста сцены width×height в системе координат вывода.
+func canvasBounds(width, height float64, frame renderFrame) (float64, float64, float64, float64) {
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, corner := range []models.Point{{}, {X: width}, {X: width, Y: height}, {Y: height}} {
+		p := frame.point(corner.X, corner.Y)
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	return minX, minY, maxX, maxY
+}
+
+// ContentBounds возвращает рамку чертежа слоя (minX, minY, width, height) в координатах viewBox,
+// который выводит RenderLayer: вершины стен и контуров с полутолщиной стен и габариты объектов,
+// с наружными размерными цепочками — с их отступом. Стрелка севера и линейка привязаны к углам листа,
+// поэтому с ними, как и для пустого слоя, рамка — весь лист.
+func (r *Renderer) ContentBounds(scene *models.Scene, layerID string) ([4]float64, error) {
+	if scene == nil {
+		return [4]float64{}, fmt.Errorf("scene is nil")
+	}
+	layer, err := r.pickLayer(scene, layerID)
+	if err != nil {
+		return [4]float64{}, err
+	}
+	meta := layerMeta(scene, layer.ID)
+	frame := newRenderFrame(meta)
+	_, _, box := r.canvas(scene, layer, meta, frame)
+	if r.annotations.North || r.annotations.Scale {
+		return box, nil
+	}
+
+	// вершины — оси стен: рамка расширяется на полутолщину стены и отступ наружной цепочки,
+	// в единицах сцены
+	pad := 0.0
+	for _, line := range layer.Lines {
+		pad = math.Max(pad, models.LengthProperty(line.Properties, "thickness", 0)/2)
+	}
+	if r.annotations.Exterior {
+		cmPerUnit, ok := parser.CentimetersPerUnit(scene.Unit)
+		if !ok || cmPerUnit <= 0 {
+			cmPerUnit = 1
+		}
+		pad += (dimensionOffset + 2*annotationFont) / cmPerUnit
+	}
+
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	add := func(x, y float64) {
+		p := frame.point(x, y)
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	for _, v := range layer.Vertices {
+		add(v.X-pad, v.Y-pad)
+		add(v.X+pad, v.Y+pad)
+		add(v.X-pad, v.Y+pad)
+		add(v.X+pad, v.Y-pad)
+	}
+	for _, item := range layer.Items {
+		width := models.LengthProperty(item.Properties, "width", 100)
+		depth := models.LengthProperty(item.Properties, "depth", 100)
+		for _, p := range rectanglePoints(item.X, item.Y, width, depth, item.Rotation) {
+			add(p.X, p.Y)
+		}
+	}
+	if minX > maxX || minY > maxY {
+		return box, nil
+	}
+	return [4]float64{minX, minY, maxX - minX, maxY - minY}, nil
 }
 
 func (r *Renderer) sceneSize(scene *models.Scene, layer models.Layer) (float64, float64) {
@@ -119,67 +430,244 @@ func (r *Renderer) sceneSize(scene *models.Scene, layer models.Layer) (float64,
 }
 
 // ============================================================
-// Element renderers
+// Output frame
 // ============================================================
 
-func (r *Renderer) renderWalls(layer models.Layer) []string {
-	var out []string
+// renderFrame переводит координаты сцены в систему координат вывода:
+// исходный SVG (по meta.inverseTransform) или саму сцену.
+type renderFrame struct {
+	m      parser.Matrix
+	scale  float64 // длина в выводе на единицу длины сцены
+	source bool    // вывод в координатах исходного документа
+}
 
-	for _, line := range layer.Lines {
+func newRenderFrame(meta map[string]any) renderFrame {
+	if m, ok := MatrixFromMeta(meta["inverseTransform"]); ok && m.Det() != 0 {
+		return renderFrame{m: m, scale: math.Sqrt(math.Abs(m.Det())), source: true}
+	}
+	return renderFrame{m: parser.Identity(), scale: 1}
+}
+
+func (f renderFrame) point(x, y float64) models.Point {
+	return f.m.Apply(models.Point{X: x, Y: y})
+}
+
+// mirrored сообщает, что вывод отражен относительно сцены (направление дуг меняется).
+func (f renderFrame) mirrored() bool {
+	return f.m.Det() < 0
+}
+
+// pristine сообщает, что объект не менялся после /convert и его можно вывести как исходный элемент.
+func (f renderFrame) pristine(src sourceInfo, fingerprint string) bool {
+	return f.source && src.Fingerprint != "" && src.Fingerprint == fingerprint
+}
+
+// ============================================================
+// Element renderers
+// ============================================================
+
+func (r *Renderer) renderWalls(layer models.Layer, frame renderFrame) []string {
+	// линии группируются по исходному элементу: части разрезанной стены выводятся одной стеной
+	groups := make(map[string][]models.Line)
+	sources := make(map[string]sourceInfo)
+	for _, id := range models.SortedKeys(layer.Lines) {
+		line := layer.Lines[id]
 		if len(line.Vertices) < 2 {
 			continue
 		}
+		key := line.ID
+		if src, ok := sourceFromMisc(line.Misc); ok && frame.source {
+			key = src.ID
+			sources[key] = src
+		}
+		groups[key] = append(groups[key], line)
+	}
+
+	joints := newWallJoints(layer)
+	var out []string
+	var curved []models.Line
+	for _, key := range models.SortedKeys(groups) {
+		lines := groups[key]
+
+		if src, ok := sources[key]; ok && src.Parts == len(lines) {
+			pristine := true
+			for _, line := range lines {
+				lineSrc, _ := sourceFromMisc(line.Misc)
+				pristine = pristine && frame.pristine(lineSrc, lineFingerprint(line, layer.Vertices))
+			}
+			if svg, ok := sourceSVG(src, r.theme.paint("wall")); ok && pristine {
+				out = append(out, svg)
+				continue
+			}
+		}
 
-		v1, ok1 := layer.Vertices[line.Vertices[0]]
-		v2, ok2 := layer.Vertices[line.Vertices[1]]
-		if !ok1 || !ok2 {
-			continue
+		if _, isArc := graph.LineArc(lines[0]); isArc {
+			curved = append(curved, lines...)
+			continue // изогнутые стены рисуются целиком в renderCurvedWalls
 		}
 
-		thickness := lengthFromProperties(line.Properties, "thickness", 10)
-		if thickness == 0 {
-			thickness = 10
+		ids, runs := wallRuns(key, lines)
+		for i, run := range runs {
+			out = append(out, r.renderWallRun(ids[i], run, layer, joints, frame)...)
 		}
+	}
 
-		dx := v2.X - v1.X
-		dy := v2.Y - v1.Y
-		horizontal := math.Abs(dx) >= math.Abs(dy)
+	return append(out, r.renderCurvedWalls(curved, layer.Vertices, frame)...)
+}
+
+// orientedOutline выводит прямоугольник вдоль отрезка a-b шириной width: <rect>, если он
+// параллелен осям вывода, иначе <path>.
+func orientedOutline(id string, a, b models.Point, width float64, paint string, frame renderFrame) string {
+	length := math.Hypot(b.X-a.X, b.Y-a.Y)
+	nx, ny := 0.0, 1.0
+	if length > 0 {
+		nx, ny = -(b.Y-a.Y)/length, (b.X-a.X)/length
+	}
+	h := width / 2
+	corners := []models.Point{
+		frame.point(a.X+nx*h, a.Y+ny*h),
+		frame.point(b.X+nx*h, b.Y+ny*h),
+		frame.point(b.X-nx*h, b.Y-ny*h),
+		frame.point(a.X-nx*h, a.Y-ny*h),
+	}
+
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, p := range corners {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	axisAligned := true
+	for _, p := range corners {
+		onX := math.Abs(p.X-minX) < 1e-6 || math.Abs(p.X-maxX) < 1e-6
+		onY := math.Abs(p.Y-minY) < 1e-6 || math.Abs(p.Y-maxY) < 1e-6
+		axisAligned = axisAligned && onX && onY
+	}
 
-		if horizontal {
-			width := math.Abs(dx)
-			if width == 0 {
-				width = thickness
+	if axisAligned {
+		return fmt.Sprintf(`<rect id="%s" x="%s" y="%s" width="%s" height="%s" %s />`,
+			escapeAttr(id), formatFloat(minX), formatFloat(minY), formatFloat(maxX-minX), formatFloat(maxY-minY), paint)
+	}
+	return polygonPath(id, corners, paint)
+}
+
+// polygonPath выводит замкнутый контур; paint — атрибуты fill и stroke.
+func polygonPath(id string, points []models.Point, paint string) string {
+	var path strings.Builder
+	path.WriteString(`<path id="`)
+	path.WriteString(escapeAttr(id))
+	path.WriteString(`" d="M `)
+	path.WriteString(formatPoint(points[0]))
+	for _, p := range points[1:] {
+		path.WriteString(" L ")
+		path.WriteString(formatPoint(p))
+	}
+	path.WriteString(` Z" `)
+	path.WriteString(paint)
+	path.WriteString(` />`)
+	return path.String()
+}
+
+// renderCurvedWalls рисует каждую изогнутую стену (цепочку хорд с общей группой)
+// одним кольцевым сектором с учетом толщины.
+func (r *Renderer) renderCurvedWalls(lines []models.Line, vertices map[string]models.Vertex, frame renderFrame) []string {
+	type curvedWall struct {
+		arc       graph.Arc
+		thickness float64
+		minU      float64
+		maxU      float64
+	}
+
+	groups := make(map[string]*curvedWall)
+	for _, line := range lines {
+		arc, ok := graph.LineArc(line)
+		if !ok || len(line.Vertices) < 2 {
+			continue
+		}
+		wall, exists := groups[arc.Group]
+		if !exists {
+			wall = &curvedWall{arc: arc, minU: math.MaxFloat64, maxU: -math.MaxFloat64}
+			groups[arc.Group] = wall
+		}
+		wall.thickness = math.Max(wall.thickness, models.LengthProperty(line.Properties, "thickness", 10))
+		for _, vid := range line.Vertices {
+			v, ok := vertices[vid]
+			if !ok {
+				continue
 			}
-			x := math.Min(v1.X, v2.X)
-			y := ((v1.Y + v2.Y) / 2) - thickness/2
-			out = append(out, fmt.Sprintf(`<rect id="%s" x="%s" y="%s" width="%s" height="%s" fill="none" stroke="#000" />`,
-				line.ID, formatFloat(x), formatFloat(y), formatFloat(width), formatFloat(thickness)))
+			u := wall.arc.Along(models.Point{X: v.X, Y: v.Y})
+			wall.minU = math.Min(wall.minU, u)
+			wall.maxU = math.Max(wall.maxU, u)
+		}
+	}
+
+	var out []string
+	for _, id := range models.SortedKeys(groups) {
+		wall := groups[id]
+		if wall.maxU <= wall.minU {
 			continue
 		}
 
-		height := math.Abs(dy)
-		if height == 0 {
-			height = thickness
+		sign := 1.0
+		clockwise := wall.arc.Sweep >= 0
+		if wall.arc.Sweep < 0 {
+			sign = -1
+		}
+		if frame.mirrored() {
+			clockwise = !clockwise
 		}
-		x := ((v1.X + v2.X) / 2) - thickness/2
-		y := math.Min(v1.Y, v2.Y)
+		sweepFlag, backFlag := "1", "0"
+		if !clockwise {
+			sweepFlag, backFlag = "0", "1"
+		}
+		largeArc := "0"
+		if wall.maxU-wall.minU > 180 {
+			largeArc = "1"
+		}
+
+		a0 := wall.arc.Start + sign*wall.minU
+		a1 := wall.arc.Start + sign*wall.maxU
+		outer := wall.arc
+		outer.Radius += wall.thickness / 2
+		inner := wall.arc
+		inner.Radius = math.Max(0, inner.Radius-wall.thickness/2)
+		at := func(arc graph.Arc, angle float64) string {
+			p := arc.PointAt(angle)
+			return formatPoint(frame.point(p.X, p.Y))
+		}
+		ro, ri := formatFloat(outer.Radius*frame.scale), formatFloat(inner.Radius*frame.scale)
 
-		out = append(out, fmt.Sprintf(`<rect id="%s" x="%s" y="%s" width="%s" height="%s" fill="none" stroke="#000" />`,
-			line.ID, formatFloat(x), formatFloat(y), formatFloat(thickness), formatFloat(height)))
+		d := fmt.Sprintf("M %s A %s %s 0 %s %s %s L %s A %s %s 0 %s %s %s Z",
+			at(outer, a0),
+			ro, ro, largeArc, sweepFlag, at(outer, a1),
+			at(inner, a1),
+			ri, ri, largeArc, backFlag, at(inner, a0))
+
+		out = append(out, fmt.Sprintf(`<path id="%s" d="%s" %s />`, escapeAttr(id), d, r.theme.paint("wall")))
 	}
 
 	return out
 }
 
-func (r *Renderer) renderHoles(layer models.Layer) []string {
+func (r *Renderer) renderHoles(layer models.Layer, frame renderFrame) []string {
 	var out []string
 
-	for _, hole := range layer.Holes {
+	for _, id := range models.SortedKeys(layer.Holes) {
+		hole := layer.Holes[id]
 		line, ok := layer.Lines[hole.Line]
 		if !ok || len(line.Vertices) < 2 {
 			continue
 		}
 
+		kind := hole.Kind()
+
+		if src, ok := sourceFromMisc(hole.Misc); ok && frame.pristine(src, holeFingerprint(hole, &layer)) {
+			if svg, ok := sourceSVG(src, r.theme.paint(kind)); ok {
+				out = append(out, svg)
+				continue
+			}
+		}
+
 		v1, ok1 := layer.Vertices[line.Vertices[0]]
 		v2, ok2 := layer.Vertices[line.Vertices[1]]
 		if !ok1 || !ok2 {
@@ -193,88 +681,191 @@ func (r *Renderer) renderHoles(layer models.Layer) []string {
 		cx := v1.X + dx*offset
 		cy := v1.Y + dy*offset
 
-		width := lengthFromProperties(hole.Properties, "width", 80)
-		thickness := lengthFromProperties(hole.Properties, "thickness", lengthFromProperties(line.Properties, "thickness", 10))
+		width := models.LengthProperty(hole.Properties, "width", 80)
+		thickness := models.LengthProperty(hole.Properties, "thickness", models.LengthProperty(line.Properties, "thickness", 10))
 
-		horizontal := math.Abs(dx) >= math.Abs(dy)
-
-		var w, h, x, y float64
-		if horizontal {
-			w = width
-			h = thickness
-			x = cx - w/2
-			y = cy - h/2
-		} else {
-			w = thickness
-			h = width
-			x = cx - w/2
-			y = cy - h/2
+		// проем вдоль линии стены
+		ux, uy := 1.0, 0.0
+		if l := math.Hypot(dx, dy); l > 0 {
+			ux, uy = dx/l, dy/l
 		}
+		a := models.Point{X: cx - ux*width/2, Y: cy - uy*width/2}
+		b := models.Point{X: cx + ux*width/2, Y: cy + uy*width/2}
 
-		stroke := "#1f77b4"
-		if hole.Type == "door" {
-			stroke = "#d62728"
+		out = append(out, orientedOutline(hole.ID, a, b, thickness, r.theme.paint(kind), frame))
+		if kind == "door" {
+			if svg, ok := doorSwingSVG(hole, a, b, thickness, r.theme.line(kind), frame); ok {
+				out = append(out, svg)
+			}
 		}
-
-		out = append(out, fmt.Sprintf(`<rect id="%s" x="%s" y="%s" width="%s" height="%s" fill="none" stroke="%s" />`,
-			hole.ID, formatFloat(x), formatFloat(y), formatFloat(w), formatFloat(h), stroke))
 	}
 
 	return out
 }
 
-func (r *Renderer) renderAreas(layer models.Layer) []string {
+// doorSwingSVG рисует полотно и дугу открывания двери от грани стены со стороны открывания:
+// полотно от петли (properties.hinge) перпендикулярно стене, дуга — до второго края проема.
+// a и b — края проема на оси стены (от v1 к v2).
+func doorSwingSVG(hole models.Hole, a, b models.Point, thickness float64, paint string, frame renderFrame) (string, bool) {
+	hinge, _ := hole.Properties["hinge"].(string)
+	swing, _ := hole.Properties["swing"].(string)
+	if (hinge != HingeStart && hinge != HingeEnd) || (swing != SwingLeft && swing != SwingRight) {
+		return "", false
+	}
+	width := math.Hypot(b.X-a.X, b.Y-a.Y)
+	if width == 0 {
+		return "", false
+	}
+
+	// нормаль влево от направления линии, к стороне открывания
+	nx, ny := -(b.Y-a.Y)/width, (b.X-a.X)/width
+	if swing == SwingRight {
+		nx, ny = -nx, -ny
+	}
+	pivot, free := a, b
+	if hinge == HingeEnd {
+		pivot, free = b, a
+	}
+	face := func(p models.Point, extra float64) models.Point {
+		return frame.point(p.X+nx*(thickness/2+extra), p.Y+ny*(thickness/2+extra))
+	}
+	center, tip, end := face(pivot, 0), face(pivot, width), face(free, 0)
+
+	// направление дуги — в координатах вывода (рамка может отражать сцену)
+	sweep := 0
+	if (tip.X-center.X)*(end.Y-center.Y)-(tip.Y-center.Y)*(end.X-center.X) > 0 {
+		sweep = 1
+	}
+	r := math.Hypot(tip.X-center.X, tip.Y-center.Y)
+	return fmt.Sprintf(`<path d="M %s L %s A %s %s 0 0 %d %s" %s />`,
+		formatPoint(center), formatPoint(tip), formatFloat(r), formatFloat(r), sweep, formatPoint(end), paint), true
+}
+
+func (r *Renderer) renderAreas(layer models.Layer, frame renderFrame) []string {
 	var out []string
 
-	for _, area := range layer.Areas {
+	for _, id := range models.SortedKeys(layer.Areas) {
+		area := layer.Areas[id]
+		paint := r.theme.roomPaint(area.Name, area.ID)
+		if src, ok := sourceFromMisc(area.Misc); ok && frame.pristine(src, areaFingerprint(area, layer.Vertices)) {
+			if src.Detected {
+				continue // найдена по контуру стен, в исходном SVG ее не было
+			}
+			if svg, ok := sourceSVG(src, paint); ok {
+				out = append(out, svg)
+				continue
+			}
+		}
+
 		points := r.collectAreaPoints(area, layer.Vertices)
 		if len(points) < 3 {
 			continue
 		}
-
-		var path strings.Builder
-		path.WriteString(`<path id="`)
-		path.WriteString(area.ID)
-		path.WriteString(`" d="M `)
-		path.WriteString(formatPoint(points[0]))
-		for _, p := range points[1:] {
-			path.WriteString(" L ")
-			path.WriteString(formatPoint(p))
+		for i, p := range points {
+			points[i] = frame.point(p.X, p.Y)
 		}
-		path.WriteString(` Z" fill="none" stroke="#888" />`)
 
-		out = append(out, path.String())
+		out = append(out, polygonPath(area.ID, points, paint))
 	}
 
 	return out
 }
 
-func (r *Renderer) renderBalconies(layer models.Layer) []string {
+// renderItems выводит балконы и items каталога прямоугольниками width×depth с поворотом,
+// колонны, шахты и лестницы — условными обозначениями.
+func (r *Renderer) renderItems(layer models.Layer, frame renderFrame) []string {
 	var out []string
 
-	for _, item := range layer.Items {
-		if item.Type != "balcony" {
+	for _, id := range models.SortedKeys(layer.Items) {
+		item := layer.Items[id]
+		paint := r.theme.paint(itemKind(item.Type))
+
+		if src, ok := sourceFromMisc(item.Misc); ok && frame.pristine(src, itemFingerprint(item)) {
+			for _, elem := range src.Elements {
+				if svg, ok := sourceSVG(elem, paint); ok {
+					out = append(out, svg)
+				}
+			}
+			continue
+		}
+
+		if svg := structuralSVG(item, r.theme, frame); svg != nil {
+			out = append(out, svg...)
 			continue
 		}
 
-		width := lengthFromProperties(item.Properties, "width", 100)
-		depth := lengthFromProperties(item.Properties, "depth", 100)
+		width := models.LengthProperty(item.Properties, "width", 100)
+		depth := models.LengthProperty(item.Properties, "depth", 100)
 		points := rectanglePoints(item.X, item.Y, width, depth, item.Rotation)
+		for i, p := range points {
+			points[i] = frame.point(p.X, p.Y)
+		}
+
+		out = append(out, polygonPath(item.ID, points, paint))
+	}
 
-		var path strings.Builder
-		path.WriteString(`<path id="`)
-		path.WriteString(item.ID)
-		path.WriteString(`" d="M `)
-		path.WriteString(formatPoint(points[0]))
-		for _, p := range points[1:] {
-			path.WriteString(" L ")
-			path.WriteString(formatPoint(p))
+	return out
+}
+
+// itemKind — вид item для стиля темы: балкон, колонна, шахта, лестница или item каталога.
+func itemKind(itemType string) string {
+	switch itemType {
+	case "balcony", TypeColumn, TypeShaft, TypeStair:
+		return itemType
+	}
+	return ItemRuleType
+}
+
+// renderUnmapped выводит исходные элементы, которые /convert не смог отобразить в сцену.
+func (r *Renderer) renderUnmapped(meta map[string]any, frame renderFrame) []string {
+	if !frame.source {
+		return nil
+	}
+	source, _ := meta["source"].(map[string]any)
+	list, _ := source["unmapped"].([]any)
+
+	var out []string
+	for _, item := range list {
+		raw, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if src, ok := parseSourceInfo(raw); ok {
+			if svg, ok := sourceSVG(src, r.theme.paint(src.Type)); ok {
+				out = append(out, svg)
+			}
 		}
-		path.WriteString(` Z" fill="none" stroke="#2ca02c" />`)
+	}
+	return out
+}
 
-		out = append(out, path.String())
+// renderTexts выводит подписи исходного документа.
+func (r *Renderer) renderTexts(meta map[string]any, frame renderFrame) []string {
+	if !frame.source {
+		return nil
 	}
+	source, _ := meta["source"].(map[string]any)
+	list, _ := source["texts"].([]any)
 
+	var out []string
+	for _, item := range list {
+		raw, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		text, _ := raw["text"].(string)
+		x, _ := raw["x"].(float64)
+		y, _ := raw["y"].(float64)
+		attrs := ""
+		if id, _ := raw["id"].(string); id != "" {
+			attrs = fmt.Sprintf(`id="%s" `, escapeAttr(id))
+		}
+		font := r.theme.textAttrs("", 0)
+		if font != "" {
+			font = " " + font
+		}
+		out = append(out, fmt.Sprintf(`<text %sx="%s" y="%s"%s>%s</text>`, attrs, formatFloat(x), formatFloat(y), font, escapeAttr(text)))
+	}
 	return out
 }
 
@@ -347,26 +938,6 @@ func clamp(val, min, max float64) float64 {
 // Formatting helpers
 // ============================================================
 
-func lengthFromProperties(props map[string]any, key string, def float64) float64 {
-	if props == nil {
-		return def
-	}
-
-	if raw, ok := props[key]; ok {
-		switch v := raw.(type) {
-		case float64:
-			return v
-		case map[string]any:
-			if val, ok := v["length"]; ok {
-				if f, ok := val.(float64); ok {
-					return f
-				}
-			}
-		}
-	}
-	return def
-}
-
 func formatFloat(val float64) string {
 	return strconv.FormatFloat(val, 'f', -1, 64)
 }
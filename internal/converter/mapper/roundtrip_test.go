@@ -0,0 +1,147 @@
+package mapper
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+const roundTripTolerance = 0.5 // допуск сравнения bbox элементов, единицы SVG
+
+// elementBounds — bbox классифицированных элементов документа по id.
+func elementBounds(t *testing.T, doc *parser.Document) map[string]*boundingBox {
+	t.Helper()
+	out := make(map[string]*boundingBox, len(doc.Elements))
+	for _, elem := range doc.Elements {
+		box, err := calculateBoundingBox([]models.SVGElement{elem}, parser.DefaultCurveTolerance, parser.Identity())
+		if err != nil {
+			t.Fatalf("%s: %v", elem.ID, err)
+		}
+		out[elem.ID] = box
+	}
+	return out
+}
+
+// Сцена из /convert, выведенная обратно в SVG, должна воспроизводить исходные элементы:
+// те же id и типы, та же геометрия в координатах исходного документа.
+func TestConvertRenderRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../../../source/*/svg/*.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skip("no sample plans")
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input, err := parser.ParseDocument(bytes.NewReader(data), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if len(input.Elements) == 0 {
+			t.Fatalf("%s: no classified elements", file)
+		}
+		scene, err := New().Convert(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: convert: %v", file, err)
+		}
+		svg, err := NewRenderer().RenderLayer(scene, "")
+		if err != nil {
+			t.Fatalf("%s: render: %v", file, err)
+		}
+		output, err := parser.ParseDocument(strings.NewReader(svg), nil)
+		if err != nil {
+			t.Fatalf("%s: parse rendered SVG: %v", file, err)
+		}
+
+		types := make(map[string]string, len(output.Elements))
+		for _, elem := range output.Elements {
+			types[elem.ID] = elem.Type
+		}
+		want, got := elementBounds(t, input), elementBounds(t, output)
+		for _, elem := range input.Elements {
+			box, ok := got[elem.ID]
+			if !ok {
+				t.Errorf("%s: %s %s missing from rendered SVG", file, elem.Type, elem.ID)
+				continue
+			}
+			if types[elem.ID] != elem.Type {
+				t.Errorf("%s: %s rendered as %s, want %s", file, elem.ID, types[elem.ID], elem.Type)
+			}
+			w := want[elem.ID]
+			if math.Abs(box.minX-w.minX) > roundTripTolerance || math.Abs(box.minY-w.minY) > roundTripTolerance ||
+				math.Abs(box.maxX-w.maxX) > roundTripTolerance || math.Abs(box.maxY-w.maxY) > roundTripTolerance {
+				t.Errorf("%s: %s bounds %+v, want %+v", file, elem.ID, *box, *w)
+			}
+		}
+	}
+}
+
+// Размеры исходного документа в meta приходят от клиента: в корневой <svg> и фон листа
+// попадают только разобранные числа, а некорректные значения заменяются холстом сцены.
+func TestRenderSourceCanvasFromMeta(t *testing.T) {
+	cases := []struct {
+		name                   string
+		source                 map[string]any
+		width, height, viewBox string
+	}{
+		{"valid", map[string]any{"width": "420mm", "height": "297mm", "viewBox": "0,0 1587 1123"},
+			"420mm", "297mm", "0 0 1587 1123"},
+		{"injected width", map[string]any{"width": `10" onload="alert(1)`, "height": "20", "viewBox": "0 0 50 60"},
+			"50", "60", "0 0 50 60"},
+		{"short viewBox", map[string]any{"width": "100", "height": "80", "viewBox": "0 0"},
+			"100", "80", "0 0 100 80"},
+		{"injected viewBox", map[string]any{"viewBox": `0 0 1 1"><script>alert(1)</script>`},
+			"200", "100", "10 20 200 100"},
+		{"zero size", map[string]any{"width": "0", "height": "-5", "viewBox": "0 0 0 10"},
+			"200", "100", "10 20 200 100"},
+	}
+
+	for _, tc := range cases {
+		scene := &models.Scene{
+			Unit: "cm", Width: 200, Height: 100,
+			Layers: map[string]models.Layer{"layer-1": {ID: "layer-1"}},
+			Meta: map[string]any{
+				"inverseTransform": map[string]any{"a": 1.0, "b": 0.0, "c": 0.0, "d": 1.0, "e": 10.0, "f": 20.0},
+				"source":           tc.source,
+			},
+		}
+		theme := debugTheme()
+		theme.Background = "#fff"
+		renderer := NewRenderer()
+		renderer.SetTheme(theme)
+		svg, err := renderer.RenderLayer(scene, "")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if strings.Contains(svg, "alert") {
+			t.Errorf("%s: meta value leaked into SVG:\n%s", tc.name, svg)
+		}
+		root := `width="` + tc.width + `" height="` + tc.height + `" viewBox="` + tc.viewBox + `"`
+		if !strings.Contains(svg, root) {
+			t.Errorf("%s: root <svg> lacks %s:\n%s", tc.name, root, svg)
+		}
+		box := strings.Fields(tc.viewBox)
+		background := `<rect x="` + box[0] + `" y="` + box[1] + `" width="` + box[2] + `" height="` + box[3] + `"`
+		if !strings.Contains(svg, background) {
+			t.Errorf("%s: background lacks %s:\n%s", tc.name, background, svg)
+		}
+		doc, err := parser.ParseDocument(strings.NewReader(svg), nil)
+		if err != nil {
+			t.Fatalf("%s: parse rendered SVG: %v", tc.name, err)
+		}
+		if len(doc.ViewBox) != 4 {
+			t.Errorf("%s: rendered viewBox %v", tc.name, doc.ViewBox)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package mapper
+
+import (
+	"fmt"
+	"strings"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// Source metadata (round-trip /convert → /render)
+// ============================================================
+
+// SourceMiscKey — ключ в Misc линий, проемов, комнат и items с данными исходного элемента SVG:
+// id, tag, class, геометрия в исходных координатах и отпечаток состояния сцены после конвертации.
+// Пока отпечаток совпадает (элемент не редактировали), /render выводит исходный элемент как есть.
+const SourceMiscKey = "source"
+
+// sourceInfo — разобранные метаданные исходного элемента.
+type sourceInfo struct {
+	ID          string
+	Type        string
+	Tag         string
+	Class       string
+	Geometry    map[string]any
+	Fingerprint string
+	Parts       int          // сколько линий сцены построено из элемента (стены режутся на части)
+	Elements    []sourceInfo // для item, собранного из нескольких элементов (балкон)
+	Detected    bool         // комната найдена по контуру стен, в исходном SVG ее нет
+}
+
+// elementSource описывает исходный элемент (геометрия до калибровки и размещения).
+func elementSource(elem models.SVGElement) map[string]any {
+	meta := map[string]any{
+		"id":       elem.ID,
+		"type":     elem.Type,
+		"tag":      elem.Tag,
+		"geometry": geometryMeta(elem.Geometry),
+	}
+	if elem.Class != "" {
+		meta["class"] = elem.Class
+	}
+	return meta
+}
+
+func geometryMeta(geometry interface{}) map[string]any {
+	switch geom := geometry.(type) {
+	case models.RectGeometry:
+		return map[string]any{"rect": []any{geom.X, geom.Y, geom.Width, geom.Height}}
+	case models.PathGeometry:
+		return map[string]any{"d": geom.D}
+	case models.PolygonGeometry:
+		points := make([]any, 0, 2*len(geom.Points))
+		for _, p := range geom.Points {
+			points = append(points, p.X, p.Y)
+		}
+		return map[string]any{"points": points, "closed": geom.Closed}
+	}
+	return map[string]any{}
+}
+
+// documentMeta — размеры исходного документа для Scene.Meta["source"].
+func documentMeta(doc *parser.Document) map[string]any {
+	meta := map[string]any{}
+	if doc.Width.Value > 0 {
+		meta["width"] = formatFloat(doc.Width.Value) + doc.Width.Unit
+	}
+	if doc.Height.Value > 0 {
+		meta["height"] = formatFloat(doc.Height.Value) + doc.Height.Unit
+	}
+	if doc.ViewBox != nil {
+		parts := make([]string, len(doc.ViewBox))
+		for i, v := range doc.ViewBox {
+			parts[i] = formatFloat(v)
+		}
+		meta["viewBox"] = strings.Join(parts, " ")
+	}
+	return meta
+}
+
+// attachSources записывает метаданные исходных элементов в Misc объектов слоя.
+// sources — элементы в исходных координатах (до калибровки), по id.
+func attachSources(layer *models.Layer, sources map[string]models.SVGElement) {
+	parts := make(map[string]int)
+	for _, line := range layer.Lines {
+		parts[line.Name]++
+	}
+
+	for id, line := range layer.Lines {
+		elem, ok := sources[line.Name]
+		if !ok {
+			continue
+		}
+		meta := elementSource(elem)
+		meta["parts"] = parts[line.Name]
+		meta["fingerprint"] = lineFingerprint(line, layer.Vertices)
+		line.Misc = withMisc(line.Misc, SourceMiscKey, meta)
+		layer.Lines[id] = line
+	}
+
+	for id, hole := range layer.Holes {
+		elem, ok := sources[id]
+		if !ok {
+			continue
+		}
+		meta := elementSource(elem)
+		meta["fingerprint"] = holeFingerprint(hole, layer)
+		hole.Misc = withMisc(hole.Misc, SourceMiscKey, meta)
+		layer.Holes[id] = hole
+	}
+
+	for id, area := range layer.Areas {
+		elem, ok := sources[id]
+		if !ok {
+			continue
+		}
+		meta := elementSource(elem)
+		meta["fingerprint"] = areaFingerprint(area, layer.Vertices)
+		area.Misc = withMisc(area.Misc, SourceMiscKey, meta)
+		layer.Areas[id] = area
+	}
+}
+
+// textsMeta — подписи исходного документа (в его координатах), /render выводит их обратно.
+func textsMeta(texts []models.TextLabel) []any {
+	out := make([]any, 0, len(texts))
+	for _, text := range texts {
+		meta := map[string]any{"text": text.Text, "x": text.Position.X, "y": text.Position.Y}
+		if text.ID != "" {
+			meta["id"] = text.ID
+		}
+		out = append(out, meta)
+	}
+	return out
+}
+
+// unmappedSources — исходные элементы, не попавшие ни в один объект сцены (например, стены
+// короче допуска склейки вершин). Сохраняются в meta.source, чтобы /render их не потерял.
+func unmappedSources(layer models.Layer, elements []models.SVGElement) []any {
+	used := make(map[string]bool)
+	for _, line := range layer.Lines {
+		used[line.Name] = true
+	}
+	for id := range layer.Holes {
+		used[id] = true
+	}
+	for id := range layer.Areas {
+		used[id] = true
+	}
+	for _, item := range layer.Items {
+		if src, ok := sourceFromMisc(item.Misc); ok {
+			for _, elem := range src.Elements {
+				used[elem.ID] = true
+			}
+		}
+	}
+
+	var out []any
+	for _, elem := range elements {
+		if !used[elem.ID] {
+			out = append(out, elementSource(elem))
+		}
+	}
+	return out
+}
+
+// sourceStroke — цвет обводки элемента по типу, как у восстановленных элементов /render.
+func sourceStroke(elemType string) string {
+	switch elemType {
+	case "door":
+		return "#d62728"
+	case "window":
+		return "#1f77b4"
+	case "room":
+		return "#888"
+	case "balcony":
+		return "#2ca02c"
+	case ItemRuleType:
+		return "#9467bd"
+	case TypeColumn:
+		return "#444"
+	case TypeShaft:
+		return "#8c564b"
+	case TypeStair:
+		return "#17becf"
+	}
+	return "#000"
+}
+
+// attachItemSources записывает исходные элементы item, собранного из нескольких элементов.
+func attachItemSources(item *models.Item, elems []models.SVGElement) {
+	list := make([]any, 0, len(elems))
+	for _, elem := range elems {
+		list = append(list, elementSource(elem))
+	}
+	item.Misc = withMisc(item.Misc, SourceMiscKey, map[string]any{
+		"elements":    list,
+		"fingerprint": itemFingerprint(*item),
+	})
+}
+
+// withMisc возвращает копию misc с добавленным ключом (misc линий одной стены общий).
+func withMisc(misc map[string]any, key string, value any) map[string]any {
+	out := make(map[string]any, len(misc)+1)
+	for k, v := range misc {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+// ============================================================
+// Fingerprints
+// ============================================================
+
+func lineFingerprint(line models.Line, vertices map[string]models.Vertex) string {
+	var b strings.Builder
+	for _, id := range line.Vertices {
+		v := vertices[id]
+		fmt.Fprintf(&b, "%.3f,%.3f;", v.X, v.Y)
+	}
+	fmt.Fprintf(&b, "t=%.3f", models.LengthProperty(line.Properties, "thickness", 0))
+	return b.String()
+}
+
+func holeFingerprint(hole models.Hole, layer *models.Layer) string {
+	line := layer.Lines[hole.Line]
+	return fmt.Sprintf("%s@%.4f;w=%.3f;t=%.3f|%s", hole.Line, hole.Offset,
+		models.LengthProperty(hole.Properties, "width", 0),
+		models.LengthProperty(hole.Properties, "thickness", 0),
+		lineFingerprint(line, layer.Vertices))
+}
+
+func areaFingerprint(area models.Area, vertices map[string]models.Vertex) string {
+	var b strings.Builder
+	for _, id := range area.Vertices {
+		v := vertices[id]
+		fmt.Fprintf(&b, "%.3f,%.3f;", v.X, v.Y)
+	}
+	return b.String()
+}
+
+func itemFingerprint(item models.Item) string {
+	return fmt.Sprintf("%.3f,%.3f;r=%.3f;w=%.3f;d=%.3f", item.X, item.Y, item.Rotation,
+		models.LengthProperty(item.Properties, "width", 0),
+		models.LengthProperty(item.Properties, "depth", 0))
+}
+
+// ============================================================
+// Reading source metadata
+// ============================================================
+
+func sourceFromMisc(misc map[string]any) (sourceInfo, bool) {
+	raw, ok := misc[SourceMiscKey].(map[string]any)
+	if !ok {
+		return sourceInfo{}, false
+	}
+	return parseSourceInfo(raw)
+}
+
+func parseSourceInfo(raw map[string]any) (sourceInfo, bool) {
+	info := sourceInfo{}
+	info.ID, _ = raw["id"].(string)
+	info.Type, _ = raw["type"].(string)
+	info.Tag, _ = raw["tag"].(string)
+	info.Class, _ = raw["class"].(string)
+	info.Geometry, _ = raw["geometry"].(map[string]any)
+	info.Fingerprint, _ = raw["fingerprint"].(string)
+	info.Detected, _ = raw["detected"].(bool)
+	switch parts := raw["parts"].(type) {
+	case int:
+		info.Parts = parts
+	case float64:
+		info.Parts = int(parts)
+	}
+	if list, ok := raw["elements"].([]any); ok {
+		for _, item := range list {
+			if m, ok := item.(map[string]any); ok {
+				if elem, ok := parseSourceInfo(m); ok {
+					info.Elements = append(info.Elements, elem)
+				}
+			}
+		}
+	}
+	return info, info.ID != "" || len(info.Elements) > 0 || info.Detected
+}
+
+// sourceSVG выводит исходный элемент в его исходной геометрии.
+func sourceSVG(info sourceInfo, paint string) (string, bool) {
+	attrs := fmt.Sprintf(`id="%s"`, escapeAttr(info.ID))
+	if info.Class != "" {
+		attrs += fmt.Sprintf(` class="%s"`, escapeAttr(info.Class))
+	}
+	style := paint
+
+	if rect, ok := floatList(info.Geometry["rect"]); ok && len(rect) == 4 {
+		return fmt.Sprintf(`<rect %s x="%s" y="%s" width="%s" height="%s" %s />`,
+			attrs, formatFloat(rect[0]), formatFloat(rect[1]), formatFloat(rect[2]), formatFloat(rect[3]), style), true
+	}
+	if d, ok := info.Geometry["d"].(string); ok {
+		return fmt.Sprintf(`<path %s d="%s" %s />`, attrs, escapeAttr(d), style), true
+	}
+	if coords, ok := floatList(info.Geometry["points"]); ok && len(coords) >= 4 {
+		tag := "polyline"
+		if closed, _ := info.Geometry["closed"].(bool); closed {
+			tag = "polygon"
+		}
+		if info.Tag == "line" && len(coords) == 4 {
+			return fmt.Sprintf(`<line %s x1="%s" y1="%s" x2="%s" y2="%s" %s />`, attrs,
+				formatFloat(coords[0]), formatFloat(coords[1]), formatFloat(coords[2]), formatFloat(coords[3]), style), true
+		}
+		points := make([]string, 0, len(coords)/2)
+		for i := 0; i+1 < len(coords); i += 2 {
+			points = append(points, formatFloat(coords[i])+","+formatFloat(coords[i+1]))
+		}
+		return fmt.Sprintf(`<%s %s points="%s" %s />`, tag, attrs, strings.Join(points, " "), style), true
+	}
+	return "", false
+}
+
+// floatList читает []float64 или []any из JSON.
+func floatList(v any) ([]float64, bool) {
+	switch list := v.(type) {
+	case []float64:
+		return list, true
+	case []any:
+		out := make([]float64, 0, len(list))
+		for _, item := range list {
+			f, ok := item.(float64)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, f)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func escapeAttr(s string) string {
+	return strings.NewReplacer(`&`, "&amp;", `"`, "&quot;", `<`, "&lt;", `>`, "&gt;").Replace(s)
+}
@@ -0,0 +1,198 @@
+package mapper
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"api-gateway/internal/converter/graph"
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Structural elements
+// ============================================================
+
+// Колонны, шахты и лестницы — items, которые нельзя двигать при перепланировке
+// (properties.structural). В граф стен они не попадают.
+const (
+	TypeColumn = "column"
+	TypeShaft  = "shaft"
+	TypeStair  = "stair"
+)
+
+// Направление лестничного марша от этажа плана.
+const (
+	StairUp   = "up"
+	StairDown = "down"
+)
+
+const stairTread = 30.0 // Глубина ступени по умолчанию, см
+
+var stairDownRe = regexp.MustCompile(`(?i)(^|[_\s-])(down|вниз)($|[_\s-])`)
+
+// createStructuralItems создает items колонн, шахт и лестниц по прямоугольнику минимальной
+// площади вокруг элемента. Колонны и шахты, стоящие в линии стены, возвращаются как
+// проемы для поиска комнат: разрыв стены по обе стороны от них замыкается.
+func (c *Converter) createStructuralItems(elems []models.SVGElement, target map[string]models.Item) []graph.Opening {
+	var openings []graph.Opening
+	for _, elem := range elems {
+		points, err := c.getElementPoints(elem)
+		if err != nil || len(points) == 0 {
+			continue
+		}
+		center, angle, length, width, ok := graph.Footprint(points)
+		if !ok {
+			continue
+		}
+
+		item := models.Item{
+			ID:        elem.ID,
+			Type:      elem.Type,
+			Prototype: "items",
+			X:         center.X,
+			Y:         center.Y,
+			Visible:   true,
+		}
+		switch elem.Type {
+		case TypeStair:
+			// локальная +y — вдоль марша: depth — длина марша, width — ширина
+			item.Name = "Stair"
+			item.Rotation = normalizeAngle(angle - 90)
+			item.Properties = c.structuralProperties(width, length)
+			item.Properties["direction"] = stairDirection(elem)
+			item.Properties["steps"] = stairSteps(elem, length)
+		default:
+			item.Name = strings.ToUpper(elem.Type[:1]) + elem.Type[1:]
+			item.Rotation = normalizeAngle(angle)
+			item.Properties = c.structuralProperties(length, width)
+			if opening, ok := c.wallObstacle(elem.ID, center, length, width); ok {
+				openings = append(openings, opening)
+			}
+		}
+		if src, ok := c.sources[elem.ID]; ok {
+			attachItemSources(&item, []models.SVGElement{src})
+		}
+		target[elem.ID] = item
+	}
+	return openings
+}
+
+// wallObstacle — колонна или шахта в линии стены: ее протяженность вдоль ближайшей стены.
+func (c *Converter) wallObstacle(id string, center models.Point, length, width float64) (graph.Opening, bool) {
+	lineID, wallAngle := c.findNearestWallAngle(center)
+	if lineID == "" {
+		return graph.Opening{}, false
+	}
+	line := c.builder.GetLines()[lineID]
+	vertices := c.builder.GetVertices()
+	dist, _ := pointToLineDistance(center, vertices[line.Vertices[0]], vertices[line.Vertices[1]])
+	thickness := models.LengthProperty(line.Properties, "thickness", 0)
+	if dist > (width+thickness)/2 {
+		return graph.Opening{}, false
+	}
+	return graph.Opening{ID: id, Center: center, Angle: wallAngle, Width: math.Max(length, width)}, true
+}
+
+func (c *Converter) structuralProperties(width, depth float64) map[string]any {
+	height := c.floors.Height
+	if height <= 0 {
+		height = DefaultFloorHeight
+	}
+	return map[string]any{
+		"width":      map[string]any{"length": width},
+		"depth":      map[string]any{"length": depth},
+		"height":     map[string]any{"length": height},
+		"altitude":   map[string]any{"length": 0.0},
+		"structural": true,
+	}
+}
+
+// stairDirection — up или down: data-direction, иначе суффикс id (Stair_01_down, Лестница вниз).
+func stairDirection(elem models.SVGElement) string {
+	switch strings.ToLower(elem.Data["direction"]) {
+	case StairUp, "вверх":
+		return StairUp
+	case StairDown, "вниз":
+		return StairDown
+	}
+	if stairDownRe.MatchString(elem.ID) {
+		return StairDown
+	}
+	return StairUp
+}
+
+// stairSteps — число ступеней: data-steps, иначе длина марша / глубина ступени.
+func stairSteps(elem models.SVGElement, length float64) int {
+	var steps int
+	if _, err := fmt.Sscanf(elem.Data["steps"], "%d", &steps); err == nil && steps > 0 {
+		return steps
+	}
+	return int(math.Max(1, math.Round(length/stairTread)))
+}
+
+// ============================================================
+// Structural rendering
+// ============================================================
+
+// structuralSVG рисует колонну (залитый прямоугольник), шахту (прямоугольник с диагоналями)
+// или лестницу (ступени и стрелка подъема).
+func structuralSVG(item models.Item, theme *Theme, frame renderFrame) []string {
+	width := models.LengthProperty(item.Properties, "width", 100)
+	depth := models.LengthProperty(item.Properties, "depth", 100)
+	corners := rectanglePoints(item.X, item.Y, width, depth, item.Rotation)
+	outline := make([]models.Point, len(corners))
+	for i, p := range corners {
+		outline[i] = frame.point(p.X, p.Y)
+	}
+	paint, line := theme.paint(item.Type), theme.line(item.Type)
+
+	switch item.Type {
+	case TypeColumn:
+		return []string{polygonPath(item.ID, outline, paint)}
+
+	case TypeShaft:
+		return []string{
+			polygonPath(item.ID, outline, paint),
+			fmt.Sprintf(`<path d="M %s L %s M %s L %s" %s />`,
+				formatPoint(outline[0]), formatPoint(outline[2]), formatPoint(outline[1]), formatPoint(outline[3]), line),
+		}
+
+	case TypeStair:
+		out := []string{polygonPath(item.ID, outline, paint)}
+
+		// ступени поперек марша: локальная +y — вдоль марша
+		steps := 1
+		if n, ok := item.Properties["steps"].(float64); ok && n >= 1 {
+			steps = int(n)
+		} else if n, ok := item.Properties["steps"].(int); ok && n >= 1 {
+			steps = n
+		}
+		local := func(x, y float64) models.Point {
+			rad := item.Rotation * math.Pi / 180
+			return frame.point(item.X+x*math.Cos(rad)-y*math.Sin(rad), item.Y+x*math.Sin(rad)+y*math.Cos(rad))
+		}
+		var d strings.Builder
+		for i := 1; i < steps; i++ {
+			y := -depth/2 + depth*float64(i)/float64(steps)
+			fmt.Fprintf(&d, "M %s L %s ", formatPoint(local(-width/2, y)), formatPoint(local(width/2, y)))
+		}
+		if d.Len() > 0 {
+			out = append(out, fmt.Sprintf(`<path d="%s" %s />`, strings.TrimSpace(d.String()), line))
+		}
+
+		// стрелка подъема: от нижней ступени к верхней
+		sign := 1.0
+		if direction, _ := item.Properties["direction"].(string); direction == StairDown {
+			sign = -1
+		}
+		from, to := local(0, -sign*depth*0.4), local(0, sign*depth*0.4)
+		head := math.Min(width, depth) * 0.15
+		left, right := local(-head, sign*(depth*0.4-head)), local(head, sign*(depth*0.4-head))
+		out = append(out, fmt.Sprintf(`<path d="M %s L %s M %s L %s L %s" %s />`,
+			formatPoint(from), formatPoint(to), formatPoint(left), formatPoint(to), formatPoint(right), line))
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package mapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ============================================================
+// Render themes
+// ============================================================
+
+// ThemeDebug — тема по умолчанию: контуры без заливки, цвет по типу элемента.
+const ThemeDebug = "debug"
+
+// Виды штриховки (Style.Hatch).
+const (
+	HatchDiagonal = "diagonal"
+	HatchCross    = "cross"
+)
+
+const defaultHatchSpacing = 8.0
+
+// Style — заливка и обводка элементов одного вида. Пустые fill и stroke — "none".
+type Style struct {
+	Fill        string  `json:"fill,omitempty"`         // цвет заливки (фон штриховки)
+	Stroke      string  `json:"stroke,omitempty"`       // цвет обводки (и линий штриховки)
+	StrokeWidth float64 `json:"stroke_width,omitempty"` // толщина линии в единицах вывода, 0 — по умолчанию SVG
+	Dash        string  `json:"dash,omitempty"`         // stroke-dasharray
+	Hatch       string  `json:"hatch,omitempty"`        // diagonal или cross — штриховка вместо заливки
+}
+
+// RoomFill — цвет заливки комнат, имя или id которых подходит под regex.
+type RoomFill struct {
+	Rooms string `json:"rooms"`
+	Fill  string `json:"fill"`
+
+	re *regexp.Regexp
+}
+
+// Theme — оформление /render. Styles по виду элемента: wall, room, door, window, balcony,
+// item, column, shaft, stair; default — для остальных (неразмеченные исходные элементы).
+type Theme struct {
+	Name         string           `json:"name"`
+	Background   string           `json:"background,omitempty"`    // цвет листа, пусто — прозрачный
+	Font         string           `json:"font,omitempty"`          // font-family подписей
+	FontSize     float64          `json:"font_size,omitempty"`     // размер подписей, единицы вывода
+	Text         string           `json:"text,omitempty"`          // цвет подписей
+	HatchSpacing float64          `json:"hatch_spacing,omitempty"` // шаг штриховки, единицы вывода
+	Styles       map[string]Style `json:"styles"`
+	Rooms        []RoomFill       `json:"rooms,omitempty"` // заливка комнат по виду, первая подходящая
+}
+
+// Themes — набор тем и тема по умолчанию.
+type Themes struct {
+	Default string   `json:"default"`
+	Themes  []*Theme `json:"themes"`
+}
+
+// DefaultThemes — debug (прежний вывод /render), blueprint, print и marketing.
+func DefaultThemes() *Themes {
+	ts := &Themes{Default: ThemeDebug, Themes: []*Theme{
+		debugTheme(),
+		{
+			Name:       "blueprint",
+			Background: "#1f3a68",
+			Font:       "monospace",
+			Text:       "#fff",
+			Styles: map[string]Style{
+				"wall":    {Stroke: "#fff", StrokeWidth: 2, Hatch: HatchDiagonal},
+				"room":    {Stroke: "#8fb0dd", Dash: "4 4"},
+				"door":    {Fill: "#1f3a68", Stroke: "#fff"},
+				"window":  {Fill: "#1f3a68", Stroke: "#cfe3ff"},
+				"balcony": {Stroke: "#cfe3ff", Dash: "2 2"},
+				"item":    {Stroke: "#cfe3ff"},
+				"column":  {Fill: "#fff", Stroke: "#fff"},
+				"shaft":   {Stroke: "#fff"},
+				"stair":   {Stroke: "#fff"},
+				"default": {Stroke: "#fff"},
+			},
+		},
+		{
+			Name:       "print",
+			Background: "#fff",
+			Font:       "sans-serif",
+			Text:       "#000",
+			Styles: map[string]Style{
+				"wall":    {Fill: "#fff", Stroke: "#000", StrokeWidth: 2, Hatch: HatchDiagonal},
+				"room":    {},
+				"door":    {Fill: "#fff", Stroke: "#000"},
+				"window":  {Fill: "#fff", Stroke: "#000"},
+				"balcony": {Stroke: "#000", Dash: "4 2"},
+				"item":    {Stroke: "#555"},
+				"column":  {Fill: "#000", Stroke: "#000"},
+				"shaft":   {Stroke: "#000"},
+				"stair":   {Stroke: "#000"},
+				"default": {Stroke: "#000"},
+			},
+		},
+		{
+			Name:       "marketing",
+			Background: "#fff",
+			Font:       "Helvetica, Arial, sans-serif",
+			Text:       "#333",
+			Styles: map[string]Style{
+				"wall":    {Fill: "#3a3a3a", Stroke: "#3a3a3a"},
+				"room":    {Fill: "#f4f1ea"},
+				"door":    {Fill: "#fff", Stroke: "#8a5a2b", StrokeWidth: 1.5},
+				"window":  {Fill: "#dff1ff", Stroke: "#3b8ed8", StrokeWidth: 1.5},
+				"balcony": {Fill: "#e6f2e1", Stroke: "#7fb36a"},
+				"item":    {Fill: "#fff", Stroke: "#9a9a9a"},
+				"column":  {Fill: "#3a3a3a", Stroke: "#3a3a3a"},
+				"shaft":   {Fill: "#ddd", Stroke: "#666"},
+				"stair":   {Fill: "#fff", Stroke: "#666"},
+				"default": {Stroke: "#999"},
+			},
+			Rooms: []RoomFill{
+				{Rooms: `(?i)kitchen|кухн`, Fill: "#fde3c8"},
+				{Rooms: `(?i)bath|toilet|wc|ванн|туалет|санузел|с/у`, Fill: "#d6ecf7"},
+				{Rooms: `(?i)hall|corridor|холл|прихож|коридор`, Fill: "#eeeae2"},
+				{Rooms: `(?i)balcon|loggia|балкон|лодж`, Fill: "#e6f2e1"},
+				{Rooms: `(?i)bed|спальн`, Fill: "#e8e2f3"},
+				{Rooms: `(?i)living|гостин|жил`, Fill: "#f8eecb"},
+			},
+		},
+	}}
+	if err := ts.compile(); err != nil {
+		panic(err)
+	}
+	return ts
+}
+
+// debugTheme — контуры без заливки цветом sourceStroke, колонны залиты.
+func debugTheme() *Theme {
+	styles := map[string]Style{"wall": {Stroke: "#000"}, "default": {Stroke: "#000"}}
+	for _, kind := range []string{"room", "door", "window", "balcony", ItemRuleType, TypeShaft, TypeStair} {
+		styles[kind] = Style{Stroke: sourceStroke(kind)}
+	}
+	styles[TypeColumn] = Style{Fill: sourceStroke(TypeColumn), Stroke: sourceStroke(TypeColumn)}
+	return &Theme{Name: ThemeDebug, Styles: styles}
+}
+
+// LoadThemes читает темы из JSON файла.
+func LoadThemes(path string) (*Themes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseThemes(data)
+}
+
+// ParseThemes разбирает JSON вида {"default": "print", "themes": [...]} или просто массив тем.
+func ParseThemes(data []byte) (*Themes, error) {
+	var ts Themes
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &ts.Themes); err != nil {
+			return nil, fmt.Errorf("decode themes: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &ts); err != nil {
+		return nil, fmt.Errorf("decode themes: %w", err)
+	}
+
+	if len(ts.Themes) == 0 {
+		return nil, fmt.Errorf("theme set is empty")
+	}
+	if err := ts.compile(); err != nil {
+		return nil, err
+	}
+	return &ts, nil
+}
+
+// Get возвращает тему по имени; пустое имя — тема по умолчанию.
+func (ts *Themes) Get(name string) (*Theme, bool) {
+	if name == "" {
+		name = ts.Default
+	}
+	for _, t := range ts.Themes {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (ts *Themes) compile() error {
+	seen := make(map[string]bool)
+	for _, t := range ts.Themes {
+		if t == nil || t.Name == "" {
+			return fmt.Errorf("theme name required")
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate theme %s", t.Name)
+		}
+		seen[t.Name] = true
+		if err := t.compile(); err != nil {
+			return fmt.Errorf("theme %s: %w", t.Name, err)
+		}
+	}
+	if ts.Default == "" {
+		ts.Default = ts.Themes[0].Name
+	} else if !seen[ts.Default] {
+		return fmt.Errorf("default theme %s not found", ts.Default)
+	}
+	return nil
+}
+
+func (t *Theme) compile() error {
+	if t.FontSize < 0 || t.HatchSpacing < 0 {
+		return fmt.Errorf("font_size and hatch_spacing must be non-negative")
+	}
+	if t.Styles == nil {
+		t.Styles = make(map[string]Style)
+	}
+	for kind, style := range t.Styles {
+		if style.StrokeWidth < 0 {
+			return fmt.Errorf("style %s: stroke_width must be non-negative", kind)
+		}
+		switch style.Hatch {
+		case "", HatchDiagonal, HatchCross:
+		default:
+			return fmt.Errorf("style %s: hatch must be %s or %s", kind, HatchDiagonal, HatchCross)
+		}
+	}
+	for i := range t.Rooms {
+		room := &t.Rooms[i]
+		if room.Rooms == "" || room.Fill == "" {
+			return fmt.Errorf("room fill %d: rooms and fill required", i)
+		}
+		re, err := regexp.Compile(room.Rooms)
+		if err != nil {
+			return fmt.Errorf("room fill %d: rooms: %w", i, err)
+		}
+		room.re = re
+	}
+	return nil
+}
+
+// ============================================================
+// Paint attributes
+// ============================================================
+
+// style — стиль вида kind, иначе default, иначе черный контур.
+func (t *Theme) style(kind string) Style {
+	if style, ok := t.Styles[kind]; ok {
+		return style
+	}
+	if style, ok := t.Styles["default"]; ok {
+		return style
+	}
+	return Style{Stroke: "#000"}
+}
+
+// paint — атрибуты fill и stroke контура элемента вида kind.
+func (t *Theme) paint(kind string) string {
+	style := t.style(kind)
+	fill := style.Fill
+	if style.Hatch != "" {
+		fill = "url(#" + hatchID(kind) + ")"
+	}
+	return style.attrs(fill)
+}
+
+// fill — атрибуты заливки без обводки (обводка выводится отдельно).
+func (t *Theme) fill(kind string) string {
+	style := t.style(kind)
+	fill := style.Fill
+	if style.Hatch != "" {
+		fill = "url(#" + hatchID(kind) + ")"
+	}
+	return Style{}.attrs(fill)
+}
+
+// line — атрибуты незамкнутой линии (дуги двери, ступеней): без заливки.
+func (t *Theme) line(kind string) string {
+	return t.style(kind).attrs("")
+}
+
+// roomPaint — атрибуты комнаты: заливка по первому подходящему RoomFill, иначе стиль room.
+func (t *Theme) roomPaint(names ...string) string {
+	for _, room := range t.Rooms {
+		for _, name := range names {
+			if name != "" && room.re.MatchString(name) {
+				return t.style("room").attrs(room.Fill)
+			}
+		}
+	}
+	return t.paint("room")
+}
+
+// textAttrs — атрибуты шрифта подписей; fontSize используется, если в теме размер не задан.
+func (t *Theme) textAttrs(font string, fontSize float64) string {
+	if t.Font != "" {
+		font = t.Font
+	}
+	if t.FontSize > 0 {
+		fontSize = t.FontSize
+	}
+	var attrs []string
+	if fontSize > 0 {
+		attrs = append(attrs, fmt.Sprintf(`font-size="%s"`, formatFloat(fontSize)))
+	}
+	if font != "" {
+		attrs = append(attrs, fmt.Sprintf(`font-family="%s"`, escapeAttr(font)))
+	}
+	if t.Text != "" {
+		attrs = append(attrs, fmt.Sprintf(`fill="%s"`, escapeAttr(t.Text)))
+	}
+	return strings.Join(attrs, " ")
+}
+
+func (s Style) attrs(fill string) string {
+	stroke := s.Stroke
+	if fill == "" {
+		fill = "none"
+	}
+	if stroke == "" {
+		stroke = "none"
+	}
+	attrs := fmt.Sprintf(`fill="%s" stroke="%s"`, escapeAttr(fill), escapeAttr(stroke))
+	if s.StrokeWidth > 0 {
+		attrs += fmt.Sprintf(` stroke-width="%s"`, formatFloat(s.StrokeWidth))
+	}
+	if s.Dash != "" {
+		attrs += fmt.Sprintf(` stroke-dasharray="%s"`, escapeAttr(s.Dash))
+	}
+	return attrs
+}
+
+func hatchID(kind string) string {
+	return "hatch-" + kind
+}
+
+// defs — <defs> с узорами штриховки стилей темы; пусто, если штриховки нет.
+func (t *Theme) defs() string {
+	spacing := t.HatchSpacing
+	if spacing <= 0 {
+		spacing = defaultHatchSpacing
+	}
+	s := formatFloat(spacing)
+
+	kinds := make([]string, 0, len(t.Styles))
+	for kind, style := range t.Styles {
+		if style.Hatch != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) == 0 {
+		return ""
+	}
+	sort.Strings(kinds)
+
+	var b strings.Builder
+	b.WriteString("<defs>")
+	for _, kind := range kinds {
+		style := t.Styles[kind]
+		d := "M 0 0 L 0 " + s
+		if style.Hatch == HatchCross {
+			d += " M 0 0 L " + s + " 0"
+		}
+		width := style.StrokeWidth / 2
+		if width <= 0 {
+			width = 1
+		}
+		fmt.Fprintf(&b, `<pattern id="%s" patternUnits="userSpaceOnUse" width="%s" height="%s" patternTransform="rotate(45)">`,
+			hatchID(kind), s, s)
+		if style.Fill != "" {
+			fmt.Fprintf(&b, `<rect width="%s" height="%s" fill="%s" />`, s, s, escapeAttr(style.Fill))
+		}
+		fmt.Fprintf(&b, `<path d="%s" stroke="%s" stroke-width="%s" />`, d, escapeAttr(strokeOr(style.Stroke, "#000")), formatFloat(width))
+		b.WriteString("</pattern>")
+	}
+	b.WriteString("</defs>")
+	return b.String()
+}
+
+func strokeOr(stroke, def string) string {
+	if stroke == "" {
+		return def
+	}
+	return stroke
+}
+
+// background — прямоугольник фона листа по viewBox (minX, minY, width, height).
+func (t *Theme) background(box [4]float64) string {
+	if t.Background == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="%s" />`,
+		formatFloat(box[0]), formatFloat(box[1]), formatFloat(box[2]), formatFloat(box[3]), escapeAttr(t.Background))
+}
@@ -0,0 +1,389 @@
+package mapper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Wall outlines
+// ============================================================
+
+const (
+	defaultWallThickness = 10.0 // толщина линии без properties.thickness
+	wallMiterLimit       = 4.0  // угол острее — вместо острия скоса грань обрезается по перпендикуляру
+)
+
+// wallEnd — конец линии в узле: направление от узла вдоль линии и полутолщина.
+type wallEnd struct {
+	line  string
+	away  models.Point
+	half  float64
+	angle float64
+}
+
+// wallJoints — углы граней стен в узлах: left/right — точка пересечения левой/правой
+// (относительно направления от узла) грани линии с гранью соседней по кругу линии.
+type wallJoints struct {
+	left, right map[string]models.Point // ключ — jointKey(узел, линия)
+	degree      map[string]int
+}
+
+func jointKey(vertexID, lineID string) string {
+	return vertexID + "\x00" + lineID
+}
+
+func wallHalf(line models.Line) float64 {
+	t := models.LengthProperty(line.Properties, "thickness", defaultWallThickness)
+	if t <= 0 {
+		t = defaultWallThickness
+	}
+	return t / 2
+}
+
+// newWallJoints считает углы всех узлов слоя: концы линий в узле сортируются по углу,
+// левая грань каждой линии пересекается с правой гранью следующей против часовой стрелки.
+// Так стыкуются углы (скос), T- и X-образные примыкания.
+func newWallJoints(layer models.Layer) wallJoints {
+	ends := make(map[string][]wallEnd)
+	for _, id := range models.SortedKeys(layer.Lines) {
+		line := layer.Lines[id]
+		if len(line.Vertices) < 2 {
+			continue
+		}
+		v1, ok1 := layer.Vertices[line.Vertices[0]]
+		v2, ok2 := layer.Vertices[line.Vertices[1]]
+		length := math.Hypot(v2.X-v1.X, v2.Y-v1.Y)
+		if !ok1 || !ok2 || length == 0 {
+			continue
+		}
+		ux, uy := (v2.X-v1.X)/length, (v2.Y-v1.Y)/length
+		half := wallHalf(line)
+		ends[line.Vertices[0]] = append(ends[line.Vertices[0]], wallEnd{line: id, away: models.Point{X: ux, Y: uy}, half: half, angle: math.Atan2(uy, ux)})
+		ends[line.Vertices[1]] = append(ends[line.Vertices[1]], wallEnd{line: id, away: models.Point{X: -ux, Y: -uy}, half: half, angle: math.Atan2(-uy, -ux)})
+	}
+
+	joints := wallJoints{left: make(map[string]models.Point), right: make(map[string]models.Point), degree: make(map[string]int)}
+	for vid, list := range ends {
+		v := layer.Vertices[vid]
+		p := models.Point{X: v.X, Y: v.Y}
+		joints.degree[vid] = len(list)
+		sort.SliceStable(list, func(i, j int) bool { return list[i].angle < list[j].angle })
+
+		for _, e := range list {
+			joints.left[jointKey(vid, e.line)] = offsetPoint(p, e.away, e.half)
+			joints.right[jointKey(vid, e.line)] = offsetPoint(p, e.away, -e.half)
+		}
+		if len(list) < 2 {
+			continue
+		}
+		for i, e := range list {
+			next := list[(i+1)%len(list)]
+			if next.line == e.line {
+				continue
+			}
+			if corner, ok := miterCorner(p, e, next); ok {
+				joints.left[jointKey(vid, e.line)] = corner
+				joints.right[jointKey(vid, next.line)] = corner
+			}
+		}
+	}
+	return joints
+}
+
+// offsetPoint — точка грани на расстоянии half влево (отрицательное — вправо) от направления dir.
+func offsetPoint(p, dir models.Point, half float64) models.Point {
+	return models.Point{X: p.X - dir.Y*half, Y: p.Y + dir.X*half}
+}
+
+// miterCorner — пересечение левой грани a и правой грани b в узле p. ok=false для параллельных
+// граней (продолжение стены) и слишком острых углов: тогда у каждой линии остается грань,
+// обрезанная по перпендикуляру.
+func miterCorner(p models.Point, a, b wallEnd) (models.Point, bool) {
+	pa := offsetPoint(p, a.away, a.half)
+	pb := offsetPoint(p, b.away, -b.half)
+	denom := a.away.X*b.away.Y - a.away.Y*b.away.X
+	if math.Abs(denom) < 1e-6 {
+		return models.Point{}, false
+	}
+	t := ((pb.X-pa.X)*b.away.Y - (pb.Y-pa.Y)*b.away.X) / denom
+	corner := models.Point{X: pa.X + a.away.X*t, Y: pa.Y + a.away.Y*t}
+	if math.Hypot(corner.X-p.X, corner.Y-p.Y) > wallMiterLimit*math.Max(a.half, b.half) {
+		return models.Point{}, false
+	}
+	return corner, true
+}
+
+// ============================================================
+// Wall runs
+// ============================================================
+
+// wallPoint — вершина контура стены; center — узел оси: ребра к нему лежат внутри стыка
+// стен и не обводятся.
+type wallPoint struct {
+	p      models.Point
+	center bool
+}
+
+// wallRun — цепочка линий одной стены от узла к узлу.
+type wallRun struct {
+	lines    []models.Line
+	vertices []string // len(lines)+1 узлов по порядку обхода
+}
+
+// chainLines упорядочивает линии стены в цепочку; ok=false — линии не образуют простую цепочку.
+func chainLines(lines []models.Line) (wallRun, bool) {
+	if len(lines) == 1 {
+		return wallRun{lines: lines, vertices: []string{lines[0].Vertices[0], lines[0].Vertices[1]}}, true
+	}
+	byVertex := make(map[string][]int)
+	for i, line := range lines {
+		byVertex[line.Vertices[0]] = append(byVertex[line.Vertices[0]], i)
+		byVertex[line.Vertices[1]] = append(byVertex[line.Vertices[1]], i)
+	}
+	start := ""
+	for _, vid := range models.SortedKeys(byVertex) {
+		switch len(byVertex[vid]) {
+		case 1:
+			if start == "" {
+				start = vid
+			}
+		case 2:
+		default:
+			return wallRun{}, false
+		}
+	}
+	if start == "" {
+		return wallRun{}, false // замкнутое кольцо
+	}
+
+	run := wallRun{vertices: []string{start}}
+	used := make(map[int]bool)
+	for vid := start; ; {
+		next := -1
+		for _, i := range byVertex[vid] {
+			if !used[i] {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		used[next] = true
+		line := lines[next]
+		vid = line.Vertices[1]
+		if vid == run.vertices[len(run.vertices)-1] {
+			vid = line.Vertices[0]
+		}
+		run.lines = append(run.lines, line)
+		run.vertices = append(run.vertices, vid)
+	}
+	return run, len(run.lines) == len(lines)
+}
+
+// wallPolygons строит контур стены: грани со скосами в узлах, стыки с другими стенами —
+// клиньями до узла оси, проемы вырезаются по перпендикуляру к оси. Возвращает части стены
+// между проемами.
+func wallPolygons(run wallRun, layer models.Layer, joints wallJoints) [][]wallPoint {
+	var pieces [][]wallPoint
+	var plus, minus []wallPoint // грань слева и справа от направления обхода
+	var startCap []wallPoint    // замыкание начала части: пусто — торец или откос проема
+
+	// начало цепочки: свободный торец или клин до узла
+	first := run.vertices[0]
+	plus = append(plus, wallPoint{p: joints.left[jointKey(first, run.lines[0].ID)]})
+	minus = append(minus, wallPoint{p: joints.right[jointKey(first, run.lines[0].ID)]})
+	if joints.degree[first] > 1 {
+		startCap = []wallPoint{vertexPoint(layer, first)}
+	}
+
+	for i, line := range run.lines {
+		from, to := run.vertices[i], run.vertices[i+1]
+		a, b := vertexPoint(layer, from).p, vertexPoint(layer, to).p
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		dir := models.Point{X: 1}
+		if length > 0 {
+			dir = models.Point{X: (b.X - a.X) / length, Y: (b.Y - a.Y) / length}
+		}
+		half := wallHalf(line)
+		at := func(s float64, side float64) wallPoint {
+			return wallPoint{p: offsetPoint(models.Point{X: a.X + dir.X*s, Y: a.Y + dir.Y*s}, dir, side*half)}
+		}
+
+		for _, span := range holeSpans(line, layer, length, line.Vertices[0] != from) {
+			plus = append(plus, at(span[0], 1))
+			minus = append(minus, at(span[0], -1))
+			pieces = appendPiece(pieces, closeWall(plus, minus, startCap, nil))
+			plus = []wallPoint{at(span[1], 1)}
+			minus = []wallPoint{at(span[1], -1)}
+			startCap = nil
+		}
+
+		if i+1 < len(run.lines) {
+			// узел внутри цепочки: к другой стороне могут примыкать стены
+			next := run.lines[i+1]
+			plus = append(plus, sideJoint(joints.right[jointKey(to, line.ID)], joints.left[jointKey(to, next.ID)], vertexPoint(layer, to))...)
+			minus = append(minus, sideJoint(joints.left[jointKey(to, line.ID)], joints.right[jointKey(to, next.ID)], vertexPoint(layer, to))...)
+			continue
+		}
+
+		// конец цепочки
+		plus = append(plus, wallPoint{p: joints.right[jointKey(to, line.ID)]})
+		minus = append(minus, wallPoint{p: joints.left[jointKey(to, line.ID)]})
+		var endCap []wallPoint
+		if joints.degree[to] > 1 {
+			endCap = []wallPoint{vertexPoint(layer, to)}
+		}
+		pieces = appendPiece(pieces, closeWall(plus, minus, startCap, endCap))
+	}
+	return pieces
+}
+
+func vertexPoint(layer models.Layer, id string) wallPoint {
+	v := layer.Vertices[id]
+	return wallPoint{p: models.Point{X: v.X, Y: v.Y}, center: true}
+}
+
+// sideJoint — грань в узле цепочки: общий угол или, если к этой стороне примыкает стена,
+// клин через узел оси.
+func sideJoint(a, b models.Point, center wallPoint) []wallPoint {
+	if math.Hypot(a.X-b.X, a.Y-b.Y) < 1e-6 {
+		return []wallPoint{{p: a}}
+	}
+	return []wallPoint{{p: a}, center, {p: b}}
+}
+
+// closeWall замыкает часть стены: левая грань, конец, правая грань в обратном порядке, начало.
+func closeWall(plus, minus, startCap, endCap []wallPoint) []wallPoint {
+	polygon := append([]wallPoint{}, plus...)
+	polygon = append(polygon, endCap...)
+	for i := len(minus) - 1; i >= 0; i-- {
+		polygon = append(polygon, minus[i])
+	}
+	return append(polygon, startCap...)
+}
+
+// appendPiece пропускает вырожденные части (проем у самого узла).
+func appendPiece(pieces [][]wallPoint, piece []wallPoint) [][]wallPoint {
+	var area float64
+	for i, j := 0, len(piece)-1; i < len(piece); j, i = i, i+1 {
+		area += piece[j].p.X*piece[i].p.Y - piece[i].p.X*piece[j].p.Y
+	}
+	if math.Abs(area) < 1e-6 {
+		return pieces
+	}
+	return append(pieces, piece)
+}
+
+// holeSpans — участки проемов линии [от, до] по оси от начала обхода; reversed — линия
+// проходится от второго узла к первому.
+func holeSpans(line models.Line, layer models.Layer, length float64, reversed bool) [][2]float64 {
+	var spans [][2]float64
+	for _, id := range models.SortedKeys(layer.Holes) {
+		hole := layer.Holes[id]
+		if hole.Line != line.ID {
+			continue
+		}
+		center := clamp(hole.Offset, 0, 1) * length
+		if reversed {
+			center = length - center
+		}
+		width := models.LengthProperty(hole.Properties, "width", 80)
+		from, to := clamp(center-width/2, 0, length), clamp(center+width/2, 0, length)
+		if to-from > 1e-6 {
+			spans = append(spans, [2]float64{from, to})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	// перекрывающиеся проемы — один вырез
+	var merged [][2]float64
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span[0] <= merged[n-1][1] {
+			merged[n-1][1] = math.Max(merged[n-1][1], span[1])
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// ============================================================
+// Wall rendering
+// ============================================================
+
+// renderWallRun выводит стену одним path с контурами частей между проемами. Если у контура есть
+// ребра внутри стыков, заливка и обводка выводятся раздельно: обводятся только грани и откосы.
+func (r *Renderer) renderWallRun(id string, run wallRun, layer models.Layer, joints wallJoints, frame renderFrame) []string {
+	pieces := wallPolygons(run, layer, joints)
+	if len(pieces) == 0 {
+		return nil
+	}
+
+	var fill, outline strings.Builder
+	internal := false
+	for _, piece := range pieces {
+		for i, wp := range piece {
+			cmd := " L "
+			if i == 0 {
+				cmd = " M "
+			}
+			fill.WriteString(cmd)
+			fill.WriteString(formatPoint(frame.point(wp.p.X, wp.p.Y)))
+		}
+		fill.WriteString(" Z")
+
+		// обводка: ребра без узла оси, соседние ребра — одним подпутем
+		open := false
+		for i := range piece {
+			a, b := piece[i], piece[(i+1)%len(piece)]
+			if a.center || b.center {
+				internal = true
+				open = false
+				continue
+			}
+			if !open {
+				outline.WriteString(" M ")
+				outline.WriteString(formatPoint(frame.point(a.p.X, a.p.Y)))
+				open = true
+			}
+			outline.WriteString(" L ")
+			outline.WriteString(formatPoint(frame.point(b.p.X, b.p.Y)))
+		}
+	}
+
+	d := strings.TrimSpace(fill.String())
+	if !internal {
+		return []string{fmt.Sprintf(`<path id="%s" d="%s" %s />`, escapeAttr(id), d, r.theme.paint("wall"))}
+	}
+
+	style := r.theme.style("wall")
+	var out []string
+	attrs := fmt.Sprintf(`id="%s" `, escapeAttr(id))
+	if style.Fill != "" || style.Hatch != "" {
+		out = append(out, fmt.Sprintf(`<path %sd="%s" %s />`, attrs, d, r.theme.fill("wall")))
+		attrs = ""
+	}
+	if style.Stroke != "" && outline.Len() > 0 {
+		out = append(out, fmt.Sprintf(`<path %sd="%s" %s />`, attrs, strings.TrimSpace(outline.String()), r.theme.line("wall")))
+	}
+	return out
+}
+
+// wallRuns — линии стены key одной цепочкой; линии, не образующие цепочку, — каждая отдельно
+// со своим id.
+func wallRuns(key string, lines []models.Line) (ids []string, runs []wallRun) {
+	if run, ok := chainLines(lines); ok {
+		return []string{key}, []wallRun{run}
+	}
+	for _, line := range lines {
+		run, _ := chainLines([]models.Line{line})
+		ids = append(ids, line.ID)
+		runs = append(runs, run)
+	}
+	return ids, runs
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ============================================================
+// Scene helpers
+// ============================================================
+
+// ErrLayerNotFound — в сцене нет запрошенного слоя.
+var ErrLayerNotFound = errors.New("layer not found")
+
+// PickLayer возвращает id слоя: заданного, иначе выбранного (selectedLayer), иначе первого по id.
+func (s *Scene) PickLayer(layerID string) (string, error) {
+	if len(s.Layers) == 0 {
+		return "", fmt.Errorf("scene has no layers")
+	}
+	if layerID != "" {
+		if _, ok := s.Layers[layerID]; !ok {
+			return "", fmt.Errorf("%w: %s", ErrLayerNotFound, layerID)
+		}
+		return layerID, nil
+	}
+	if _, ok := s.Layers[s.SelectedLayer]; ok {
+		return s.SelectedLayer, nil
+	}
+	return SortedKeys(s.Layers)[0], nil
+}
+
+// DisplayName — название помещения: properties.name, иначе name, иначе id.
+func (a Area) DisplayName() string {
+	if name, ok := a.Properties["name"].(string); ok && name != "" {
+		return name
+	}
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.ID
+}
+
+// PlaceholderName сообщает, что комната найдена по стенам без подписи и сохранила имя
+// по умолчанию (room_N → "Room N"); по такому имени вид помещения не определить.
+func (a Area) PlaceholderName() bool {
+	source, _ := a.Misc["source"].(map[string]any)
+	if detected, _ := source["detected"].(bool); !detected {
+		return false
+	}
+	var n int
+	if _, err := fmt.Sscanf(a.ID, "room_%d", &n); err != nil {
+		return false
+	}
+	return a.DisplayName() == fmt.Sprintf("Room %d", n)
+}
+
+// LengthProperty читает длину из properties: число или {"length": число}.
+func LengthProperty(props map[string]any, key string, def float64) float64 {
+	switch v := props[key].(type) {
+	case float64:
+		return v
+	case map[string]any:
+		if f, ok := v["length"].(float64); ok {
+			return f
+		}
+	}
+	return def
+}
+
+// SortedKeys возвращает ключи map по возрастанию: обход сцены не зависит от порядка map.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Round округляет до digits знаков после запятой.
+func Round(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
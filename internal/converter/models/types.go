@@ -1,12 +1,19 @@
 package models
 
+import "strings"
+
 // ============================================================
 // SVG Elements
 // ============================================================
 
 type SVGElement struct {
 	ID       string
-	Type     string // wall, door, window, room, balcony
+	Type     string            // wall, door, window, room, balcony, item, column, shaft, stair
+	Tag      string            // имя исходного элемента: rect, path, polygon...
+	Class    string            // CSS class исходного элемента
+	Rule     string            // имя сработавшего правила классификации
+	Groups   []string          // имена родительских групп, от ближайшей к корню
+	Data     map[string]string // data-* атрибуты (без префикса data-)
 	Geometry interface{}
 }
 
@@ -21,6 +28,20 @@ type PathGeometry struct {
 	D string
 }
 
+// PolygonGeometry — polygon/polyline/line, а также rect после поворота или skew.
+type PolygonGeometry struct {
+	Points []Point
+	Closed bool
+}
+
+// TextLabel — текст (<text>) в мировых координатах SVG, кандидат в название комнаты.
+type TextLabel struct {
+	ID       string
+	Text     string
+	Position Point
+	Groups   []string // имена родительских групп, от ближайшей к корню
+}
+
 // ============================================================
 // Geometry primitives
 // ============================================================
@@ -56,6 +77,7 @@ type Vertex struct {
 	Lines      []string       `json:"lines"`
 	Areas      []string       `json:"areas"`
 	Selected   bool           `json:"selected"`
+	Visible    bool           `json:"visible"`
 	Properties map[string]any `json:"properties,omitempty"`
 	Misc       map[string]any `json:"misc,omitempty"`
 }
@@ -67,6 +89,8 @@ type Line struct {
 	Prototype  string         `json:"prototype"`
 	Vertices   []string       `json:"vertices"`
 	Holes      []string       `json:"holes"`
+	Selected   bool           `json:"selected"`
+	Visible    bool           `json:"visible"`
 	Properties map[string]any `json:"properties"`
 	Misc       map[string]any `json:"misc,omitempty"`
 }
@@ -78,10 +102,27 @@ type Hole struct {
 	Prototype  string         `json:"prototype"`
 	Offset     float64        `json:"offset"`
 	Line       string         `json:"line"`
+	Selected   bool           `json:"selected"`
+	Visible    bool           `json:"visible"`
 	Properties map[string]any `json:"properties"`
 	Misc       map[string]any `json:"misc,omitempty"`
 }
 
+// Kind — вид проема: door или window. Type — элемент каталога react-planner (door-double,
+// sliding-door...), поэтому вид берется из properties.kind, иначе из названия типа.
+func (h Hole) Kind() string {
+	if kind, ok := h.Properties["kind"].(string); ok && kind != "" {
+		return kind
+	}
+	switch {
+	case strings.Contains(h.Type, "window"):
+		return "window"
+	case strings.Contains(h.Type, "door"), strings.Contains(h.Type, "gate"):
+		return "door"
+	}
+	return h.Type
+}
+
 type Area struct {
 	ID         string         `json:"id"`
 	Name       string         `json:"name"`
@@ -89,6 +130,8 @@ type Area struct {
 	Prototype  string         `json:"prototype"`
 	Vertices   []string       `json:"vertices"`
 	Holes      []string       `json:"holes"`
+	Selected   bool           `json:"selected"`
+	Visible    bool           `json:"visible"`
 	Properties map[string]any `json:"properties"`
 	Misc       map[string]any `json:"misc,omitempty"`
 }
@@ -0,0 +1,396 @@
+package models
+
+import "fmt"
+
+// ============================================================
+// Scene validation
+// ============================================================
+
+// Уровни проблем. Ошибки ломают рендер (Renderer.Render), предупреждения — нет.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Коды проблем.
+const (
+	IssueNoLayers          = "no_layers"              // в сцене нет слоев
+	IssueSelectedLayer     = "selected_layer"         // selectedLayer не найден
+	IssueIDMismatch        = "id_mismatch"            // id элемента не совпадает с ключом в слое
+	IssueLineVertices      = "line_vertices"          // у линии не две вершины
+	IssueMissingVertex     = "missing_vertex"         // ссылка на несуществующую вершину
+	IssueMissingLine       = "missing_line"           // ссылка на несуществующую линию
+	IssueMissingHole       = "missing_hole"           // ссылка на несуществующий проем
+	IssueAreaVertices      = "area_vertices"          // у комнаты меньше трех различных вершин
+	IssueZeroLength        = "zero_length"            // линия нулевой длины
+	IssueHoleOffset        = "hole_offset"            // offset проема вне [0, 1]
+	IssueStaleBackRef      = "stale_back_reference"   // обратная ссылка на элемент, который на нее не ссылается
+	IssueMissingBackRef    = "missing_back_reference" // вершина или линия не ссылается на использующий ее элемент
+	IssueSelectedReference = "selected_reference"     // выделение ссылается на несуществующий элемент
+)
+
+// Issue — проблема в сцене с указанием элемента.
+type Issue struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Layer    string `json:"layer,omitempty"`
+	Element  string `json:"element,omitempty"` // vertex, line, hole, area, item, layer
+	ID       string `json:"id,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ValidationResult — результат проверки сцены. Repairs — исправления, сделанные RepairScene.
+type ValidationResult struct {
+	Valid    bool    `json:"valid"`
+	Errors   []Issue `json:"errors"`
+	Warnings []Issue `json:"warnings"`
+	Repairs  []Issue `json:"repairs,omitempty"`
+}
+
+// ValidateScene проверяет структурную целостность сцены: ссылки между вершинами,
+// линиями, проемами и комнатами и обратные ссылки вершин и линий.
+func ValidateScene(scene *Scene) *ValidationResult {
+	v := &validator{result: &ValidationResult{Errors: []Issue{}, Warnings: []Issue{}}}
+
+	if len(scene.Layers) == 0 {
+		v.add(SeverityError, IssueNoLayers, "", "", "", "scene has no layers")
+	} else if _, ok := scene.Layers[scene.SelectedLayer]; !ok {
+		v.add(SeverityWarning, IssueSelectedLayer, "", "layer", scene.SelectedLayer,
+			fmt.Sprintf("selected layer %q not found", scene.SelectedLayer))
+	}
+	for _, id := range SortedKeys(scene.Layers) {
+		layer := scene.Layers[id]
+		if layer.ID != id {
+			v.add(SeverityWarning, IssueIDMismatch, id, "layer", id, fmt.Sprintf("layer id %q differs from key", layer.ID))
+		}
+		v.layer(id, layer)
+	}
+
+	v.result.Valid = len(v.result.Errors) == 0
+	return v.result
+}
+
+type validator struct {
+	result *ValidationResult
+}
+
+func (v *validator) add(severity, code, layer, element, id, message string) {
+	issue := Issue{Severity: severity, Code: code, Layer: layer, Element: element, ID: id, Message: message}
+	if severity == SeverityError {
+		v.result.Errors = append(v.result.Errors, issue)
+	} else {
+		v.result.Warnings = append(v.result.Warnings, issue)
+	}
+}
+
+func (v *validator) layer(layerID string, layer Layer) {
+	errorf := func(code, element, id, format string, args ...any) {
+		v.add(SeverityError, code, layerID, element, id, fmt.Sprintf(format, args...))
+	}
+	warnf := func(code, element, id, format string, args ...any) {
+		v.add(SeverityWarning, code, layerID, element, id, fmt.Sprintf(format, args...))
+	}
+
+	for _, id := range SortedKeys(layer.Vertices) {
+		vertex := layer.Vertices[id]
+		if vertex.ID != id {
+			warnf(IssueIDMismatch, "vertex", id, "vertex id %q differs from key", vertex.ID)
+		}
+		for _, lineID := range vertex.Lines {
+			line, ok := layer.Lines[lineID]
+			if !ok {
+				warnf(IssueStaleBackRef, "vertex", id, "vertex references missing line %s", lineID)
+			} else if !contains(line.Vertices, id) {
+				warnf(IssueStaleBackRef, "vertex", id, "vertex references line %s that does not use it", lineID)
+			}
+		}
+		for _, areaID := range vertex.Areas {
+			area, ok := layer.Areas[areaID]
+			if !ok {
+				warnf(IssueStaleBackRef, "vertex", id, "vertex references missing area %s", areaID)
+			} else if !contains(area.Vertices, id) {
+				warnf(IssueStaleBackRef, "vertex", id, "vertex references area %s that does not use it", areaID)
+			}
+		}
+	}
+
+	for _, id := range SortedKeys(layer.Lines) {
+		line := layer.Lines[id]
+		if line.ID != id {
+			warnf(IssueIDMismatch, "line", id, "line id %q differs from key", line.ID)
+		}
+		if len(line.Vertices) != 2 {
+			errorf(IssueLineVertices, "line", id, "line has %d vertices, expected 2", len(line.Vertices))
+		}
+		resolved := 0
+		for _, vertexID := range line.Vertices {
+			vertex, ok := layer.Vertices[vertexID]
+			if !ok {
+				errorf(IssueMissingVertex, "line", id, "line references missing vertex %s", vertexID)
+				continue
+			}
+			resolved++
+			if !contains(vertex.Lines, id) {
+				warnf(IssueMissingBackRef, "vertex", vertexID, "vertex does not list line %s", id)
+			}
+		}
+		if resolved == 2 && len(line.Vertices) == 2 {
+			a, b := layer.Vertices[line.Vertices[0]], layer.Vertices[line.Vertices[1]]
+			if a.X == b.X && a.Y == b.Y {
+				warnf(IssueZeroLength, "line", id, "line has zero length")
+			}
+		}
+		for _, holeID := range line.Holes {
+			hole, ok := layer.Holes[holeID]
+			if !ok {
+				warnf(IssueMissingHole, "line", id, "line references missing hole %s", holeID)
+			} else if hole.Line != id {
+				warnf(IssueStaleBackRef, "line", id, "line lists hole %s that belongs to line %s", holeID, hole.Line)
+			}
+		}
+	}
+
+	for _, id := range SortedKeys(layer.Holes) {
+		hole := layer.Holes[id]
+		if hole.ID != id {
+			warnf(IssueIDMismatch, "hole", id, "hole id %q differs from key", hole.ID)
+		}
+		line, ok := layer.Lines[hole.Line]
+		if !ok {
+			errorf(IssueMissingLine, "hole", id, "hole references missing line %q", hole.Line)
+			continue
+		}
+		if !contains(line.Holes, id) {
+			warnf(IssueMissingBackRef, "line", hole.Line, "line does not list hole %s", id)
+		}
+		if hole.Offset < 0 || hole.Offset > 1 {
+			warnf(IssueHoleOffset, "hole", id, "hole offset %g is outside [0, 1]", hole.Offset)
+		}
+	}
+
+	for _, id := range SortedKeys(layer.Areas) {
+		area := layer.Areas[id]
+		if area.ID != id {
+			warnf(IssueIDMismatch, "area", id, "area id %q differs from key", area.ID)
+		}
+		distinct := make(map[string]bool)
+		for _, vertexID := range area.Vertices {
+			vertex, ok := layer.Vertices[vertexID]
+			if !ok {
+				errorf(IssueMissingVertex, "area", id, "area references missing vertex %s", vertexID)
+				continue
+			}
+			distinct[vertexID] = true
+			if !contains(vertex.Areas, id) {
+				warnf(IssueMissingBackRef, "vertex", vertexID, "vertex does not list area %s", id)
+			}
+		}
+		if len(distinct) < 3 {
+			errorf(IssueAreaVertices, "area", id, "area has %d distinct vertices, expected at least 3", len(distinct))
+		}
+	}
+
+	for _, id := range SortedKeys(layer.Items) {
+		if item := layer.Items[id]; item.ID != id {
+			warnf(IssueIDMismatch, "item", id, "item id %q differs from key", item.ID)
+		}
+	}
+
+	selected := []struct {
+		element string
+		ids     []string
+		exists  func(string) bool
+	}{
+		{"vertex", layer.Selected.Vertices, func(id string) bool { _, ok := layer.Vertices[id]; return ok }},
+		{"line", layer.Selected.Lines, func(id string) bool { _, ok := layer.Lines[id]; return ok }},
+		{"hole", layer.Selected.Holes, func(id string) bool { _, ok := layer.Holes[id]; return ok }},
+		{"area", layer.Selected.Areas, func(id string) bool { _, ok := layer.Areas[id]; return ok }},
+		{"item", layer.Selected.Items, func(id string) bool { _, ok := layer.Items[id]; return ok }},
+	}
+	for _, set := range selected {
+		for _, id := range set.ids {
+			if !set.exists(id) {
+				warnf(IssueSelectedReference, set.element, id, "selection references missing %s %s", set.element, id)
+			}
+		}
+	}
+}
+
+// ============================================================
+// Repair
+// ============================================================
+
+// RepairScene исправляет то, что однозначно восстанавливается по прямым ссылкам:
+// id элементов по ключам, обратные ссылки вершин (lines, areas) и линий (holes),
+// выделение несуществующих элементов. Битые прямые ссылки (проем на удаленной стене,
+// комната из двух вершин) не трогает — это ошибки редактирования.
+func RepairScene(scene *Scene) []Issue {
+	var repairs []Issue
+	fixed := func(code, layer, element, id, format string, args ...any) {
+		repairs = append(repairs, Issue{
+			Severity: SeverityWarning, Code: code, Layer: layer,
+			Element: element, ID: id, Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, layerID := range SortedKeys(scene.Layers) {
+		layer := scene.Layers[layerID]
+		if layer.ID != layerID {
+			layer.ID = layerID
+			fixed(IssueIDMismatch, layerID, "layer", layerID, "layer id set to key")
+		}
+
+		vertexLines := make(map[string][]string)
+		for _, id := range SortedKeys(layer.Lines) {
+			for _, vertexID := range layer.Lines[id].Vertices {
+				if !contains(vertexLines[vertexID], id) {
+					vertexLines[vertexID] = append(vertexLines[vertexID], id)
+				}
+			}
+		}
+		vertexAreas := make(map[string][]string)
+		for _, id := range SortedKeys(layer.Areas) {
+			for _, vertexID := range layer.Areas[id].Vertices {
+				if !contains(vertexAreas[vertexID], id) {
+					vertexAreas[vertexID] = append(vertexAreas[vertexID], id)
+				}
+			}
+		}
+		lineHoles := make(map[string][]string)
+		for _, id := range SortedKeys(layer.Holes) {
+			hole := layer.Holes[id]
+			if _, ok := layer.Lines[hole.Line]; ok {
+				lineHoles[hole.Line] = append(lineHoles[hole.Line], id)
+			}
+		}
+
+		for _, id := range SortedKeys(layer.Vertices) {
+			vertex := layer.Vertices[id]
+			if vertex.ID != id {
+				vertex.ID = id
+				fixed(IssueIDMismatch, layerID, "vertex", id, "vertex id set to key")
+			}
+			if lines := orEmpty(vertexLines[id]); !sameSet(vertex.Lines, lines) {
+				vertex.Lines = keepOrder(vertex.Lines, lines)
+				fixed(IssueStaleBackRef, layerID, "vertex", id, "vertex lines rebuilt: %v", vertex.Lines)
+			}
+			if areas := orEmpty(vertexAreas[id]); !sameSet(vertex.Areas, areas) {
+				vertex.Areas = keepOrder(vertex.Areas, areas)
+				fixed(IssueStaleBackRef, layerID, "vertex", id, "vertex areas rebuilt: %v", vertex.Areas)
+			}
+			layer.Vertices[id] = vertex
+		}
+		for _, id := range SortedKeys(layer.Lines) {
+			line := layer.Lines[id]
+			if line.ID != id {
+				line.ID = id
+				fixed(IssueIDMismatch, layerID, "line", id, "line id set to key")
+			}
+			if holes := orEmpty(lineHoles[id]); !sameSet(line.Holes, holes) {
+				line.Holes = keepOrder(line.Holes, holes)
+				fixed(IssueStaleBackRef, layerID, "line", id, "line holes rebuilt: %v", line.Holes)
+			}
+			layer.Lines[id] = line
+		}
+		for _, id := range SortedKeys(layer.Holes) {
+			if hole := layer.Holes[id]; hole.ID != id {
+				hole.ID = id
+				layer.Holes[id] = hole
+				fixed(IssueIDMismatch, layerID, "hole", id, "hole id set to key")
+			}
+		}
+		for _, id := range SortedKeys(layer.Areas) {
+			if area := layer.Areas[id]; area.ID != id {
+				area.ID = id
+				layer.Areas[id] = area
+				fixed(IssueIDMismatch, layerID, "area", id, "area id set to key")
+			}
+		}
+		for _, id := range SortedKeys(layer.Items) {
+			if item := layer.Items[id]; item.ID != id {
+				item.ID = id
+				layer.Items[id] = item
+				fixed(IssueIDMismatch, layerID, "item", id, "item id set to key")
+			}
+		}
+
+		before := len(layer.Selected.Vertices) + len(layer.Selected.Lines) + len(layer.Selected.Holes) +
+			len(layer.Selected.Areas) + len(layer.Selected.Items)
+		layer.Selected.Vertices = existing(layer.Selected.Vertices, layer.Vertices)
+		layer.Selected.Lines = existing(layer.Selected.Lines, layer.Lines)
+		layer.Selected.Holes = existing(layer.Selected.Holes, layer.Holes)
+		layer.Selected.Areas = existing(layer.Selected.Areas, layer.Areas)
+		layer.Selected.Items = existing(layer.Selected.Items, layer.Items)
+		after := len(layer.Selected.Vertices) + len(layer.Selected.Lines) + len(layer.Selected.Holes) +
+			len(layer.Selected.Areas) + len(layer.Selected.Items)
+		if after != before {
+			fixed(IssueSelectedReference, layerID, "layer", layerID, "removed %d missing elements from selection", before-after)
+		}
+
+		scene.Layers[layerID] = layer
+	}
+	return repairs
+}
+
+// ============================================================
+// Helpers
+// ============================================================
+
+func contains(list []string, id string) bool {
+	for _, item := range list {
+		if item == id {
+			return true
+		}
+	}
+	return false
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, id := range a {
+		if !contains(b, id) {
+			return false
+		}
+	}
+	for _, id := range b {
+		if !contains(a, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// keepOrder возвращает want, сохраняя порядок уже существующих ссылок.
+func keepOrder(have, want []string) []string {
+	out := make([]string, 0, len(want))
+	for _, id := range have {
+		if contains(want, id) && !contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	for _, id := range want {
+		if !contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func existing[V any](ids []string, m map[string]V) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := m[id]; ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func orEmpty(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
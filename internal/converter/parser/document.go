@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Document
+// ============================================================
+
+// Length — значение атрибута длины с единицей измерения ("210mm" → 210, "mm").
+type Length struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+// Document — классифицированные элементы, текстовые подписи и размеры корневого <svg>.
+type Document struct {
+	Elements []models.SVGElement
+	Texts    []models.TextLabel
+	Width    Length
+	Height   Length
+	ViewBox  []float64        // minX, minY, width, height; nil, если viewBox не задан
+	Skipped  []SkippedElement // поддеревья, пропущенные из-за ошибок разбора
+}
+
+// ParseDocument парсит SVG как ParseSVGWithRules, дополнительно возвращая width/height/viewBox.
+func ParseDocument(r io.Reader, rules *RuleSet) (*Document, error) {
+	w, err := walkDocument(r, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Elements: w.elements, Texts: w.texts, Skipped: w.skipped}
+	doc.Width, _ = ParseLength(w.root.attr("width"))
+	doc.Height, _ = ParseLength(w.root.attr("height"))
+	if raw := w.root.attr("viewBox"); raw != "" {
+		if vb, err := parseNumberList(raw); err == nil && len(vb) == 4 && vb[2] > 0 && vb[3] > 0 {
+			doc.ViewBox = vb
+		}
+	}
+	return doc, nil
+}
+
+// PhysicalScale возвращает, сколько сантиметров в единице пользователя SVG, если
+// width/height корня заданы в физических единицах (mm, cm, in, pt, pc).
+// С viewBox масштаб = физическая ширина / ширина viewBox, без него единица пользователя — CSS px.
+func (d *Document) PhysicalScale() (float64, bool) {
+	for i, size := range []Length{d.Width, d.Height} {
+		if size.Value <= 0 || size.Unit == "" || size.Unit == "px" {
+			continue
+		}
+		cm, ok := CentimetersPerUnit(size.Unit)
+		if !ok {
+			continue
+		}
+		if d.ViewBox == nil {
+			px, _ := CentimetersPerUnit("px")
+			return px, true
+		}
+		return size.Value * cm / d.ViewBox[2+i], true
+	}
+	return 0, false
+}
+
+// ParseLength разбирает длину с необязательной единицей: "420", "420cm", "4.2 m", "12in".
+func ParseLength(s string) (Length, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Length{}, fmt.Errorf("empty length")
+	}
+
+	end := len(s)
+	for end > 0 && (s[end-1] >= 'a' && s[end-1] <= 'z' || s[end-1] >= 'A' && s[end-1] <= 'Z' || s[end-1] == '%') {
+		end--
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(s[:end]), 64)
+	if err != nil {
+		return Length{}, fmt.Errorf("invalid length %q", s)
+	}
+	return Length{Value: value, Unit: strings.ToLower(s[end:])}, nil
+}
+
+// CentimetersPerUnit — сколько сантиметров в единице измерения. Пустая единица считается см.
+func CentimetersPerUnit(unit string) (float64, bool) {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "", "cm":
+		return 1, true
+	case "mm":
+		return 0.1, true
+	case "m":
+		return 100, true
+	case "in":
+		return 2.54, true
+	case "ft":
+		return 30.48, true
+	case "pt":
+		return 2.54 / 72, true
+	case "pc":
+		return 2.54 / 6, true
+	case "px":
+		return 2.54 / 96, true
+	}
+	return 0, false
+}
+
+// TransformElements применяет матрицу к геометрии всех элементов (например, масштаб калибровки).
+// Rect остается RectGeometry при сохраняющем оси преобразовании, иначе становится полигоном.
+func TransformElements(elements []models.SVGElement, m Matrix) ([]models.SVGElement, error) {
+	out := make([]models.SVGElement, 0, len(elements))
+	for _, elem := range elements {
+		geometry, err := TransformGeometry(elem.Geometry, m)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", elem.ID, err)
+		}
+		elem.Geometry = geometry
+		out = append(out, elem)
+	}
+	return out, nil
+}
+
+// TransformTexts применяет матрицу к точкам привязки подписей.
+func TransformTexts(texts []models.TextLabel, m Matrix) []models.TextLabel {
+	out := make([]models.TextLabel, len(texts))
+	for i, text := range texts {
+		text.Position = m.Apply(text.Position)
+		out[i] = text
+	}
+	return out
+}
+
+// TransformGeometry применяет матрицу к RectGeometry, PathGeometry или PolygonGeometry.
+func TransformGeometry(geometry interface{}, m Matrix) (interface{}, error) {
+	if m.IsIdentity() {
+		return geometry, nil
+	}
+
+	switch geom := geometry.(type) {
+	case models.RectGeometry:
+		corners := []models.Point{
+			m.Apply(models.Point{X: geom.X, Y: geom.Y}),
+			m.Apply(models.Point{X: geom.X + geom.Width, Y: geom.Y}),
+			m.Apply(models.Point{X: geom.X + geom.Width, Y: geom.Y + geom.Height}),
+			m.Apply(models.Point{X: geom.X, Y: geom.Y + geom.Height}),
+		}
+		if m.IsAxisAligned() {
+			return rectFromCorners(corners), nil
+		}
+		return models.PolygonGeometry{Points: corners, Closed: true}, nil
+
+	case models.PathGeometry:
+		segments, err := ParsePathSegments(geom.D)
+		if err != nil {
+			return nil, err
+		}
+		return models.PathGeometry{D: FormatPath(TransformSegments(segments, m))}, nil
+
+	case models.PolygonGeometry:
+		points := make([]models.Point, len(geom.Points))
+		for i, p := range geom.Points {
+			points[i] = m.Apply(p)
+		}
+		return models.PolygonGeometry{Points: points, Closed: geom.Closed}, nil
+	}
+	return geometry, nil
+}
@@ -2,7 +2,7 @@ package parser
 
 import (
 	"fmt"
-	"regexp"
+	"math"
 	"strconv"
 	"strings"
 
@@ -13,115 +13,691 @@ import (
 // Path Parser
 // ============================================================
 
-// ParsePath парсит SVG path в список точек
+// DefaultCurveTolerance — максимальное отклонение ломаной от кривой (в единицах SVG)
+// при аппроксимации C/S/Q/T/A команд.
+const DefaultCurveTolerance = 0.5
+
+// maxCurveSteps ограничивает число отрезков на одну кривую.
+const maxCurveSteps = 256
+
+type SegmentKind int
+
+const (
+	SegmentMove SegmentKind = iota
+	SegmentLine
+	SegmentCubic
+	SegmentQuad
+	SegmentArc
+	SegmentClose
+)
+
+// PathSegment — сегмент пути в абсолютных координатах.
+// Для кривых C1/C2 — контрольные точки, для дуг — параметры эллипса из команды A.
+type PathSegment struct {
+	Kind     SegmentKind
+	From     models.Point
+	To       models.Point
+	C1       models.Point
+	C2       models.Point
+	RX       float64
+	RY       float64
+	Rotation float64
+	LargeArc bool
+	Sweep    bool
+}
+
+// ParsePath парсит SVG path в список точек (кривые аппроксимируются с DefaultCurveTolerance).
 func ParsePath(d string) ([]models.Point, error) {
+	return ParsePathTolerance(d, DefaultCurveTolerance)
+}
+
+// ParsePathTolerance парсит SVG path в список точек, аппроксимируя кривые и дуги
+// ломаной с заданным допуском.
+func ParsePathTolerance(d string, tolerance float64) ([]models.Point, error) {
+	segments, err := ParsePathSegments(d)
+	if err != nil {
+		return nil, err
+	}
+	return FlattenSegments(segments, tolerance), nil
+}
+
+// ParsePathSegments разбирает полную грамматику SVG path (M/L/H/V/C/S/Q/T/A/Z,
+// абсолютные и относительные, с неявным повтором команд) в абсолютные сегменты.
+func ParsePathSegments(d string) ([]PathSegment, error) {
 	d = strings.TrimSpace(d)
 	if d == "" {
 		return nil, fmt.Errorf("empty path")
 	}
 
-	var points []models.Point
-	var currentX, currentY float64
+	s := &pathScanner{src: d}
+	var segments []PathSegment
+	var current, subpathStart models.Point
+	var lastControl models.Point // отражаемая контрольная точка для S/T
+	var prevCmd byte
+	var cmd byte
 
-	// Простой парсер команд M, m, L, l, H, h, V, v, Z
-	re := regexp.MustCompile(`([MmLlHhVvZz])([^MmLlHhVvZz]*)`)
-	matches := re.FindAllStringSubmatch(d, -1)
+	for {
+		s.skipSeparators()
+		if s.eof() {
+			break
+		}
 
-	for _, match := range matches {
-		if len(match) < 2 {
-			continue
+		ch := s.peek()
+		if isPathCommand(ch) {
+			cmd = ch
+			s.pos++
+		} else {
+			if cmd == 0 {
+				return nil, fmt.Errorf("path must start with a command, got %q at %d", ch, s.pos)
+			}
+			if cmd == 'Z' || cmd == 'z' {
+				return nil, fmt.Errorf("unexpected %q after close command at %d", ch, s.pos)
+			}
+			// Неявный повтор: после M/m пары координат трактуются как L/l
+			switch cmd {
+			case 'M':
+				cmd = 'L'
+			case 'm':
+				cmd = 'l'
+			}
 		}
 
-		cmd := match[1]
-		args := strings.TrimSpace(match[2])
+		relative := cmd >= 'a' && cmd <= 'z'
+		base := current
+		if !relative {
+			base = models.Point{}
+		}
 
 		switch cmd {
-		case "M": // MoveTo absolute
-			coords := parseCoords(args)
-			if len(coords) >= 2 {
-				currentX, currentY = coords[0], coords[1]
-				points = append(points, models.Point{X: currentX, Y: currentY})
+		case 'M', 'm':
+			x, y, err := s.readPair()
+			if err != nil {
+				return nil, err
+			}
+			current = models.Point{X: base.X + x, Y: base.Y + y}
+			subpathStart = current
+			segments = append(segments, PathSegment{Kind: SegmentMove, From: current, To: current})
+
+		case 'L', 'l':
+			x, y, err := s.readPair()
+			if err != nil {
+				return nil, err
 			}
+			next := models.Point{X: base.X + x, Y: base.Y + y}
+			segments = append(segments, PathSegment{Kind: SegmentLine, From: current, To: next})
+			current = next
 
-		case "m": // MoveTo relative
-			coords := parseCoords(args)
-			if len(coords) >= 2 {
-				currentX += coords[0]
-				currentY += coords[1]
-				points = append(points, models.Point{X: currentX, Y: currentY})
+		case 'H', 'h':
+			x, err := s.readNumber()
+			if err != nil {
+				return nil, err
 			}
+			next := models.Point{X: base.X + x, Y: current.Y}
+			segments = append(segments, PathSegment{Kind: SegmentLine, From: current, To: next})
+			current = next
 
-		case "L": // LineTo absolute
-			coords := parseCoords(args)
-			if len(coords) >= 2 {
-				currentX, currentY = coords[0], coords[1]
-				points = append(points, models.Point{X: currentX, Y: currentY})
+		case 'V', 'v':
+			y, err := s.readNumber()
+			if err != nil {
+				return nil, err
 			}
+			next := models.Point{X: current.X, Y: base.Y + y}
+			segments = append(segments, PathSegment{Kind: SegmentLine, From: current, To: next})
+			current = next
 
-		case "l": // LineTo relative
-			coords := parseCoords(args)
-			if len(coords) >= 2 {
-				currentX += coords[0]
-				currentY += coords[1]
-				points = append(points, models.Point{X: currentX, Y: currentY})
+		case 'C', 'c':
+			nums, err := s.readNumbers(6)
+			if err != nil {
+				return nil, err
 			}
+			c1 := models.Point{X: base.X + nums[0], Y: base.Y + nums[1]}
+			c2 := models.Point{X: base.X + nums[2], Y: base.Y + nums[3]}
+			next := models.Point{X: base.X + nums[4], Y: base.Y + nums[5]}
+			segments = append(segments, PathSegment{Kind: SegmentCubic, From: current, To: next, C1: c1, C2: c2})
+			lastControl = c2
+			current = next
 
-		case "H": // Horizontal line absolute
-			coords := parseCoords(args)
-			if len(coords) >= 1 {
-				currentX = coords[0]
-				points = append(points, models.Point{X: currentX, Y: currentY})
+		case 'S', 's':
+			nums, err := s.readNumbers(4)
+			if err != nil {
+				return nil, err
+			}
+			c1 := current
+			if isCubicCommand(prevCmd) {
+				c1 = reflect(lastControl, current)
 			}
+			c2 := models.Point{X: base.X + nums[0], Y: base.Y + nums[1]}
+			next := models.Point{X: base.X + nums[2], Y: base.Y + nums[3]}
+			segments = append(segments, PathSegment{Kind: SegmentCubic, From: current, To: next, C1: c1, C2: c2})
+			lastControl = c2
+			current = next
 
-		case "h": // Horizontal line relative
-			coords := parseCoords(args)
-			if len(coords) >= 1 {
-				currentX += coords[0]
-				points = append(points, models.Point{X: currentX, Y: currentY})
+		case 'Q', 'q':
+			nums, err := s.readNumbers(4)
+			if err != nil {
+				return nil, err
 			}
+			c1 := models.Point{X: base.X + nums[0], Y: base.Y + nums[1]}
+			next := models.Point{X: base.X + nums[2], Y: base.Y + nums[3]}
+			segments = append(segments, PathSegment{Kind: SegmentQuad, From: current, To: next, C1: c1})
+			lastControl = c1
+			current = next
 
-		case "V": // Vertical line absolute
-			coords := parseCoords(args)
-			if len(coords) >= 1 {
-				currentY = coords[0]
-				points = append(points, models.Point{X: currentX, Y: currentY})
+		case 'T', 't':
+			x, y, err := s.readPair()
+			if err != nil {
+				return nil, err
+			}
+			c1 := current
+			if isQuadCommand(prevCmd) {
+				c1 = reflect(lastControl, current)
 			}
+			next := models.Point{X: base.X + x, Y: base.Y + y}
+			segments = append(segments, PathSegment{Kind: SegmentQuad, From: current, To: next, C1: c1})
+			lastControl = c1
+			current = next
 
-		case "v": // Vertical line relative
-			coords := parseCoords(args)
-			if len(coords) >= 1 {
-				currentY += coords[0]
-				points = append(points, models.Point{X: currentX, Y: currentY})
+		case 'A', 'a':
+			rx, err := s.readNumber()
+			if err != nil {
+				return nil, err
+			}
+			ry, err := s.readNumber()
+			if err != nil {
+				return nil, err
+			}
+			rotation, err := s.readNumber()
+			if err != nil {
+				return nil, err
 			}
+			largeArc, err := s.readFlag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := s.readFlag()
+			if err != nil {
+				return nil, err
+			}
+			x, y, err := s.readPair()
+			if err != nil {
+				return nil, err
+			}
+			next := models.Point{X: base.X + x, Y: base.Y + y}
+			segments = append(segments, PathSegment{
+				Kind:     SegmentArc,
+				From:     current,
+				To:       next,
+				RX:       math.Abs(rx),
+				RY:       math.Abs(ry),
+				Rotation: rotation,
+				LargeArc: largeArc,
+				Sweep:    sweep,
+			})
+			current = next
+
+		case 'Z', 'z':
+			segments = append(segments, PathSegment{Kind: SegmentClose, From: current, To: subpathStart})
+			current = subpathStart
+		}
+
+		prevCmd = cmd
+	}
+
+	return segments, nil
+}
+
+// FlattenSegments превращает сегменты в ломаную. Z добавляет точку начала подпути.
+func FlattenSegments(segments []PathSegment, tolerance float64) []models.Point {
+	if tolerance <= 0 {
+		tolerance = DefaultCurveTolerance
+	}
+
+	var points []models.Point
+	for _, seg := range segments {
+		switch seg.Kind {
+		case SegmentMove, SegmentLine, SegmentClose:
+			points = append(points, seg.To)
+		case SegmentCubic:
+			points = append(points, flattenCubic(seg, tolerance)...)
+		case SegmentQuad:
+			points = append(points, flattenQuad(seg, tolerance)...)
+		case SegmentArc:
+			points = append(points, flattenArc(seg, tolerance)...)
+		}
+	}
+	return points
+}
+
+// ============================================================
+// Curve flattening
+// ============================================================
+
+// flattenCubic: ошибка хорды не превышает M/(8n²), где M ≤ 6·max|P0-2P1+P2|.
+func flattenCubic(seg PathSegment, tolerance float64) []models.Point {
+	dd := math.Max(
+		math.Hypot(seg.From.X-2*seg.C1.X+seg.C2.X, seg.From.Y-2*seg.C1.Y+seg.C2.Y),
+		math.Hypot(seg.C1.X-2*seg.C2.X+seg.To.X, seg.C1.Y-2*seg.C2.Y+seg.To.Y),
+	)
+	n := curveSteps(math.Sqrt(3 * dd / (4 * tolerance)))
+
+	out := make([]models.Point, 0, n)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		a := mt * mt * mt
+		b := 3 * mt * mt * t
+		c := 3 * mt * t * t
+		e := t * t * t
+		out = append(out, models.Point{
+			X: a*seg.From.X + b*seg.C1.X + c*seg.C2.X + e*seg.To.X,
+			Y: a*seg.From.Y + b*seg.C1.Y + c*seg.C2.Y + e*seg.To.Y,
+		})
+	}
+	out[len(out)-1] = seg.To
+	return out
+}
+
+// flattenQuad: вторая производная квадратичной кривой постоянна, M = 2·|P0-2P1+P2|.
+func flattenQuad(seg PathSegment, tolerance float64) []models.Point {
+	dd := math.Hypot(seg.From.X-2*seg.C1.X+seg.To.X, seg.From.Y-2*seg.C1.Y+seg.To.Y)
+	n := curveSteps(math.Sqrt(dd / (4 * tolerance)))
+
+	out := make([]models.Point, 0, n)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		out = append(out, models.Point{
+			X: mt*mt*seg.From.X + 2*mt*t*seg.C1.X + t*t*seg.To.X,
+			Y: mt*mt*seg.From.Y + 2*mt*t*seg.C1.Y + t*t*seg.To.Y,
+		})
+	}
+	out[len(out)-1] = seg.To
+	return out
+}
+
+// flattenArc: шаг по углу выбирается так, чтобы стрелка сегмента r(1-cos(Δ/2)) не превышала допуск.
+func flattenArc(seg PathSegment, tolerance float64) []models.Point {
+	arc, ok := ArcCenter(seg)
+	if !ok {
+		return []models.Point{seg.To}
+	}
+
+	r := math.Max(arc.RX, arc.RY)
+	maxStep := math.Pi / 2
+	if tolerance < r {
+		maxStep = math.Min(maxStep, 2*math.Acos(1-tolerance/r))
+	}
+	n := curveSteps(math.Abs(arc.Delta) / maxStep)
+
+	out := make([]models.Point, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, arc.PointAt(arc.Start+arc.Delta*float64(i)/float64(n)))
+	}
+	out[len(out)-1] = seg.To
+	return out
+}
+
+func curveSteps(v float64) int {
+	n := int(math.Ceil(v))
+	if n < 1 {
+		return 1
+	}
+	if n > maxCurveSteps {
+		return maxCurveSteps
+	}
+	return n
+}
+
+// Arc — дуга эллипса в центральной параметризации (углы в радианах).
+type Arc struct {
+	Center models.Point
+	RX     float64
+	RY     float64
+	Phi    float64
+	Start  float64
+	Delta  float64
+}
+
+// PointAt возвращает точку эллипса для параметрического угла theta.
+func (a Arc) PointAt(theta float64) models.Point {
+	cosPhi, sinPhi := math.Cos(a.Phi), math.Sin(a.Phi)
+	x := a.RX * math.Cos(theta)
+	y := a.RY * math.Sin(theta)
+	return models.Point{
+		X: a.Center.X + x*cosPhi - y*sinPhi,
+		Y: a.Center.Y + x*sinPhi + y*cosPhi,
+	}
+}
+
+// ArcCenter переводит дугу из endpoint-параметризации SVG в центральную
+// (SVG 1.1, приложение F.6.5), корректируя слишком маленькие радиусы (F.6.6).
+func ArcCenter(seg PathSegment) (Arc, bool) {
+	rx, ry := math.Abs(seg.RX), math.Abs(seg.RY)
+	if rx == 0 || ry == 0 || (seg.From.X == seg.To.X && seg.From.Y == seg.To.Y) {
+		return Arc{}, false
+	}
+
+	phi := seg.Rotation * math.Pi / 180
+	cosPhi, sinPhi := math.Cos(phi), math.Sin(phi)
+
+	dx := (seg.From.X - seg.To.X) / 2
+	dy := (seg.From.Y - seg.To.Y) / 2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+
+	lambda := (x1*x1)/(rx*rx) + (y1*y1)/(ry*ry)
+	if lambda > 1 {
+		scale := math.Sqrt(lambda)
+		rx *= scale
+		ry *= scale
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := 0.0
+	if den != 0 && num > 0 {
+		coef = math.Sqrt(num / den)
+	}
+	if seg.LargeArc == seg.Sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+
+	center := models.Point{
+		X: cosPhi*cx1 - sinPhi*cy1 + (seg.From.X+seg.To.X)/2,
+		Y: sinPhi*cx1 + cosPhi*cy1 + (seg.From.Y+seg.To.Y)/2,
+	}
+
+	start := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	end := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx)
+	delta := end - start
+	if seg.Sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !seg.Sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+
+	return Arc{Center: center, RX: rx, RY: ry, Phi: phi, Start: start, Delta: delta}, true
+}
+
+// ============================================================
+// Tokenizer
+// ============================================================
+
+type pathScanner struct {
+	src string
+	pos int
+}
+
+func (s *pathScanner) eof() bool {
+	return s.pos >= len(s.src)
+}
+
+func (s *pathScanner) peek() byte {
+	return s.src[s.pos]
+}
+
+func (s *pathScanner) skipSeparators() {
+	for !s.eof() {
+		switch s.peek() {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// readNumber читает число по грамматике SVG: знак, целая/дробная часть, экспонента.
+// Поддерживает компактную запись вида "-0.08.02" (= -0.08, 0.02) и "10-5" (= 10, -5).
+func (s *pathScanner) readNumber() (float64, error) {
+	s.skipSeparators()
+	if s.eof() {
+		return 0, fmt.Errorf("unexpected end of path, number expected")
+	}
+
+	start := s.pos
+	if c := s.peek(); c == '+' || c == '-' {
+		s.pos++
+	}
+
+	digits := 0
+	for !s.eof() && isDigit(s.peek()) {
+		s.pos++
+		digits++
+	}
+	if !s.eof() && s.peek() == '.' {
+		s.pos++
+		for !s.eof() && isDigit(s.peek()) {
+			s.pos++
+			digits++
+		}
+	}
+	if digits == 0 {
+		s.pos = start
+		return 0, fmt.Errorf("number expected at %d, got %q", start, s.src[start])
+	}
+
+	// Экспонента: e/E только если за ней действительно идут цифры
+	if !s.eof() && (s.peek() == 'e' || s.peek() == 'E') {
+		mark := s.pos
+		s.pos++
+		if !s.eof() && (s.peek() == '+' || s.peek() == '-') {
+			s.pos++
+		}
+		expDigits := 0
+		for !s.eof() && isDigit(s.peek()) {
+			s.pos++
+			expDigits++
+		}
+		if expDigits == 0 {
+			s.pos = mark
+		}
+	}
+
+	val, err := strconv.ParseFloat(s.src[start:s.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q at %d: %w", s.src[start:s.pos], start, err)
+	}
+	return val, nil
+}
+
+// readFlag читает флаг дуги: ровно один символ 0 или 1 (допускается запись "a10 10 0 0110 10").
+func (s *pathScanner) readFlag() (bool, error) {
+	s.skipSeparators()
+	if s.eof() {
+		return false, fmt.Errorf("unexpected end of path, arc flag expected")
+	}
+	switch s.peek() {
+	case '0':
+		s.pos++
+		return false, nil
+	case '1':
+		s.pos++
+		return true, nil
+	}
+	return false, fmt.Errorf("arc flag expected at %d, got %q", s.pos, s.peek())
+}
+
+func (s *pathScanner) readPair() (float64, float64, error) {
+	x, err := s.readNumber()
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := s.readNumber()
+	if err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
+
+func (s *pathScanner) readNumbers(n int) ([]float64, error) {
+	out := make([]float64, n)
+	for i := range out {
+		v, err := s.readNumber()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func isPathCommand(c byte) bool {
+	return strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0
+}
+
+func isCubicCommand(c byte) bool {
+	return c == 'C' || c == 'c' || c == 'S' || c == 's'
+}
+
+func isQuadCommand(c byte) bool {
+	return c == 'Q' || c == 'q' || c == 'T' || c == 't'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func reflect(control, around models.Point) models.Point {
+	return models.Point{X: 2*around.X - control.X, Y: 2*around.Y - control.Y}
+}
 
-		case "Z", "z": // Close path
-			// Замыкаем путь, возвращаясь к первой точке
-			if len(points) > 0 {
-				points = append(points, points[0])
+// ============================================================
+// Transform & serialization
+// ============================================================
+
+// TransformSegments применяет матрицу к сегментам пути. Дуги при преобразовании,
+// не являющемся подобием (skew, неравномерный scale), заменяются кубическими кривыми.
+func TransformSegments(segments []PathSegment, m Matrix) []PathSegment {
+	out := make([]PathSegment, 0, len(segments))
+	similarity := m.IsSimilarity()
+
+	for _, seg := range segments {
+		if seg.Kind == SegmentArc && !similarity {
+			for _, cubic := range ArcToCubics(seg) {
+				out = append(out, transformSegment(cubic, m))
 			}
+			continue
 		}
+		out = append(out, transformSegment(seg, m))
 	}
+	return out
+}
+
+func transformSegment(seg PathSegment, m Matrix) PathSegment {
+	res := seg
+	res.From = m.Apply(seg.From)
+	res.To = m.Apply(seg.To)
+	res.C1 = m.Apply(seg.C1)
+	res.C2 = m.Apply(seg.C2)
 
-	return points, nil
+	if seg.Kind == SegmentArc {
+		scale := m.ScaleFactor()
+		res.RX = seg.RX * scale
+		res.RY = seg.RY * scale
+		// направление оси эллипса после преобразования
+		phi := seg.Rotation * math.Pi / 180
+		ux := m.A*math.Cos(phi) + m.C*math.Sin(phi)
+		uy := m.B*math.Cos(phi) + m.D*math.Sin(phi)
+		res.Rotation = math.Atan2(uy, ux) * 180 / math.Pi
+		if m.Det() < 0 {
+			res.Sweep = !seg.Sweep
+		}
+	}
+	return res
 }
 
-func parseCoords(s string) []float64 {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return nil
+// ArcToCubics аппроксимирует дугу кубическими кривыми (по одной на каждые ≤90°).
+func ArcToCubics(seg PathSegment) []PathSegment {
+	arc, ok := ArcCenter(seg)
+	if !ok {
+		return []PathSegment{{Kind: SegmentLine, From: seg.From, To: seg.To}}
+	}
+
+	n := int(math.Ceil(math.Abs(arc.Delta) / (math.Pi / 2)))
+	if n < 1 {
+		n = 1
+	}
+	step := arc.Delta / float64(n)
+	k := 4.0 / 3.0 * math.Tan(step/4)
+
+	cosPhi, sinPhi := math.Cos(arc.Phi), math.Sin(arc.Phi)
+	derivative := func(theta float64) models.Point {
+		dx := -arc.RX * math.Sin(theta)
+		dy := arc.RY * math.Cos(theta)
+		return models.Point{X: dx*cosPhi - dy*sinPhi, Y: dx*sinPhi + dy*cosPhi}
+	}
+
+	out := make([]PathSegment, 0, n)
+	from := seg.From
+	for i := 0; i < n; i++ {
+		t1 := arc.Start + step*float64(i)
+		t2 := t1 + step
+		to := arc.PointAt(t2)
+		if i == n-1 {
+			to = seg.To
+		}
+		d1 := derivative(t1)
+		d2 := derivative(t2)
+		out = append(out, PathSegment{
+			Kind: SegmentCubic,
+			From: from,
+			To:   to,
+			C1:   models.Point{X: from.X + k*d1.X, Y: from.Y + k*d1.Y},
+			C2:   models.Point{X: to.X - k*d2.X, Y: to.Y - k*d2.Y},
+		})
+		from = to
 	}
+	return out
+}
 
-	// Разделитель: запятая или пробел
-	s = strings.ReplaceAll(s, ",", " ")
-	parts := strings.Fields(s)
+// FormatPath сериализует сегменты в атрибут d (абсолютные команды).
+func FormatPath(segments []PathSegment) string {
+	var b strings.Builder
+	write := func(cmd string, nums ...float64) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(cmd)
+		for _, n := range nums {
+			b.WriteByte(' ')
+			b.WriteString(formatNumber(n))
+		}
+	}
 
-	var coords []float64
-	for _, part := range parts {
-		val, err := strconv.ParseFloat(part, 64)
-		if err == nil {
-			coords = append(coords, val)
+	for _, seg := range segments {
+		switch seg.Kind {
+		case SegmentMove:
+			write("M", seg.To.X, seg.To.Y)
+		case SegmentLine:
+			write("L", seg.To.X, seg.To.Y)
+		case SegmentCubic:
+			write("C", seg.C1.X, seg.C1.Y, seg.C2.X, seg.C2.Y, seg.To.X, seg.To.Y)
+		case SegmentQuad:
+			write("Q", seg.C1.X, seg.C1.Y, seg.To.X, seg.To.Y)
+		case SegmentArc:
+			write("A", seg.RX, seg.RY, seg.Rotation, boolFlag(seg.LargeArc), boolFlag(seg.Sweep), seg.To.X, seg.To.Y)
+		case SegmentClose:
+			write("Z")
 		}
 	}
+	return b.String()
+}
 
-	return coords
+func boolFlag(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// formatNumber округляет шум плавающей точки, чтобы d оставался читаемым.
+func formatNumber(v float64) string {
+	v = math.Round(v*1e6) / 1e6
+	if v == 0 {
+		v = 0 // убираем -0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
 }
@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"math"
+	"testing"
+
+	"api-gateway/internal/converter/models"
+)
+
+const pathEpsilon = 1e-9
+
+func samePoint(a, b models.Point) bool {
+	return math.Abs(a.X-b.X) <= pathEpsilon && math.Abs(a.Y-b.Y) <= pathEpsilon
+}
+
+// Числа разбираются по грамматике SVG, в том числе в компактной записи без разделителей.
+func TestPathScannerNumbers(t *testing.T) {
+	cases := []struct {
+		src  string
+		want []float64
+	}{
+		{"10 20,30\t40", []float64{10, 20, 30, 40}},
+		{"-0.08.02", []float64{-0.08, 0.02}},
+		{".5.5-.5", []float64{0.5, 0.5, -0.5}},
+		{"10-5+3", []float64{10, -5, 3}},
+		{"1e2.5", []float64{100, 0.5}},
+		{"1.5E-1-2e+1", []float64{0.15, -20}},
+	}
+	for _, tc := range cases {
+		sc := &pathScanner{src: tc.src}
+		var got []float64
+		for sc.skipSeparators(); !sc.eof(); sc.skipSeparators() {
+			v, err := sc.readNumber()
+			if err != nil {
+				t.Fatalf("%q: %v", tc.src, err)
+			}
+			got = append(got, v)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%q: got %v, want %v", tc.src, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tc.want[i]) > pathEpsilon {
+				t.Errorf("%q: got %v, want %v", tc.src, got, tc.want)
+				break
+			}
+		}
+	}
+
+	// флаги дуги — по одному символу: "0110" = 0, 1, 10
+	sc := &pathScanner{src: "0110"}
+	large, err1 := sc.readFlag()
+	sweep, err2 := sc.readFlag()
+	rest, err3 := sc.readNumber()
+	if err1 != nil || err2 != nil || err3 != nil || large || !sweep || rest != 10 {
+		t.Errorf("flags 0110: %v %v %v (%v %v %v)", large, sweep, rest, err1, err2, err3)
+	}
+}
+
+// Сегменты в абсолютных координатах и ломаная для команд без кривых.
+func TestParsePathSegments(t *testing.T) {
+	pt := func(x, y float64) models.Point { return models.Point{X: x, Y: y} }
+	cases := []struct {
+		name  string
+		d     string
+		kinds []SegmentKind
+		ends  []models.Point // To каждого сегмента; это же — ломаная
+	}{
+		{"compact numbers", "M-0.08.02l.5-.5",
+			[]SegmentKind{SegmentMove, SegmentLine},
+			[]models.Point{pt(-0.08, 0.02), pt(0.42, -0.48)}},
+		{"implicit lineto after M", "M0 0 10 0 10 10",
+			[]SegmentKind{SegmentMove, SegmentLine, SegmentLine},
+			[]models.Point{pt(0, 0), pt(10, 0), pt(10, 10)}},
+		{"implicit relative lineto after m", "m1 1 2 0 0 2z",
+			[]SegmentKind{SegmentMove, SegmentLine, SegmentLine, SegmentClose},
+			[]models.Point{pt(1, 1), pt(3, 1), pt(3, 3), pt(1, 1)}},
+		{"repeated h and v", "M0 0h10 5v-3-2",
+			[]SegmentKind{SegmentMove, SegmentLine, SegmentLine, SegmentLine, SegmentLine},
+			[]models.Point{pt(0, 0), pt(10, 0), pt(15, 0), pt(15, -3), pt(15, -5)}},
+		{"relative after close starts at subpath", "M5 5l5 0l0 5zl-5 0",
+			[]SegmentKind{SegmentMove, SegmentLine, SegmentLine, SegmentClose, SegmentLine},
+			[]models.Point{pt(5, 5), pt(10, 5), pt(10, 10), pt(5, 5), pt(0, 5)}},
+	}
+	for _, tc := range cases {
+		segments, err := ParsePathSegments(tc.d)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(segments) != len(tc.kinds) {
+			t.Errorf("%s: %d segments, want %d", tc.name, len(segments), len(tc.kinds))
+			continue
+		}
+		for i, seg := range segments {
+			if seg.Kind != tc.kinds[i] || !samePoint(seg.To, tc.ends[i]) {
+				t.Errorf("%s: segment %d = %v to %v, want %v to %v", tc.name, i, seg.Kind, seg.To, tc.kinds[i], tc.ends[i])
+			}
+		}
+		points := FlattenSegments(segments, DefaultCurveTolerance)
+		if len(points) != len(tc.ends) {
+			t.Errorf("%s: flattened to %v, want %v", tc.name, points, tc.ends)
+			continue
+		}
+		for i := range points {
+			if !samePoint(points[i], tc.ends[i]) {
+				t.Errorf("%s: flattened to %v, want %v", tc.name, points, tc.ends)
+				break
+			}
+		}
+	}
+}
+
+// Дуги: флаги без разделителей, относительная и абсолютная запись дают одну полуокружность
+// радиуса 10 с центром в начале координат, ломаная лежит на ней.
+func TestParsePathArcs(t *testing.T) {
+	cases := []struct {
+		name  string
+		d     string
+		sweep bool
+	}{
+		{"absolute", "M10 0A10 10 0 0 1-10 0", true},
+		{"relative", "M10 0a10 10 0 0 1-20 0", true},
+		{"compact flags", "M10 0a10,10,0,01-20,0", true},
+		{"compact flags and numbers", "M10 0a10 10 0 00-20-0", false},
+		{"implicit repeat", "M10 0a10 10 0 0 1-20 0 10 10 0 0 1 20 0", true},
+	}
+	for _, tc := range cases {
+		segments, err := ParsePathSegments(tc.d)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(segments) < 2 {
+			t.Errorf("%s: %d segments", tc.name, len(segments))
+			continue
+		}
+		arc := segments[1]
+		if arc.Kind != SegmentArc || arc.RX != 10 || arc.RY != 10 || arc.LargeArc || arc.Sweep != tc.sweep ||
+			!samePoint(arc.From, models.Point{X: 10}) || !samePoint(arc.To, models.Point{X: -10}) {
+			t.Errorf("%s: arc %+v", tc.name, arc)
+		}
+
+		points := FlattenSegments(segments, 0.1)
+		if len(points) < 4 {
+			t.Errorf("%s: arc flattened to %d points", tc.name, len(points))
+			continue
+		}
+		for _, p := range points {
+			if r := math.Hypot(p.X, p.Y); math.Abs(r-10) > 1e-6 {
+				t.Errorf("%s: point %v off the circle (r = %g)", tc.name, p, r)
+				break
+			}
+		}
+		// sweep=1 — по возрастанию угла: в системе SVG (ось Y вниз) через (0, 10)
+		half := FlattenSegments(segments[:2], 0.1)
+		apex := half[0]
+		for _, p := range half {
+			if math.Abs(p.Y) > math.Abs(apex.Y) {
+				apex = p
+			}
+		}
+		wantY := 10.0
+		if !tc.sweep {
+			wantY = -10
+		}
+		if math.Abs(apex.Y-wantY) > 0.1 {
+			t.Errorf("%s: arc apex at %v, want y = %g", tc.name, apex, wantY)
+		}
+		if last := half[len(half)-1]; !samePoint(last, models.Point{X: -10}) {
+			t.Errorf("%s: arc ends at %v", tc.name, last)
+		}
+	}
+}
+
+func TestParsePathSegmentsErrors(t *testing.T) {
+	for _, d := range []string{
+		"",
+		"10 10",                  // нет начальной команды
+		"M0 0a10 10 0 2 1 5 5",   // флаг дуги не 0/1
+		"M0 0 L10",               // не хватает координаты
+		"M0 0z5 5",               // числа после Z
+		"M0 0a10 10 0 0 1 5 5 3", // неполный неявный повтор дуги
+	} {
+		if _, err := ParsePathSegments(d); err == nil {
+			t.Errorf("%q: expected error", d)
+		}
+	}
+}
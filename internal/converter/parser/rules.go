@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ============================================================
+// Classification rules
+// ============================================================
+
+// Rule — правило классификации SVG элемента. Все заданные условия должны совпасть,
+// пустые условия игнорируются. Правила проверяются по порядку, побеждает первое.
+type Rule struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`             // wall, door, window, room, balcony, item, column, shaft, stair
+	ID     string            `json:"id,omitempty"`     // regex по id элемента
+	Tag    string            `json:"tag,omitempty"`    // имя элемента: rect, path, polygon...
+	Class  string            `json:"class,omitempty"`  // CSS класс (один из классов элемента)
+	Fill   string            `json:"fill,omitempty"`   // цвет заливки с учетом <style> и наследования
+	Stroke string            `json:"stroke,omitempty"` // цвет обводки
+	Group  string            `json:"group,omitempty"`  // regex по имени любой родительской группы (слоя)
+	Data   map[string]string `json:"data,omitempty"`   // data-* атрибуты (без префикса data-) → regex
+
+	idRe    *regexp.Regexp
+	groupRe *regexp.Regexp
+	dataRe  map[string]*regexp.Regexp
+}
+
+// ruleTypes — типы элементов, которые понимает конвертер.
+var ruleTypes = map[string]bool{
+	"wall": true, "door": true, "window": true, "room": true, "balcony": true,
+	"item": true, "column": true, "shaft": true, "stair": true,
+}
+
+type RuleSet struct {
+	Rules []Rule `json:"rules"`
+}
+
+// ElementInfo — то, что видят правила при классификации элемента.
+type ElementInfo struct {
+	ID      string            `json:"id"`
+	Tag     string            `json:"tag"`
+	Classes []string          `json:"classes,omitempty"`
+	Fill    string            `json:"fill,omitempty"`
+	Stroke  string            `json:"stroke,omitempty"`
+	Groups  []string          `json:"groups,omitempty"` // от ближайшей группы к корню
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// DefaultRules повторяет соглашение об именовании id, принятое в исходных планах.
+func DefaultRules() *RuleSet {
+	rs := &RuleSet{Rules: []Rule{
+		{Name: "wall-prefix", Type: "wall", ID: `^(Hui_)?Wall_`},
+		{Name: "door-prefix", Type: "door", ID: `^Door_`},
+		{Name: "window-prefix", Type: "window", ID: `^Window_`},
+		{Name: "room-prefix", Type: "room", ID: `^Room_|_[Rr]oom$`},
+		{Name: "balcony-prefix", Type: "balcony", ID: `^Balcony`},
+		{Name: "column-prefix", Type: "column", ID: `(?i)^(column|колонна)`},
+		{Name: "shaft-prefix", Type: "shaft", ID: `(?i)^(shaft|vent|шахта)`},
+		{Name: "stair-prefix", Type: "stair", ID: `(?i)^(stair|лестница)`},
+	}}
+	if err := rs.compile(); err != nil {
+		panic(err)
+	}
+	return rs
+}
+
+// LoadRules читает набор правил из JSON файла.
+func LoadRules(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(data)
+}
+
+// ParseRules разбирает JSON вида {"rules": [...]} или просто массив правил.
+func ParseRules(data []byte) (*RuleSet, error) {
+	var rs RuleSet
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &rs.Rules); err != nil {
+			return nil, fmt.Errorf("decode rules: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("decode rules: %w", err)
+	}
+
+	if len(rs.Rules) == 0 {
+		return nil, fmt.Errorf("rule set is empty")
+	}
+	if err := rs.compile(); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// With возвращает новый набор: правила rs, затем extra (с меньшим приоритетом).
+func (rs *RuleSet) With(extra ...Rule) (*RuleSet, error) {
+	out := &RuleSet{}
+	if rs != nil {
+		out.Rules = append(out.Rules, rs.Rules...)
+	}
+	out.Rules = append(out.Rules, extra...)
+	if err := out.compile(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (rs *RuleSet) compile() error {
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		if rule.Type == "" {
+			return fmt.Errorf("rule %d (%s): type required", i, rule.Name)
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if !ruleTypes[rule.Type] {
+			return fmt.Errorf("rule %s: unknown type %q", rule.Name, rule.Type)
+		}
+		// правило без условий (или только с tag) подошло бы любому элементу
+		if rule.ID == "" && rule.Class == "" && rule.Group == "" && len(rule.Data) == 0 &&
+			rule.Fill == "" && rule.Stroke == "" {
+			return fmt.Errorf("rule %s: at least one of id, class, group, data, fill, stroke required", rule.Name)
+		}
+		if rule.ID != "" {
+			re, err := regexp.Compile(rule.ID)
+			if err != nil {
+				return fmt.Errorf("rule %s: id: %w", rule.Name, err)
+			}
+			rule.idRe = re
+		}
+		if rule.Group != "" {
+			re, err := regexp.Compile(rule.Group)
+			if err != nil {
+				return fmt.Errorf("rule %s: group: %w", rule.Name, err)
+			}
+			rule.groupRe = re
+		}
+		if len(rule.Data) > 0 {
+			rule.dataRe = make(map[string]*regexp.Regexp, len(rule.Data))
+			for key, pattern := range rule.Data {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("rule %s: data-%s: %w", rule.Name, key, err)
+				}
+				rule.dataRe[strings.TrimPrefix(key, "data-")] = re
+			}
+		}
+		rule.Fill = NormalizeColor(rule.Fill)
+		rule.Stroke = NormalizeColor(rule.Stroke)
+	}
+	return nil
+}
+
+// Match возвращает первое подходящее правило.
+func (rs *RuleSet) Match(info ElementInfo) (*Rule, bool) {
+	if rs == nil {
+		return nil, false
+	}
+	for i := range rs.Rules {
+		if rs.Rules[i].matches(info) {
+			return &rs.Rules[i], true
+		}
+	}
+	return nil, false
+}
+
+func (r *Rule) matches(info ElementInfo) bool {
+	if r.idRe != nil && !r.idRe.MatchString(info.ID) {
+		return false
+	}
+	if r.Tag != "" && r.Tag != info.Tag {
+		return false
+	}
+	if r.Class != "" && !containsString(info.Classes, r.Class) {
+		return false
+	}
+	if r.Fill != "" && r.Fill != info.Fill {
+		return false
+	}
+	if r.Stroke != "" && r.Stroke != info.Stroke {
+		return false
+	}
+	if r.groupRe != nil {
+		matched := false
+		for _, g := range info.Groups {
+			if r.groupRe.MatchString(g) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for key, re := range r.dataRe {
+		val, ok := info.Data[key]
+		if !ok || !re.MatchString(val) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
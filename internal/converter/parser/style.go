@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ============================================================
+// CSS (<style>) & presentation attributes
+// ============================================================
+
+type cssRule struct {
+	tag   string
+	id    string
+	class string
+	decls map[string]string
+}
+
+// stylesheet — минимальная поддержка CSS из <style>: селекторы tag, .class, #id, tag.class.
+type stylesheet struct {
+	rules []cssRule
+}
+
+func parseStylesheet(text string) *stylesheet {
+	sheet := &stylesheet{}
+	text = stripCSSComments(text)
+
+	for {
+		open := strings.IndexByte(text, '{')
+		if open < 0 {
+			break
+		}
+		closeIdx := strings.IndexByte(text[open:], '}')
+		if closeIdx < 0 {
+			break
+		}
+		closeIdx += open
+
+		selectors := text[:open]
+		decls := parseDeclarations(text[open+1 : closeIdx])
+		text = text[closeIdx+1:]
+
+		for _, sel := range strings.Split(selectors, ",") {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || strings.ContainsAny(sel, " >+~:[") {
+				continue // составные селекторы не поддерживаются
+			}
+			rule := cssRule{decls: decls}
+			switch {
+			case strings.HasPrefix(sel, "#"):
+				rule.id = sel[1:]
+			case strings.Contains(sel, "."):
+				parts := strings.SplitN(sel, ".", 2)
+				rule.tag, rule.class = parts[0], parts[1]
+			default:
+				rule.tag = sel
+			}
+			sheet.rules = append(sheet.rules, rule)
+		}
+	}
+	return sheet
+}
+
+// lookup возвращает значение свойства из CSS правил (последнее подходящее правило побеждает).
+func (s *stylesheet) lookup(tag, id string, classes []string, prop string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	var value string
+	found := false
+	for _, rule := range s.rules {
+		if rule.id != "" && rule.id != id {
+			continue
+		}
+		if rule.tag != "" && rule.tag != tag {
+			continue
+		}
+		if rule.class != "" && !containsString(classes, rule.class) {
+			continue
+		}
+		if v, ok := rule.decls[prop]; ok {
+			value = v
+			found = true
+		}
+	}
+	return value, found
+}
+
+func parseDeclarations(s string) map[string]string {
+	out := make(map[string]string)
+	for _, decl := range strings.Split(s, ";") {
+		parts := strings.SplitN(decl, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(strings.ToLower(parts[0]))
+		val := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(parts[1]), "!important"))
+		if key != "" {
+			out[key] = strings.TrimSpace(val)
+		}
+	}
+	return out
+}
+
+func stripCSSComments(s string) string {
+	for {
+		start := strings.Index(s, "/*")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start+2:], "*/")
+		if end < 0 {
+			return s[:start]
+		}
+		s = s[:start] + s[start+2+end+2:]
+	}
+}
+
+// resolveProperty вычисляет свойство элемента по приоритету SVG:
+// style="" > CSS из <style> > атрибут представления > наследование от родителя.
+func resolveProperty(n *node, sheet *stylesheet, classes []string, prop, inherited string) string {
+	if v, ok := parseDeclarations(n.attr("style"))[prop]; ok && v != "inherit" {
+		return v
+	}
+	if v, ok := sheet.lookup(n.name, n.attr("id"), classes, prop); ok && v != "inherit" {
+		return v
+	}
+	if v := strings.TrimSpace(n.attr(prop)); v != "" && v != "inherit" {
+		return v
+	}
+	return inherited
+}
+
+var namedColors = map[string]string{
+	"black":  "#000000",
+	"white":  "#ffffff",
+	"red":    "#ff0000",
+	"green":  "#008000",
+	"blue":   "#0000ff",
+	"yellow": "#ffff00",
+	"gray":   "#808080",
+	"grey":   "#808080",
+	"orange": "#ffa500",
+}
+
+// NormalizeColor приводит цвет к виду #rrggbb, чтобы "#333", "#333333" и "rgb(51,51,51)" совпадали.
+func NormalizeColor(c string) string {
+	c = strings.ToLower(strings.TrimSpace(c))
+	if c == "" {
+		return ""
+	}
+	if named, ok := namedColors[c]; ok {
+		return named
+	}
+	if strings.HasPrefix(c, "#") && len(c) == 4 {
+		return "#" + strings.Repeat(c[1:2], 2) + strings.Repeat(c[2:3], 2) + strings.Repeat(c[3:4], 2)
+	}
+	if strings.HasPrefix(c, "rgb(") && strings.HasSuffix(c, ")") {
+		parts := strings.Split(c[4:len(c)-1], ",")
+		if len(parts) == 3 {
+			var rgb [3]int
+			for i, p := range parts {
+				v, err := strconv.Atoi(strings.TrimSpace(p))
+				if err != nil || v < 0 || v > 255 {
+					return c
+				}
+				rgb[i] = v
+			}
+			return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+		}
+	}
+	return c
+}
@@ -2,7 +2,9 @@ package parser
 
 import (
 	"encoding/xml"
+	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"api-gateway/internal/converter/models"
@@ -12,96 +14,477 @@ import (
 // XML Structures
 // ============================================================
 
-type SVG struct {
-	XMLName xml.Name `xml:"svg"`
-	Rects   []Rect   `xml:"rect"`
-	Paths   []Path   `xml:"path"`
+// node — универсальный узел SVG-дерева (имя без namespace, атрибуты, потомки).
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	text     string
+	size     int // число узлов поддерева, включая сам узел
 }
 
-type Rect struct {
-	ID     string  `xml:"id,attr"`
-	X      float64 `xml:"x,attr"`
-	Y      float64 `xml:"y,attr"`
-	Width  float64 `xml:"width,attr"`
-	Height float64 `xml:"height,attr"`
+func (n *node) attr(name string) string {
+	return n.attrs[name]
 }
 
-type Path struct {
-	ID string `xml:"id,attr"`
-	D  string `xml:"d,attr"`
+// maxUseDepth ограничивает вложенность <use> (защита от циклических ссылок).
+const maxUseDepth = 16
+
+// maxUseNodes ограничивает число узлов, обходимых через <use> во всем документе: вложенные
+// ссылки раскрываются экспоненциально, и 6 уровней по 10 <use> дают миллион элементов.
+const maxUseNodes = 100000
+
+// skippedElements не рисуются напрямую (defs/symbol доступны только через <use>).
+var skippedElements = map[string]bool{
+	"defs":     true,
+	"symbol":   true,
+	"style":    true,
+	"title":    true,
+	"desc":     true,
+	"metadata": true,
+	"clipPath": true,
+	"mask":     true,
+	"pattern":  true,
+	"marker":   true,
+}
+
+// SkippedElement — элемент, пропущенный вместе с потомками из-за ошибки разбора.
+type SkippedElement struct {
+	ID     string `json:"id,omitempty"`
+	Tag    string `json:"tag"`
+	Reason string `json:"reason"`
 }
 
 // ============================================================
 // Parser
 // ============================================================
 
+// ParseSVG парсит SVG и классифицирует элементы правилами по умолчанию (префиксы id).
 func ParseSVG(r io.Reader) ([]models.SVGElement, error) {
-	var svg SVG
-	decoder := xml.NewDecoder(r)
-	if err := decoder.Decode(&svg); err != nil {
+	return ParseSVGWithRules(r, nil)
+}
+
+// ParseSVGWithRules парсит SVG и классифицирует элементы заданным набором правил
+// (nil — DefaultRules). Элементы, не подошедшие ни под одно правило, отбрасываются.
+func ParseSVGWithRules(r io.Reader, rules *RuleSet) ([]models.SVGElement, error) {
+	doc, err := ParseDocument(r, rules)
+	if err != nil {
 		return nil, err
 	}
+	return doc.Elements, nil
+}
 
-	var elements []models.SVGElement
+// Classification — результат dry-run классификации одного элемента.
+type Classification struct {
+	ElementInfo
+	Rule string `json:"rule,omitempty"`
+	Type string `json:"type,omitempty"`
+}
 
-	// Parse rects
-	for _, rect := range svg.Rects {
-		elemType := classifyElementByID(rect.ID)
-		if elemType == "" {
-			continue
+// ClassifySVG показывает, какое правило сработало для каждого рисуемого элемента,
+// включая неклассифицированные, и какие элементы пропущены из-за ошибок разбора.
+func ClassifySVG(r io.Reader, rules *RuleSet) ([]Classification, []SkippedElement, error) {
+	w, err := walkDocument(r, rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w.classified, w.skipped, nil
+}
+
+func walkDocument(r io.Reader, rules *RuleSet) (*walker, error) {
+	if rules == nil {
+		rules = DefaultRules()
+	}
+
+	root, err := decodeTree(r)
+	if err != nil {
+		return nil, err
+	}
+	if root.name != "svg" {
+		return nil, fmt.Errorf("expected element type <svg> but have <%s>", root.name)
+	}
+
+	w := &walker{
+		root:    root,
+		rules:   rules,
+		byID:    make(map[string]*node),
+		seen:    make(map[string]int),
+		dropped: make(map[string]bool),
+	}
+	var css strings.Builder
+	w.index(root, &css)
+	w.sheet = parseStylesheet(css.String())
+	w.walkChildren(root, walkContext{matrix: Identity()}, 0)
+
+	return w, nil
+}
+
+// decodeTree читает XML целиком в дерево node.
+func decodeTree(r io.Reader) (*node, error) {
+	decoder := xml.NewDecoder(r)
+	var stack []*node
+	var root *node
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
 		}
 
-		elements = append(elements, models.SVGElement{
-			ID:   rect.ID,
-			Type: elemType,
-			Geometry: models.RectGeometry{
-				X:      rect.X,
-				Y:      rect.Y,
-				Width:  rect.Width,
-				Height: rect.Height,
-			},
-		})
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				// xlink:href и href приводятся к одному ключу
+				n.attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("multiple root elements")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
 	}
 
-	// Parse paths
-	for _, path := range svg.Paths {
-		elemType := classifyElementByID(path.ID)
-		if elemType == "" {
-			continue
+	if root == nil {
+		return nil, io.EOF
+	}
+	return root, nil
+}
+
+// ============================================================
+// Tree walker
+// ============================================================
+
+type walker struct {
+	root       *node
+	rules      *RuleSet
+	sheet      *stylesheet
+	byID       map[string]*node
+	seen       map[string]int
+	elements   []models.SVGElement
+	texts      []models.TextLabel
+	classified []Classification
+	skipped    []SkippedElement
+	expanded   int             // узлов, уже развернутых через <use>
+	dropped    map[string]bool // "id#href" ссылок, не развернутых из-за maxUseNodes
+}
+
+// walkContext — состояние, наследуемое вниз по дереву.
+type walkContext struct {
+	matrix Matrix
+	groups []string // имена родительских групп, от ближайшей к корню
+	fill   string
+	stroke string
+}
+
+func (w *walker) index(n *node, css *strings.Builder) {
+	if id := n.attr("id"); id != "" {
+		if _, exists := w.byID[id]; !exists {
+			w.byID[id] = n
+		}
+	}
+	if n.name == "style" {
+		css.WriteString(n.text)
+		css.WriteByte('\n')
+	}
+	n.size = 1
+	for _, child := range n.children {
+		w.index(child, css)
+		n.size += child.size
+	}
+}
+
+func (w *walker) walkChildren(n *node, ctx walkContext, depth int) {
+	for _, child := range n.children {
+		w.walk(child, ctx, "", depth)
+	}
+}
+
+// walk обходит узел, накапливая матрицу трансформации и наследуемые стили.
+// idOverride задает id элемента, на который сослался <use>.
+func (w *walker) walk(n *node, parent walkContext, idOverride string, depth int) {
+	if skippedElements[n.name] || n.attr("display") == "none" {
+		return
+	}
+
+	id := n.attr("id")
+	if idOverride != "" {
+		id = idOverride
+	}
+
+	ctx := parent
+	if raw := n.attr("transform"); raw != "" {
+		local, err := ParseTransform(raw)
+		if err != nil {
+			// без transform положение поддерева неизвестно: пропускаем его и сообщаем об этом
+			w.skipped = append(w.skipped, SkippedElement{ID: id, Tag: n.name, Reason: err.Error()})
+			return
+		}
+		ctx.matrix = parent.matrix.Multiply(local)
+	}
+	classes := strings.Fields(n.attr("class"))
+	ctx.fill = NormalizeColor(resolveProperty(n, w.sheet, classes, "fill", parent.fill))
+	ctx.stroke = NormalizeColor(resolveProperty(n, w.sheet, classes, "stroke", parent.stroke))
+
+	switch n.name {
+	case "g", "a", "switch":
+		ctx.groups = append(groupNames(n), parent.groups...)
+		w.walkChildren(n, ctx, depth)
+	case "svg":
+		// вложенный <svg>: смещение x/y
+		ctx.matrix = ctx.matrix.Multiply(Translate(parseLength(n.attr("x")), parseLength(n.attr("y"))))
+		w.walkChildren(n, ctx, depth)
+	case "use":
+		w.walkUse(n, ctx, id, depth)
+	case "rect", "path", "polygon", "polyline", "line":
+		w.emit(n, ctx, id, classes)
+	case "text":
+		w.emitText(n, ctx, id)
+	}
+}
+
+func (w *walker) walkUse(n *node, ctx walkContext, id string, depth int) {
+	if depth >= maxUseDepth {
+		return
+	}
+	href := strings.TrimPrefix(n.attr("href"), "#")
+	target, ok := w.byID[href]
+	if !ok {
+		return
+	}
+
+	// ссылка разворачивается целиком или не разворачивается вовсе; о каждой паре
+	// (id <use>, цель) сообщается один раз
+	if w.expanded+target.size > maxUseNodes {
+		if key := id + "#" + href; !w.dropped[key] {
+			w.dropped[key] = true
+			w.skipped = append(w.skipped, SkippedElement{ID: id, Tag: n.name,
+				Reason: fmt.Sprintf("use expansion limit of %d elements exceeded, #%s not expanded", maxUseNodes, href)})
+		}
+		return
+	}
+	w.expanded += target.size
+
+	ctx.matrix = ctx.matrix.Multiply(Translate(parseLength(n.attr("x")), parseLength(n.attr("y"))))
+	if target.name == "symbol" {
+		w.walkChildren(target, ctx, depth+1)
+		return
+	}
+	w.walk(target, ctx, id, depth+1)
+}
+
+func (w *walker) emit(n *node, ctx walkContext, id string, classes []string) {
+	info := ElementInfo{
+		ID:      id,
+		Tag:     n.name,
+		Classes: classes,
+		Fill:    ctx.fill,
+		Stroke:  ctx.stroke,
+		Groups:  ctx.groups,
+		Data:    dataAttributes(n),
+	}
+
+	rule, ok := w.rules.Match(info)
+	result := Classification{ElementInfo: info}
+	if ok {
+		result.Rule = rule.Name
+		result.Type = rule.Type
+	}
+	w.classified = append(w.classified, result)
+	if !ok {
+		return
+	}
+
+	geometry, ok := buildGeometry(n, ctx.matrix)
+	if !ok {
+		return
+	}
+
+	if id == "" {
+		id = rule.Type
+	}
+	w.elements = append(w.elements, models.SVGElement{
+		ID:       w.uniqueID(id),
+		Type:     rule.Type,
+		Tag:      n.name,
+		Class:    n.attr("class"),
+		Rule:     rule.Name,
+		Groups:   ctx.groups,
+		Data:     info.Data,
+		Geometry: geometry,
+	})
+}
+
+// emitText сохраняет подпись: текст вместе с <tspan> и точку привязки (x/y текста или первого tspan).
+func (w *walker) emitText(n *node, ctx walkContext, id string) {
+	text := strings.Join(strings.Fields(nodeText(n)), " ")
+	if text == "" {
+		return
+	}
+
+	anchor := n
+	if n.attr("x") == "" && n.attr("y") == "" {
+		for _, child := range n.children {
+			if child.name == "tspan" {
+				anchor = child
+				break
+			}
 		}
+	}
+	p := models.Point{X: firstLength(anchor.attr("x")), Y: firstLength(anchor.attr("y"))}
+
+	w.texts = append(w.texts, models.TextLabel{
+		ID:       id,
+		Text:     text,
+		Position: ctx.matrix.Apply(p),
+		Groups:   ctx.groups,
+	})
+}
+
+// nodeText собирает текст узла и всех потомков.
+func nodeText(n *node) string {
+	var b strings.Builder
+	b.WriteString(n.text)
+	for _, child := range n.children {
+		b.WriteByte(' ')
+		b.WriteString(nodeText(child))
+	}
+	return b.String()
+}
 
-		elements = append(elements, models.SVGElement{
-			ID:   path.ID,
-			Type: elemType,
-			Geometry: models.PathGeometry{
-				D: path.D,
-			},
-		})
+// firstLength читает первое значение списка координат (x="10 20 30" у <text>).
+func firstLength(s string) float64 {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return 0
 	}
+	return parseLength(fields[0])
+}
 
-	return elements, nil
+// groupNames — имена группы для правил: id, data-name (Illustrator), inkscape:label.
+func groupNames(n *node) []string {
+	var names []string
+	for _, key := range []string{"id", "data-name", "label"} {
+		if v := n.attr(key); v != "" && !containsString(names, v) {
+			names = append(names, v)
+		}
+	}
+	return names
 }
 
-func classifyElementByID(id string) string {
-	if strings.HasPrefix(id, "Wall_") {
-		return "wall"
+func dataAttributes(n *node) map[string]string {
+	var out map[string]string
+	for key, val := range n.attrs {
+		if !strings.HasPrefix(key, "data-") {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[strings.TrimPrefix(key, "data-")] = val
 	}
-	if strings.HasPrefix(id, "Hui_Wall_") { // новые префиксы стен
-		return "wall"
+	return out
+}
+
+// uniqueID добавляет суффикс, если один и тот же id встретился несколько раз (например, через <use>).
+func (w *walker) uniqueID(id string) string {
+	w.seen[id]++
+	if w.seen[id] == 1 {
+		return id
 	}
-	if strings.HasPrefix(id, "Door_") {
-		return "door"
+	return fmt.Sprintf("%s_%d", id, w.seen[id])
+}
+
+// ============================================================
+// Geometry
+// ============================================================
+
+// buildGeometry переводит элемент в мировые координаты. Прямоугольник остается
+// RectGeometry, пока преобразование сохраняет оси, иначе становится полигоном.
+func buildGeometry(n *node, m Matrix) (interface{}, bool) {
+	switch n.name {
+	case "rect":
+		x, y := parseLength(n.attr("x")), parseLength(n.attr("y"))
+		width, height := parseLength(n.attr("width")), parseLength(n.attr("height"))
+		geometry, err := TransformGeometry(models.RectGeometry{X: x, Y: y, Width: width, Height: height}, m)
+		return geometry, err == nil
+
+	case "path":
+		d := n.attr("d")
+		if m.IsIdentity() {
+			return models.PathGeometry{D: d}, true
+		}
+		segments, err := ParsePathSegments(d)
+		if err != nil {
+			return nil, false
+		}
+		return models.PathGeometry{D: FormatPath(TransformSegments(segments, m))}, true
+
+	case "polygon", "polyline":
+		coords, err := parseNumberList(n.attr("points"))
+		if err != nil || len(coords) < 4 {
+			return nil, false
+		}
+		points := make([]models.Point, 0, len(coords)/2)
+		for i := 0; i+1 < len(coords); i += 2 {
+			points = append(points, m.Apply(models.Point{X: coords[i], Y: coords[i+1]}))
+		}
+		return models.PolygonGeometry{Points: points, Closed: n.name == "polygon"}, true
+
+	case "line":
+		p1 := m.Apply(models.Point{X: parseLength(n.attr("x1")), Y: parseLength(n.attr("y1"))})
+		p2 := m.Apply(models.Point{X: parseLength(n.attr("x2")), Y: parseLength(n.attr("y2"))})
+		return models.PolygonGeometry{Points: []models.Point{p1, p2}, Closed: false}, true
 	}
-	if strings.HasPrefix(id, "Window_") {
-		return "window"
+	return nil, false
+}
+
+func rectFromCorners(corners []models.Point) models.RectGeometry {
+	minX, maxX := corners[0].X, corners[0].X
+	minY, maxY := corners[0].Y, corners[0].Y
+	for _, p := range corners[1:] {
+		if p.X < minX {
+			minX = p.X
+		}
+		if p.X > maxX {
+			maxX = p.X
+		}
+		if p.Y < minY {
+			minY = p.Y
+		}
+		if p.Y > maxY {
+			maxY = p.Y
+		}
 	}
-	if strings.HasPrefix(id, "Room_") ||
-		strings.HasSuffix(id, "_room") || // Hall_room, Toilet_room
-		strings.HasSuffix(id, "_Room") {
-		return "room"
+	return models.RectGeometry{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// parseLength читает числовой атрибут, отбрасывая суффикс px. Некорректное значение = 0.
+func parseLength(s string) float64 {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "px"))
+	if s == "" {
+		return 0
 	}
-	if strings.HasPrefix(id, "Balcony_") || strings.HasPrefix(id, "Balcony") {
-		return "balcony"
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
 	}
-	return ""
+	return v
 }
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Вложенные <use> раскрываются экспоненциально: 6 уровней по 10 ссылок — миллион стен.
+// Разбор должен остановиться на бюджете и перечислить неразвернутые ссылки в Skipped.
+func TestUseExpansionBudget(t *testing.T) {
+	const levels, fanout = 6, 10
+	var svg strings.Builder
+	svg.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><defs>`)
+	svg.WriteString(`<rect id="Wall_0" width="10" height="1" />`)
+	for level := 1; level <= levels; level++ {
+		fmt.Fprintf(&svg, `<g id="level%d">`, level)
+		for i := 0; i < fanout; i++ {
+			ref := fmt.Sprintf("level%d", level-1)
+			if level == 1 {
+				ref = "Wall_0"
+			}
+			fmt.Fprintf(&svg, `<use xlink:href="#%s" x="%d" />`, ref, i)
+		}
+		svg.WriteString(`</g>`)
+	}
+	fmt.Fprintf(&svg, `</defs><use id="plan" href="#level%d" /><use id="Wall_1" href="#Wall_0" /></svg>`, levels)
+
+	doc, err := ParseDocument(strings.NewReader(svg.String()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Elements) == 0 || len(doc.Elements) > maxUseNodes {
+		t.Errorf("got %d elements, want 1..%d", len(doc.Elements), maxUseNodes)
+	}
+	if len(doc.Skipped) == 0 {
+		t.Fatal("dropped <use> references not reported")
+	}
+	seen := make(map[SkippedElement]bool)
+	for _, skipped := range doc.Skipped {
+		if skipped.Tag != "use" || seen[skipped] {
+			t.Errorf("unexpected skipped entry %+v", skipped)
+		}
+		seen[skipped] = true
+	}
+	if !seen[SkippedElement{ID: "Wall_1", Tag: "use", Reason: fmt.Sprintf("use expansion limit of %d elements exceeded, #Wall_0 not expanded", maxUseNodes)}] {
+		t.Errorf("top-level <use> Wall_1 not reported: %+v", doc.Skipped)
+	}
+
+	// небольшой документ с <use> разворачивается полностью
+	small := `<svg xmlns="http://www.w3.org/2000/svg"><defs><g id="pair"><rect id="Wall_a" width="10" height="1" />` +
+		`<rect id="Wall_b" y="5" width="10" height="1" /></g></defs><use href="#pair" /><use href="#pair" x="20" /></svg>`
+	doc, err = ParseDocument(strings.NewReader(small), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Elements) != 4 || len(doc.Skipped) != 0 {
+		t.Errorf("small document: %d elements, skipped %+v; want 4 and none", len(doc.Elements), doc.Skipped)
+	}
+}
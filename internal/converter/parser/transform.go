@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Affine transforms
+// ============================================================
+
+// Matrix — аффинное преобразование SVG вида matrix(a b c d e f):
+//
+//	x' = A*x + C*y + E
+//	y' = B*x + D*y + F
+type Matrix struct {
+	A, B, C, D, E, F float64
+}
+
+func Identity() Matrix {
+	return Matrix{A: 1, D: 1}
+}
+
+func Translate(tx, ty float64) Matrix {
+	return Matrix{A: 1, D: 1, E: tx, F: ty}
+}
+
+func Scale(sx, sy float64) Matrix {
+	return Matrix{A: sx, D: sy}
+}
+
+// Rotate возвращает поворот на angleDeg градусов вокруг начала координат.
+func Rotate(angleDeg float64) Matrix {
+	rad := angleDeg * math.Pi / 180
+	cos, sin := math.Cos(rad), math.Sin(rad)
+	return Matrix{A: cos, B: sin, C: -sin, D: cos}
+}
+
+// Multiply возвращает m × n: сначала применяется n, затем m.
+func (m Matrix) Multiply(n Matrix) Matrix {
+	return Matrix{
+		A: m.A*n.A + m.C*n.B,
+		B: m.B*n.A + m.D*n.B,
+		C: m.A*n.C + m.C*n.D,
+		D: m.B*n.C + m.D*n.D,
+		E: m.A*n.E + m.C*n.F + m.E,
+		F: m.B*n.E + m.D*n.F + m.F,
+	}
+}
+
+func (m Matrix) Apply(p models.Point) models.Point {
+	return models.Point{
+		X: m.A*p.X + m.C*p.Y + m.E,
+		Y: m.B*p.X + m.D*p.Y + m.F,
+	}
+}
+
+func (m Matrix) Det() float64 {
+	return m.A*m.D - m.B*m.C
+}
+
+// Invert возвращает обратную матрицу; false, если матрица вырождена.
+func (m Matrix) Invert() (Matrix, bool) {
+	det := m.Det()
+	if math.Abs(det) < 1e-12 {
+		return Matrix{}, false
+	}
+	return Matrix{
+		A: m.D / det,
+		B: -m.B / det,
+		C: -m.C / det,
+		D: m.A / det,
+		E: (m.C*m.F - m.D*m.E) / det,
+		F: (m.B*m.E - m.A*m.F) / det,
+	}, true
+}
+
+func (m Matrix) IsIdentity() bool {
+	return m == Identity()
+}
+
+// IsAxisAligned — true, если прямоугольники со сторонами по осям остаются такими же.
+func (m Matrix) IsAxisAligned() bool {
+	return (m.B == 0 && m.C == 0) || (m.A == 0 && m.D == 0)
+}
+
+// IsSimilarity — поворот/отражение + равномерный масштаб + перенос (окружности остаются окружностями).
+func (m Matrix) IsSimilarity() bool {
+	const eps = 1e-9
+	sx := m.A*m.A + m.B*m.B
+	sy := m.C*m.C + m.D*m.D
+	return math.Abs(sx-sy) <= eps*math.Max(1, sx) && math.Abs(m.A*m.C+m.B*m.D) <= eps*math.Max(1, sx)
+}
+
+// ScaleFactor — средний линейный масштаб матрицы (sqrt(|det|)).
+func (m Matrix) ScaleFactor() float64 {
+	return math.Sqrt(math.Abs(m.Det()))
+}
+
+func (m Matrix) String() string {
+	return fmt.Sprintf("matrix(%s %s %s %s %s %s)",
+		formatNumber(m.A), formatNumber(m.B), formatNumber(m.C),
+		formatNumber(m.D), formatNumber(m.E), formatNumber(m.F))
+}
+
+// ParseTransform разбирает атрибут transform: matrix, translate, scale, rotate, skewX, skewY.
+// Список преобразований применяется справа налево, как в SVG.
+func ParseTransform(s string) (Matrix, error) {
+	result := Identity()
+	s = strings.TrimSpace(s)
+
+	for s != "" {
+		open := strings.IndexByte(s, '(')
+		if open < 0 {
+			return Identity(), fmt.Errorf("invalid transform %q", s)
+		}
+		closeIdx := strings.IndexByte(s[open:], ')')
+		if closeIdx < 0 {
+			return Identity(), fmt.Errorf("unclosed transform %q", s)
+		}
+		closeIdx += open
+
+		name := strings.TrimSpace(s[:open])
+		args, err := parseNumberList(s[open+1 : closeIdx])
+		if err != nil {
+			return Identity(), fmt.Errorf("transform %s: %w", name, err)
+		}
+
+		var m Matrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				return Identity(), fmt.Errorf("matrix expects 6 arguments, got %d", len(args))
+			}
+			m = Matrix{A: args[0], B: args[1], C: args[2], D: args[3], E: args[4], F: args[5]}
+		case "translate":
+			switch len(args) {
+			case 1:
+				m = Translate(args[0], 0)
+			case 2:
+				m = Translate(args[0], args[1])
+			default:
+				return Identity(), fmt.Errorf("translate expects 1 or 2 arguments, got %d", len(args))
+			}
+		case "scale":
+			switch len(args) {
+			case 1:
+				m = Scale(args[0], args[0])
+			case 2:
+				m = Scale(args[0], args[1])
+			default:
+				return Identity(), fmt.Errorf("scale expects 1 or 2 arguments, got %d", len(args))
+			}
+		case "rotate":
+			switch len(args) {
+			case 1:
+				m = Rotate(args[0])
+			case 3:
+				m = Translate(args[1], args[2]).Multiply(Rotate(args[0])).Multiply(Translate(-args[1], -args[2]))
+			default:
+				return Identity(), fmt.Errorf("rotate expects 1 or 3 arguments, got %d", len(args))
+			}
+		case "skewX":
+			if len(args) != 1 {
+				return Identity(), fmt.Errorf("skewX expects 1 argument, got %d", len(args))
+			}
+			m = Matrix{A: 1, C: math.Tan(args[0] * math.Pi / 180), D: 1}
+		case "skewY":
+			if len(args) != 1 {
+				return Identity(), fmt.Errorf("skewY expects 1 argument, got %d", len(args))
+			}
+			m = Matrix{A: 1, B: math.Tan(args[0] * math.Pi / 180), D: 1}
+		default:
+			return Identity(), fmt.Errorf("unknown transform %q", name)
+		}
+
+		result = result.Multiply(m)
+		s = strings.TrimLeft(s[closeIdx+1:], " \t\n\r,")
+	}
+
+	return result, nil
+}
+
+// parseNumberList разбирает список чисел через пробелы/запятые (points, viewBox, аргументы transform).
+func parseNumberList(s string) ([]float64, error) {
+	sc := &pathScanner{src: s}
+	var out []float64
+	for {
+		sc.skipSeparators()
+		if sc.eof() {
+			return out, nil
+		}
+		v, err := sc.readNumber()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+}
@@ -0,0 +1,479 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+
+	"golang.org/x/image/vector"
+)
+
+// ============================================================
+// Canvas
+// ============================================================
+
+// curveTolerance — допуск аппроксимации кривых в пикселях.
+const curveTolerance = 0.2
+
+// maxPatternDepth ограничивает вложенность штриховок (защита от циклических ссылок).
+const maxPatternDepth = 4
+
+// canvas рисует узлы SVG в изображение. Контуры переводятся в пиксели матрицей состояния
+// и заливаются vector.Rasterizer в пределах своего bounding box.
+type canvas struct {
+	img    *image.RGBA
+	byID   map[string]*node
+	fonts  *fontCache
+	raster *vector.Rasterizer
+	depth  int
+}
+
+// contour — ломаная в пикселях; closed — замкнута командой Z или по смыслу элемента.
+type contour struct {
+	points []models.Point
+	closed bool
+}
+
+func newCanvas(img *image.RGBA, root *node) *canvas {
+	c := &canvas{img: img, byID: make(map[string]*node), fonts: newFontCache(), raster: vector.NewRasterizer(0, 0)}
+	c.index(root)
+	return c
+}
+
+func (c *canvas) index(n *node) {
+	if id := n.attr("id"); id != "" {
+		if _, exists := c.byID[id]; !exists {
+			c.byID[id] = n
+		}
+	}
+	for _, child := range n.children {
+		c.index(child)
+	}
+}
+
+func (c *canvas) drawChildren(n *node, st state) {
+	for _, child := range n.children {
+		c.draw(child, st)
+	}
+}
+
+// draw рисует узел и его потомков.
+func (c *canvas) draw(n *node, parent state) {
+	if n.attr("display") == "none" || n.attr("visibility") == "hidden" {
+		return
+	}
+	st, ok := parent.inherit(n)
+	if !ok {
+		return
+	}
+
+	switch n.name {
+	case "g", "a":
+		c.drawChildren(n, st)
+	case "svg":
+		c.drawViewport(n, st)
+	case "rect", "path", "polygon", "polyline", "line", "circle", "ellipse":
+		contours := shapeContours(n, st.m)
+		c.fill(contours, st)
+		c.stroke(contours, st)
+	case "text":
+		c.drawText(n, st)
+	}
+}
+
+// drawViewport — вложенный <svg>: область x, y, width, height и ее viewBox.
+func (c *canvas) drawViewport(n *node, st state) {
+	x, _ := number(n.attr("x"))
+	y, _ := number(n.attr("y"))
+	viewBox, hasViewBox := parseViewBox(n.attr("viewBox"))
+	width, okW := number(n.attr("width"))
+	height, okH := number(n.attr("height"))
+	if !okW || !okH {
+		if !hasViewBox {
+			st.m = st.m.Multiply(parser.Translate(x, y))
+			c.drawChildren(n, st)
+			return
+		}
+		width, height = viewBox[2], viewBox[3]
+	}
+	if width <= 0 || height <= 0 {
+		return
+	}
+	if hasViewBox {
+		st.m = st.m.Multiply(viewBoxMatrix(x, y, width, height, viewBox))
+	} else {
+		st.m = st.m.Multiply(parser.Translate(x, y))
+	}
+	c.drawChildren(n, st)
+}
+
+// ============================================================
+// Shapes
+// ============================================================
+
+// shapeContours строит контуры фигуры в пикселях.
+func shapeContours(n *node, m parser.Matrix) []contour {
+	switch n.name {
+	case "rect":
+		x, _ := number(n.attr("x"))
+		y, _ := number(n.attr("y"))
+		w, _ := number(n.attr("width"))
+		h, _ := number(n.attr("height"))
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		return []contour{transformContour([]models.Point{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}}, true, m)}
+	case "line":
+		x1, _ := number(n.attr("x1"))
+		y1, _ := number(n.attr("y1"))
+		x2, _ := number(n.attr("x2"))
+		y2, _ := number(n.attr("y2"))
+		return []contour{transformContour([]models.Point{{X: x1, Y: y1}, {X: x2, Y: y2}}, false, m)}
+	case "polygon", "polyline":
+		coords := numberList(n.attr("points"))
+		points := make([]models.Point, 0, len(coords)/2)
+		for i := 0; i+1 < len(coords); i += 2 {
+			points = append(points, models.Point{X: coords[i], Y: coords[i+1]})
+		}
+		if len(points) < 2 {
+			return nil
+		}
+		return []contour{transformContour(points, n.name == "polygon", m)}
+	case "circle", "ellipse":
+		cx, _ := number(n.attr("cx"))
+		cy, _ := number(n.attr("cy"))
+		rx, _ := number(n.attr("r"))
+		ry := rx
+		if n.name == "ellipse" {
+			rx, _ = number(n.attr("rx"))
+			ry, _ = number(n.attr("ry"))
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		return pathContours([]parser.PathSegment{
+			{Kind: parser.SegmentMove, To: models.Point{X: cx + rx, Y: cy}},
+			{Kind: parser.SegmentArc, From: models.Point{X: cx + rx, Y: cy}, To: models.Point{X: cx - rx, Y: cy}, RX: rx, RY: ry, Sweep: true},
+			{Kind: parser.SegmentArc, From: models.Point{X: cx - rx, Y: cy}, To: models.Point{X: cx + rx, Y: cy}, RX: rx, RY: ry, Sweep: true},
+			{Kind: parser.SegmentClose, From: models.Point{X: cx + rx, Y: cy}, To: models.Point{X: cx + rx, Y: cy}},
+		}, m)
+	case "path":
+		segments, err := parser.ParsePathSegments(n.attr("d"))
+		if err != nil {
+			return nil
+		}
+		return pathContours(segments, m)
+	}
+	return nil
+}
+
+func transformContour(points []models.Point, closed bool, m parser.Matrix) contour {
+	out := make([]models.Point, len(points))
+	for i, p := range points {
+		out[i] = m.Apply(p)
+	}
+	return contour{points: out, closed: closed}
+}
+
+// pathContours переводит сегменты в пиксели и делит их на подпути по командам M.
+func pathContours(segments []parser.PathSegment, m parser.Matrix) []contour {
+	segments = parser.TransformSegments(segments, m)
+	var contours []contour
+	start := 0
+	for i := 1; i <= len(segments); i++ {
+		if i < len(segments) && segments[i].Kind != parser.SegmentMove {
+			continue
+		}
+		part := segments[start:i]
+		start = i
+		points := parser.FlattenSegments(part, curveTolerance)
+		if len(points) < 2 {
+			continue
+		}
+		closed := part[len(part)-1].Kind == parser.SegmentClose
+		if closed {
+			// Z повторяет первую точку
+			points = points[:len(points)-1]
+		}
+		contours = append(contours, contour{points: points, closed: closed})
+	}
+	return contours
+}
+
+// ============================================================
+// Fill & stroke
+// ============================================================
+
+func (c *canvas) fill(contours []contour, st state) {
+	src, ok := c.paint(st.fill, st.m)
+	if !ok {
+		return
+	}
+	var polygons [][]models.Point
+	for _, ct := range contours {
+		if len(ct.points) >= 3 {
+			polygons = append(polygons, ct.points)
+		}
+	}
+	c.rasterize(polygons, src)
+}
+
+// stroke обводит контуры: каждый отрезок — четырехугольник, стыки — круги; все фигуры
+// обходятся в одном направлении, чтобы перекрытия не вычитались.
+func (c *canvas) stroke(contours []contour, st state) {
+	if st.strokeWidth <= 0 {
+		return
+	}
+	src, ok := c.paint(st.stroke, st.m)
+	if !ok {
+		return
+	}
+	scale := st.m.ScaleFactor()
+	half := st.strokeWidth * scale / 2
+	if half <= 0 {
+		return
+	}
+	dash := make([]float64, len(st.dash))
+	for i, d := range st.dash {
+		dash[i] = d * scale
+	}
+
+	var polygons [][]models.Point
+	for _, ct := range contours {
+		points := ct.points
+		if ct.closed && len(points) > 1 {
+			points = append(append([]models.Point(nil), points...), points[0])
+		}
+		for _, line := range dashPolyline(points, dash) {
+			polygons = append(polygons, strokePolyline(line, half, ct.closed && len(dash) == 0)...)
+		}
+	}
+	c.rasterize(polygons, src)
+}
+
+// strokePolyline — четырехугольники отрезков и круги в промежуточных вершинах
+// (и в начальной у замкнутого контура без пунктира).
+func strokePolyline(points []models.Point, half float64, closed bool) [][]models.Point {
+	var out [][]models.Point
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		length := math.Hypot(dx, dy)
+		if length < 1e-9 {
+			continue
+		}
+		nx, ny := -dy/length*half, dx/length*half
+		out = append(out, []models.Point{
+			{X: a.X + nx, Y: a.Y + ny},
+			{X: b.X + nx, Y: b.Y + ny},
+			{X: b.X - nx, Y: b.Y - ny},
+			{X: a.X - nx, Y: a.Y - ny},
+		})
+	}
+	if half < 0.75 {
+		// у тонких линий стыки не видны
+		return out
+	}
+	for i := 1; i+1 < len(points); i++ {
+		out = append(out, disc(points[i], half))
+	}
+	if closed && len(points) > 2 {
+		out = append(out, disc(points[0], half))
+	}
+	return out
+}
+
+// disc — круг, обходимый в ту же сторону, что и четырехугольники strokePolyline.
+func disc(center models.Point, radius float64) []models.Point {
+	steps := int(math.Min(64, math.Max(8, math.Ceil(radius*2))))
+	out := make([]models.Point, steps)
+	for i := range out {
+		angle := -2 * math.Pi * float64(i) / float64(steps)
+		out[i] = models.Point{X: center.X + radius*math.Cos(angle), Y: center.Y + radius*math.Sin(angle)}
+	}
+	return out
+}
+
+// dashPolyline режет ломаную по stroke-dasharray (в пикселях); пустой dash — без пунктира.
+func dashPolyline(points []models.Point, dash []float64) [][]models.Point {
+	if len(points) < 2 {
+		return nil
+	}
+	if len(dash) == 0 {
+		return [][]models.Point{points}
+	}
+
+	var out [][]models.Point
+	current := []models.Point{points[0]}
+	index, left, on := 0, dash[0], true
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		pos := 0.0
+		for length-pos > left {
+			pos += left
+			t := pos / length
+			p := models.Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+			if on {
+				out = append(out, append(current, p))
+				current = nil
+			} else {
+				current = []models.Point{p}
+			}
+			on = !on
+			index = (index + 1) % len(dash)
+			left = dash[index]
+		}
+		left -= length - pos
+		if on {
+			current = append(current, b)
+		}
+	}
+	if on && len(current) > 1 {
+		out = append(out, current)
+	}
+	return out
+}
+
+// rasterize заливает многоугольники (в пикселях) источником src по правилу nonzero.
+func (c *canvas) rasterize(polygons [][]models.Point, src image.Image) {
+	if len(polygons) == 0 {
+		return
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, polygon := range polygons {
+		for _, p := range polygon {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+	}
+	bounds := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).
+		Intersect(c.img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+
+	c.raster.Reset(bounds.Dx(), bounds.Dy())
+	ox, oy := float64(bounds.Min.X), float64(bounds.Min.Y)
+	for _, polygon := range polygons {
+		c.raster.MoveTo(float32(polygon[0].X-ox), float32(polygon[0].Y-oy))
+		for _, p := range polygon[1:] {
+			c.raster.LineTo(float32(p.X-ox), float32(p.Y-oy))
+		}
+		c.raster.ClosePath()
+	}
+	c.raster.Draw(c.img, bounds, src, bounds.Min)
+}
+
+// ============================================================
+// Paint
+// ============================================================
+
+// paint — источник цвета для fill/stroke: однотонный или штриховка url(#id).
+func (c *canvas) paint(value string, m parser.Matrix) (image.Image, bool) {
+	if id, ok := paintURL(value); ok {
+		return c.pattern(id, m)
+	}
+	col, ok := parseColor(value)
+	if !ok {
+		return nil, false
+	}
+	return image.NewUniform(col), true
+}
+
+func paintURL(value string) (string, bool) {
+	if len(value) < 6 || value[:4] != "url(" {
+		return "", false
+	}
+	end := len(value) - 1
+	for end > 0 && value[end] != ')' {
+		end--
+	}
+	id := value[4:end]
+	if len(id) < 2 || id[0] != '#' {
+		return "", false
+	}
+	return id[1:], true
+}
+
+// pattern рисует плитку <pattern patternUnits="userSpaceOnUse"> в разрешении холста
+// и возвращает бесконечно повторяющийся источник.
+func (c *canvas) pattern(id string, m parser.Matrix) (image.Image, bool) {
+	n, ok := c.byID[id]
+	if !ok || n.name != "pattern" || c.depth >= maxPatternDepth {
+		return nil, false
+	}
+	w, okW := number(n.attr("width"))
+	h, okH := number(n.attr("height"))
+	if !okW || !okH || w <= 0 || h <= 0 {
+		return nil, false
+	}
+	x, _ := number(n.attr("x"))
+	y, _ := number(n.attr("y"))
+	space := m
+	if raw := n.attr("patternTransform"); raw != "" {
+		local, err := parser.ParseTransform(raw)
+		if err != nil {
+			return nil, false
+		}
+		space = space.Multiply(local)
+	}
+	space = space.Multiply(parser.Translate(x, y))
+
+	scale := space.ScaleFactor()
+	tw := int(math.Max(1, math.Round(w*scale)))
+	th := int(math.Max(1, math.Round(h*scale)))
+	if tw*th > 1<<20 {
+		return nil, false
+	}
+	toTile := parser.Scale(float64(tw)/w, float64(th)/h)
+	inv, ok := toTile.Multiply(space).Invert()
+	if !ok {
+		return nil, false
+	}
+
+	tile := image.NewRGBA(image.Rect(0, 0, tw, th))
+	sub := &canvas{img: tile, byID: c.byID, fonts: c.fonts, raster: vector.NewRasterizer(0, 0), depth: c.depth + 1}
+	st, _ := defaultState(parser.Identity()).inherit(&node{attrs: map[string]string{
+		"fill": n.attr("fill"), "stroke": n.attr("stroke"), "stroke-width": n.attr("stroke-width"),
+	}})
+	// соседние копии дорисовывают обводку, выходящую за край плитки
+	for i := -1; i <= 1; i++ {
+		for j := -1; j <= 1; j++ {
+			st.m = toTile.Multiply(parser.Translate(float64(i)*w, float64(j)*h))
+			sub.drawChildren(n, st)
+		}
+	}
+	return &patternImage{tile: tile, inv: inv}, true
+}
+
+// patternImage — плитка, повторенная по всей плоскости: пиксель холста переводится
+// обратной матрицей в координаты плитки и берется по модулю ее размера.
+type patternImage struct {
+	tile *image.RGBA
+	inv  parser.Matrix
+}
+
+func (p *patternImage) ColorModel() color.Model { return color.RGBAModel }
+
+func (p *patternImage) Bounds() image.Rectangle {
+	return image.Rect(-1<<30, -1<<30, 1<<30, 1<<30)
+}
+
+func (p *patternImage) At(x, y int) color.Color {
+	q := p.inv.Apply(models.Point{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+	size := p.tile.Bounds().Size()
+	u := int(math.Floor(q.X)) % size.X
+	v := int(math.Floor(q.Y)) % size.Y
+	if u < 0 {
+		u += size.X
+	}
+	if v < 0 {
+		v += size.Y
+	}
+	return p.tile.RGBAAt(u, v)
+}
@@ -0,0 +1,386 @@
+package raster
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+)
+
+// ============================================================
+// SVG Rasterizer
+// ============================================================
+
+// DefaultDPI — разрешение, при котором 1px SVG соответствует одному пикселю PNG.
+const DefaultDPI = 96
+
+// MaxDPI ограничивает разрешение сверху.
+const MaxDPI = 1200
+
+// DefaultMaxPixels — ограничение на площадь изображения по умолчанию (~ 8000×6000).
+const DefaultMaxPixels = 48_000_000
+
+var (
+	// ErrInvalidSVG возвращается для документа, который не удалось разобрать.
+	ErrInvalidSVG = errors.New("invalid svg")
+	// ErrTooLarge возвращается, если изображение при заданном DPI превышает MaxPixels.
+	ErrTooLarge = errors.New("image too large")
+)
+
+// Options — параметры растеризации.
+type Options struct {
+	// DPI — разрешение: размер <svg> в px умножается на DPI/96. 0 — DefaultDPI.
+	DPI float64
+	// Background — цвет подложки; пусто или "none" — прозрачная.
+	Background string
+	// MaxPixels — ограничение на число пикселей; 0 — DefaultMaxPixels.
+	MaxPixels int
+	// Region — фрагмент листа x, y, ширина, высота в px листа; нулевой — весь лист.
+	Region [4]float64
+}
+
+// Rasterize рисует SVG в изображение. Поддерживается подмножество SVG, которое выводит
+// mapper.Renderer: вложенные <svg> с viewBox, <g> с transform, rect/path/polygon/polyline/line/
+// circle/ellipse, <text> (text-anchor, dominant-baseline, font-size), заливка и обводка цветом
+// или штриховкой <pattern>, stroke-width и stroke-dasharray. Заливка — по правилу nonzero,
+// концы линий — butt, стыки — round.
+func Rasterize(r io.Reader, opts Options) (*image.RGBA, error) {
+	root, err := decodeTree(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSVG, err)
+	}
+	if root.name != "svg" {
+		return nil, fmt.Errorf("%w: root element is <%s>", ErrInvalidSVG, root.name)
+	}
+
+	dpi := opts.DPI
+	if dpi <= 0 {
+		dpi = DefaultDPI
+	}
+	if dpi > MaxDPI {
+		return nil, fmt.Errorf("%w: dpi %s exceeds %d", ErrTooLarge, formatFloat(dpi), MaxDPI)
+	}
+	maxPixels := opts.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
+
+	sheet, width, height, err := sheetMatrix(root)
+	if err != nil {
+		return nil, err
+	}
+	if region := opts.Region; region[2] > 0 && region[3] > 0 {
+		sheet = parser.Translate(-region[0], -region[1]).Multiply(sheet)
+		width, height = region[2], region[3]
+	}
+	w := int(math.Ceil(width * dpi / DefaultDPI))
+	h := int(math.Ceil(height * dpi / DefaultDPI))
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("%w: empty canvas", ErrInvalidSVG)
+	}
+	if float64(w)*float64(h) > float64(maxPixels) {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrTooLarge, w, h, maxPixels)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	if bg, ok := parseColor(opts.Background); ok {
+		draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	}
+
+	m := parser.Scale(float64(w)/width, float64(h)/height).Multiply(sheet)
+
+	// transform корневого <svg> не применяется, свойства оформления наследуются
+	st, _ := defaultState(m).inherit(&node{attrs: root.attrs})
+	st.m = m
+	newCanvas(img, root).drawChildren(root, st)
+	return img, nil
+}
+
+// SheetRegion переводит прямоугольник box (minX, minY, width, height) из координат viewBox
+// корневого <svg> в px листа — координаты Options.Region — и возвращает размер листа в px.
+func SheetRegion(r io.Reader, box [4]float64) ([4]float64, [2]float64, error) {
+	root, err := decodeTree(r)
+	if err != nil {
+		return [4]float64{}, [2]float64{}, fmt.Errorf("%w: %v", ErrInvalidSVG, err)
+	}
+	if root.name != "svg" {
+		return [4]float64{}, [2]float64{}, fmt.Errorf("%w: root element is <%s>", ErrInvalidSVG, root.name)
+	}
+	sheet, width, height, err := sheetMatrix(root)
+	if err != nil {
+		return [4]float64{}, [2]float64{}, err
+	}
+	// viewBox вписывается без поворота: достаточно двух углов
+	a := sheet.Apply(models.Point{X: box[0], Y: box[1]})
+	b := sheet.Apply(models.Point{X: box[0] + box[2], Y: box[1] + box[3]})
+	x0, y0 := math.Min(a.X, b.X), math.Min(a.Y, b.Y)
+	return [4]float64{x0, y0, math.Abs(b.X - a.X), math.Abs(b.Y - a.Y)}, [2]float64{width, height}, nil
+}
+
+// sheetMatrix переводит координаты корневого <svg> в px листа и возвращает размер листа:
+// viewBox вписывается в width × height.
+func sheetMatrix(root *node) (parser.Matrix, float64, float64, error) {
+	viewBox, hasViewBox := parseViewBox(root.attr("viewBox"))
+	width, okW := pixelLength(root.attr("width"))
+	height, okH := pixelLength(root.attr("height"))
+	if !okW || !okH {
+		if !hasViewBox {
+			return parser.Matrix{}, 0, 0, fmt.Errorf("%w: width, height or viewBox required", ErrInvalidSVG)
+		}
+		width, height = viewBox[2], viewBox[3]
+	}
+	if !hasViewBox {
+		return parser.Identity(), width, height, nil
+	}
+	return viewBoxMatrix(0, 0, width, height, viewBox), width, height, nil
+}
+
+// EncodePNG растеризует SVG и кодирует результат в PNG.
+func EncodePNG(svg string, opts Options) ([]byte, error) {
+	img, err := Rasterize(strings.NewReader(svg), opts)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ============================================================
+// XML tree
+// ============================================================
+
+// node — узел SVG-дерева: имя без namespace, атрибуты (вместе с объявлениями style) и текст.
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	text     string
+}
+
+func (n *node) attr(name string) string {
+	return n.attrs[name]
+}
+
+// decodeTree читает XML целиком в дерево node.
+func decodeTree(r io.Reader) (*node, error) {
+	decoder := xml.NewDecoder(r)
+	var stack []*node
+	var root *node
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			// объявления style="fill: ...; stroke: ..." перекрывают атрибуты
+			for _, decl := range strings.Split(n.attrs["style"], ";") {
+				if key, value, ok := strings.Cut(decl, ":"); ok {
+					n.attrs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+				}
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("multiple root elements")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, io.EOF
+	}
+	return root, nil
+}
+
+// ============================================================
+// Inherited state
+// ============================================================
+
+// state — матрица пользовательских единиц в пиксели и наследуемые свойства оформления.
+type state struct {
+	m           parser.Matrix
+	fill        string
+	stroke      string
+	strokeWidth float64
+	dash        []float64
+	fontSize    float64
+	anchor      string
+	baseline    string
+}
+
+func defaultState(m parser.Matrix) state {
+	return state{m: m, fill: "#000", stroke: "none", strokeWidth: 1, fontSize: 16}
+}
+
+// inherit применяет transform и свойства оформления узла поверх родительских.
+func (s state) inherit(n *node) (state, bool) {
+	if raw := n.attr("transform"); raw != "" {
+		local, err := parser.ParseTransform(raw)
+		if err != nil {
+			return s, false
+		}
+		s.m = s.m.Multiply(local)
+	}
+	if v := n.attr("fill"); v != "" {
+		s.fill = v
+	}
+	if v := n.attr("stroke"); v != "" {
+		s.stroke = v
+	}
+	if v, ok := number(n.attr("stroke-width")); ok && v >= 0 {
+		s.strokeWidth = v
+	}
+	if v := n.attr("stroke-dasharray"); v != "" {
+		s.dash = parseDash(v)
+	}
+	if v, ok := number(n.attr("font-size")); ok && v > 0 {
+		s.fontSize = v
+	}
+	if v := n.attr("text-anchor"); v != "" {
+		s.anchor = v
+	}
+	if v := n.attr("dominant-baseline"); v != "" {
+		s.baseline = v
+	}
+	return s, true
+}
+
+// ============================================================
+// Attribute helpers
+// ============================================================
+
+// number читает число с необязательным суффиксом px.
+func number(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "px"))
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// numberList разбирает список чисел через пробелы и запятые (points, viewBox, dasharray).
+func numberList(s string) []float64 {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	out := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// parseDash — stroke-dasharray; нечетный список повторяется дважды, "none" и нули отключают пунктир.
+func parseDash(s string) []float64 {
+	dash := numberList(s)
+	total := 0.0
+	for _, d := range dash {
+		if d < 0 {
+			return nil
+		}
+		total += d
+	}
+	if total <= 0 {
+		return nil
+	}
+	if len(dash)%2 == 1 {
+		dash = append(dash, dash...)
+	}
+	return dash
+}
+
+// pixelLength переводит ширину/высоту корневого <svg> в CSS-пиксели; проценты не поддерживаются.
+func pixelLength(s string) (float64, bool) {
+	length, err := parser.ParseLength(s)
+	if err != nil || length.Value <= 0 {
+		return 0, false
+	}
+	if length.Unit == "" || length.Unit == "px" {
+		return length.Value, true
+	}
+	cm, ok := parser.CentimetersPerUnit(length.Unit)
+	if !ok {
+		return 0, false
+	}
+	px, _ := parser.CentimetersPerUnit("px")
+	return length.Value * cm / px, true
+}
+
+func parseViewBox(s string) ([4]float64, bool) {
+	values := numberList(s)
+	if len(values) != 4 || values[2] <= 0 || values[3] <= 0 {
+		return [4]float64{}, false
+	}
+	return [4]float64{values[0], values[1], values[2], values[3]}, true
+}
+
+// viewBoxMatrix вписывает viewBox в область x, y, width, height с сохранением пропорций
+// (preserveAspectRatio по умолчанию: xMidYMid meet).
+func viewBoxMatrix(x, y, width, height float64, viewBox [4]float64) parser.Matrix {
+	scale := math.Min(width/viewBox[2], height/viewBox[3])
+	tx := x + (width-viewBox[2]*scale)/2 - viewBox[0]*scale
+	ty := y + (height-viewBox[3]*scale)/2 - viewBox[1]*scale
+	return parser.Translate(tx, ty).Multiply(parser.Scale(scale, scale))
+}
+
+// parseColor разбирает цвет (#rgb, #rrggbb, rgb(), имена из parser); "none" — нет цвета.
+func parseColor(s string) (color.RGBA, bool) {
+	c := parser.NormalizeColor(s)
+	if len(c) != 7 || c[0] != '#' {
+		return color.RGBA{}, false
+	}
+	v, err := strconv.ParseUint(c[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, true
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,151 @@
+package raster
+
+import (
+	"image"
+	"math"
+	"strings"
+	"sync"
+
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/parser"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/f64"
+	"golang.org/x/image/math/fixed"
+)
+
+// ============================================================
+// Text
+// ============================================================
+
+// maxFontPixels ограничивает размер глифов в пикселях.
+const maxFontPixels = 1024
+
+// Go Regular покрывает латиницу и кириллицу; font-family не учитывается.
+var (
+	regularOnce sync.Once
+	regularFont *opentype.Font
+	regularErr  error
+)
+
+func loadRegular() (*opentype.Font, error) {
+	regularOnce.Do(func() {
+		regularFont, regularErr = opentype.Parse(goregular.TTF)
+	})
+	return regularFont, regularErr
+}
+
+// fontCache — начертания по размеру в четвертях пикселя (font.Face не потокобезопасен,
+// поэтому кэш живет в пределах одной растеризации).
+type fontCache struct {
+	faces map[int]font.Face
+}
+
+func newFontCache() *fontCache {
+	return &fontCache{faces: make(map[int]font.Face)}
+}
+
+func (f *fontCache) face(size float64) (font.Face, bool) {
+	key := int(math.Round(size * 4))
+	if face, ok := f.faces[key]; ok {
+		return face, true
+	}
+	regular, err := loadRegular()
+	if err != nil {
+		return nil, false
+	}
+	face, err := opentype.NewFace(regular, &opentype.FaceOptions{Size: float64(key) / 4, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, false
+	}
+	f.faces[key] = face
+	return face, true
+}
+
+// drawText рисует строку в пиксельном размере во временное изображение и переносит
+// его на холст матрицей текста, так что повернутые подписи остаются повернутыми.
+func (c *canvas) drawText(n *node, st state) {
+	text := strings.Join(strings.Fields(n.text), " ")
+	if text == "" {
+		return
+	}
+	col, ok := parseColor(st.fill)
+	if !ok {
+		return
+	}
+	scale := st.m.ScaleFactor()
+	size := st.fontSize * scale
+	if size < 1 || scale <= 0 {
+		return
+	}
+	size = math.Min(size, maxFontPixels)
+	face, ok := c.fonts.face(size)
+	if !ok {
+		return
+	}
+
+	metrics := face.Metrics()
+	ascent, descent := fixedFloat(metrics.Ascent), fixedFloat(metrics.Descent)
+	advance := fixedFloat(font.MeasureString(face, text))
+	tmp := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(advance))+2, int(math.Ceil(ascent+descent))+2))
+	drawer := font.Drawer{
+		Dst:  tmp,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.Point26_6{X: fixed.I(1), Y: metrics.Ascent + fixed.I(1)},
+	}
+	drawer.DrawString(text)
+
+	// смещение от точки привязки до верхнего левого угла временного изображения, в пикселях
+	dx := -1.0
+	switch st.anchor {
+	case "middle":
+		dx -= advance / 2
+	case "end":
+		dx -= advance
+	}
+	dy := -1 - ascent
+	switch st.baseline {
+	case "middle", "central":
+		dy += (ascent - descent) / 2
+	case "hanging", "text-before-edge":
+		dy += ascent
+	case "text-after-edge", "ideographic":
+		dy -= descent
+	}
+
+	x, _ := number(n.attr("x"))
+	y, _ := number(n.attr("y"))
+	m := st.m.
+		Multiply(parser.Translate(x, y)).
+		Multiply(parser.Scale(1/scale, 1/scale)).
+		Multiply(parser.Translate(dx, dy))
+	if !onCanvas(m, tmp.Bounds(), c.img.Bounds()) {
+		return
+	}
+	aff := f64.Aff3{m.A, m.C, m.E, m.B, m.D, m.F}
+	draw.BiLinear.Transform(c.img, aff, tmp, tmp.Bounds(), draw.Over, nil)
+}
+
+// onCanvas — пересекает ли образ прямоугольника r холст.
+func onCanvas(m parser.Matrix, r, canvas image.Rectangle) bool {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range []models.Point{
+		{X: float64(r.Min.X), Y: float64(r.Min.Y)}, {X: float64(r.Max.X), Y: float64(r.Min.Y)},
+		{X: float64(r.Max.X), Y: float64(r.Max.Y)}, {X: float64(r.Min.X), Y: float64(r.Max.Y)},
+	} {
+		p := m.Apply(corner)
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	return maxX >= float64(canvas.Min.X) && minX <= float64(canvas.Max.X) &&
+		maxY >= float64(canvas.Min.Y) && minY <= float64(canvas.Max.Y)
+}
+
+func fixedFloat(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"api-gateway/internal/converter/diff"
+)
+
+// ============================================================
+// Change descriptions
+// ============================================================
+
+// describeChanges — изменения сцены по-русски: стены, проемы, помещения. Длины, сдвиги и
+// изменения размеров в отчете diff — в м (изменения толщины и ширины выводятся в см),
+// ширина проема в after — в единицах сцены.
+func describeChanges(report *diff.Report) []string {
+	unit := unitLabel(report.Unit)
+	var out []string
+
+	for _, c := range report.Walls {
+		name := c.Name
+		if name == "" {
+			name = c.ID
+		}
+		switch c.Change {
+		case diff.ChangeAdded:
+			out = append(out, fmt.Sprintf("Возведена стена %s длиной %s м", name, meters(segmentLength(c.After))))
+		case diff.ChangeRemoved:
+			out = append(out, fmt.Sprintf("Демонтирована стена %s длиной %s м", name, meters(segmentLength(c.Before))))
+		case diff.ChangeMoved:
+			out = append(out, fmt.Sprintf("Стена %s перенесена на %s м", name, meters(c.Shift)))
+		case diff.ChangeResized:
+			var parts []string
+			if c.LengthDelta != 0 {
+				parts = append(parts, fmt.Sprintf("длина изменена на %s м", signed(c.LengthDelta, 2)))
+			}
+			if c.ThicknessDelta != 0 {
+				parts = append(parts, fmt.Sprintf("толщина изменена на %s %s", signed(c.ThicknessDelta*100, 0), "см"))
+			}
+			out = append(out, fmt.Sprintf("Стена %s: %s", name, strings.Join(parts, ", ")))
+		}
+	}
+
+	for _, c := range report.Holes {
+		kind := holeKind(c.Kind)
+		switch c.Change {
+		case diff.ChangeAdded:
+			text := fmt.Sprintf("Устроен %s %s", kind, c.ID)
+			if c.After != nil && c.After.Width > 0 {
+				text += fmt.Sprintf(" шириной %s %s", number(c.After.Width, 0), unit)
+			}
+			out = append(out, text)
+		case diff.ChangeRemoved:
+			out = append(out, fmt.Sprintf("Заложен %s %s", kind, c.ID))
+		case diff.ChangeMoved:
+			out = append(out, fmt.Sprintf("%s %s смещен на %s м", capitalize(kind), c.ID, meters(c.Shift)))
+		case diff.ChangeResized:
+			out = append(out, fmt.Sprintf("%s %s: ширина изменена на %s %s", capitalize(kind), c.ID, signed(c.WidthDelta*100, 0), "см"))
+		}
+	}
+
+	for _, c := range report.Rooms {
+		name := c.Name
+		if name == "" {
+			name = c.ID
+		}
+		switch c.Change {
+		case diff.ChangeAdded:
+			out = append(out, fmt.Sprintf("Образовано помещение %s площадью %s м²", name, number(c.AreaAfter, 2)))
+		case diff.ChangeRemoved:
+			out = append(out, fmt.Sprintf("Упразднено помещение %s площадью %s м²", name, number(c.AreaBefore, 2)))
+		case diff.ChangeResized:
+			out = append(out, fmt.Sprintf("Площадь помещения %s изменена: %s → %s м² (%s)",
+				name, number(c.AreaBefore, 2), number(c.AreaAfter, 2), signed(c.AreaDelta, 2)))
+		case diff.ChangeRenamed:
+			out = append(out, fmt.Sprintf("Помещение %s переименовано в %s", c.NameBefore, name))
+		case diff.ChangeMerged:
+			out = append(out, fmt.Sprintf("Помещения %s объединены в %s площадью %s м²",
+				strings.Join(c.From, ", "), name, number(c.AreaAfter, 2)))
+		case diff.ChangeSplit:
+			out = append(out, fmt.Sprintf("Помещение %s разделено на %s", name, strings.Join(c.Into, ", ")))
+		}
+	}
+	return out
+}
+
+func segmentLength(s *diff.Segment) float64 {
+	if s == nil {
+		return 0
+	}
+	return s.Length
+}
+
+func holeKind(kind string) string {
+	switch kind {
+	case "door":
+		return "дверной проем"
+	case "window":
+		return "оконный проем"
+	}
+	return "проем"
+}
+
+// unitLabel — русское обозначение единицы сцены.
+func unitLabel(unit string) string {
+	switch unit {
+	case "", "cm":
+		return "см"
+	case "mm":
+		return "мм"
+	case "m":
+		return "м"
+	case "in":
+		return "дюйм"
+	case "ft":
+		return "фут"
+	}
+	return unit
+}
+
+func meters(v float64) string {
+	return number(v, 2)
+}
+
+func number(v float64, precision int) string {
+	return fmt.Sprintf("%.*f", precision, v)
+}
+
+func signed(v float64, precision int) string {
+	return fmt.Sprintf("%+.*f", precision, v)
+}
+
+func capitalize(s string) string {
+	for i, r := range s {
+		return strings.ToUpper(string(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
@@ -0,0 +1,152 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// ============================================================
+// Embedded TrueType fonts
+// ============================================================
+
+// pdfFont — TrueType шрифт, встроенный целиком как CIDFontType2 с кодировкой Identity-H:
+// текст кодируется номерами глифов, ToUnicode восстанавливает символы при копировании.
+type pdfFont struct {
+	name   string // имя в ресурсах страницы: F1, F2...
+	base   string // PostScript имя
+	data   []byte
+	font   *sfnt.Font
+	buf    sfnt.Buffer
+	upem   float64
+	id     int
+	glyphs map[rune]sfnt.GlyphIndex
+	widths map[sfnt.GlyphIndex]float64 // ширины в 1/1000 em
+	runes  map[sfnt.GlyphIndex]rune
+}
+
+func newPDFFont(d *pdfDocument, base string, data []byte) (*pdfFont, error) {
+	parsed, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse font %s: %w", base, err)
+	}
+	f := &pdfFont{
+		name:   fmt.Sprintf("F%d", len(d.fonts)+1),
+		base:   base,
+		data:   data,
+		font:   parsed,
+		upem:   float64(parsed.UnitsPerEm()),
+		id:     d.reserve(),
+		glyphs: make(map[rune]sfnt.GlyphIndex),
+		widths: make(map[sfnt.GlyphIndex]float64),
+		runes:  make(map[sfnt.GlyphIndex]rune),
+	}
+	d.fonts = append(d.fonts, f)
+	return f, nil
+}
+
+// glyph — номер глифа символа и его ширина в 1/1000 em; отсутствующий символ — глиф 0.
+func (f *pdfFont) glyph(r rune) (sfnt.GlyphIndex, float64) {
+	gid, ok := f.glyphs[r]
+	if !ok {
+		gid, _ = f.font.GlyphIndex(&f.buf, r)
+		f.glyphs[r] = gid
+	}
+	width, ok := f.widths[gid]
+	if !ok {
+		advance, err := f.font.GlyphAdvance(&f.buf, gid, fixed.I(int(f.upem)), font.HintingNone)
+		if err == nil {
+			width = float64(advance) / 64 * 1000 / f.upem
+		}
+		f.widths[gid] = width
+	}
+	return gid, width
+}
+
+// measure — ширина строки в pt при размере size.
+func (f *pdfFont) measure(s string, size float64) float64 {
+	total := 0.0
+	for _, r := range s {
+		_, width := f.glyph(r)
+		total += width
+	}
+	return total * size / 1000
+}
+
+// encode — строка как шестнадцатеричные номера глифов; запоминает символы для ToUnicode.
+func (f *pdfFont) encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		gid, _ := f.glyph(r)
+		if _, ok := f.runes[gid]; !ok && gid != 0 {
+			f.runes[gid] = r
+		}
+		fmt.Fprintf(&b, "%04X", uint16(gid))
+	}
+	return b.String()
+}
+
+// write добавляет объекты шрифта: Type0, CIDFont, дескриптор, файл шрифта и ToUnicode.
+func (f *pdfFont) write(d *pdfDocument) {
+	ppem := fixed.I(int(f.upem))
+	scale := func(v fixed.Int26_6) int { return int(float64(v) / 64 * 1000 / f.upem) }
+	bounds, _ := f.font.Bounds(&f.buf, ppem, font.HintingNone)
+	metrics, _ := f.font.Metrics(&f.buf, ppem, font.HintingNone)
+
+	file := d.addStream(fmt.Sprintf("/Length1 %d ", len(f.data)), f.data)
+	descriptor := d.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.base, scale(bounds.Min.X), -scale(bounds.Max.Y), scale(bounds.Max.X), -scale(bounds.Min.Y),
+		scale(metrics.Ascent), -scale(metrics.Descent), scale(metrics.CapHeight), file))
+
+	gids := make([]int, 0, len(f.widths))
+	for gid := range f.widths {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, int(math.Round(f.widths[sfnt.GlyphIndex(gid)])))
+	}
+	cid := d.add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>", f.base, descriptor, widths.String()))
+
+	toUnicode := d.addStream("", []byte(f.cmap(gids)))
+	d.set(f.id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+		"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", f.base, cid, toUnicode))
+}
+
+// cmap — ToUnicode CMap для использованных глифов (не больше 100 записей в блоке bfchar).
+func (f *pdfFont) cmap(gids []int) string {
+	var entries []string
+	for _, gid := range gids {
+		r, ok := f.runes[sfnt.GlyphIndex(gid)]
+		if !ok {
+			continue
+		}
+		var unicode strings.Builder
+		for _, u := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&unicode, "%04X", u)
+		}
+		entries = append(entries, fmt.Sprintf("<%04X> <%s>", gid, unicode.String()))
+	}
+
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(entries); start += 100 {
+		end := min(start+100, len(entries))
+		fmt.Fprintf(&b, "%d beginbfchar\n%s\nendbfchar\n", end-start, strings.Join(entries[start:end], "\n"))
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
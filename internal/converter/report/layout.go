@@ -0,0 +1,245 @@
+package report
+
+import (
+	"image/color"
+	"strings"
+)
+
+// ============================================================
+// Page layout
+// ============================================================
+
+var (
+	colorText   = color.RGBA{R: 0x22, G: 0x22, B: 0x22, A: 0xff}
+	colorMuted  = color.RGBA{R: 0x66, G: 0x66, B: 0x66, A: 0xff}
+	colorRule   = color.RGBA{A: 0xff}
+	colorLight  = color.RGBA{R: 0xcc, G: 0xcc, B: 0xcc, A: 0xff}
+	colorError  = color.RGBA{R: 0xb0, A: 0xff}
+	colorShaded = color.RGBA{R: 0xf4, G: 0xf4, B: 0xf4, A: 0xff}
+)
+
+// layout выводит текст сверху вниз, переходя на новую страницу, когда блок не помещается.
+// y — верхний край следующей строки, pt от верха листа.
+type layout struct {
+	doc     *pdfDocument
+	regular *pdfFont
+	bold    *pdfFont
+	size    float64 // основной кегль
+	page    *pdfPage
+	y       float64
+	left    float64
+	right   float64
+	top     float64
+	bottom  float64
+	closed  bool // страница занята разделом на отдельном листе
+}
+
+func (l *layout) width() float64 {
+	return l.right - l.left
+}
+
+func (l *layout) leading(size float64) float64 {
+	return size * 1.4
+}
+
+func (l *layout) newPage() {
+	l.page = l.doc.addPage()
+	l.y = l.top
+	l.closed = false
+}
+
+// flow начинает раздел, который продолжает текущую страницу.
+func (l *layout) flow() {
+	if l.page == nil || l.closed {
+		l.newPage()
+	}
+}
+
+// ensure переходит на новую страницу, если блок высотой h не помещается.
+func (l *layout) ensure(h float64) {
+	if l.page == nil || l.y+h > l.bottom {
+		l.newPage()
+	}
+}
+
+// heading — заголовок раздела с линией; не отрывается от первых строк раздела.
+func (l *layout) heading(text string) {
+	size := l.size + 2
+	if l.y > l.top {
+		l.y += l.size
+	}
+	l.ensure(l.leading(size) + 3*l.leading(l.size))
+	l.page.text(l.bold, size, l.left, l.y+size, colorRule, text)
+	l.y += l.leading(size) + 2
+	l.page.line(l.left, l.y, l.right, l.y, 1.5, colorRule)
+	l.y += l.size * 0.8
+}
+
+// paragraph выводит текст с переносом по словам; indent — отступ слева.
+func (l *layout) paragraph(f *pdfFont, size float64, c color.RGBA, indent float64, text string) {
+	for _, line := range wrap(f, size, l.width()-indent, text) {
+		l.ensure(l.leading(size))
+		l.page.text(f, size, l.left+indent, l.y+size, c, line)
+		l.y += l.leading(size)
+	}
+}
+
+// bullet — пункт списка с маркером.
+func (l *layout) bullet(c color.RGBA, text string) {
+	const indent = 16
+	lines := wrap(l.regular, l.size, l.width()-indent, text)
+	for i, line := range lines {
+		l.ensure(l.leading(l.size))
+		if i == 0 {
+			l.page.text(l.regular, l.size, l.left+4, l.y+l.size, c, "•")
+		}
+		l.page.text(l.regular, l.size, l.left+indent, l.y+l.size, c, line)
+		l.y += l.leading(l.size)
+	}
+	l.y += l.size * 0.25
+}
+
+// field — строка «подпись: значение» с подчеркиванием.
+func (l *layout) field(label, value string, labelWidth float64) {
+	lines := wrap(l.regular, l.size, l.width()-labelWidth, value)
+	l.ensure(l.leading(l.size) * float64(len(lines)))
+	l.page.text(l.bold, l.size, l.left, l.y+l.size, colorRule, label)
+	for _, line := range lines {
+		l.page.text(l.regular, l.size, l.left+labelWidth, l.y+l.size, colorText, line)
+		l.y += l.leading(l.size)
+	}
+	l.page.line(l.left, l.y, l.right, l.y, 0.5, colorLight)
+	l.y += l.size * 0.5
+}
+
+// textRight выводит строку, выровненную по правому полю.
+func (l *layout) textRight(f *pdfFont, size float64, c color.RGBA, text string) {
+	l.ensure(l.leading(size))
+	l.page.text(f, size, l.right-f.measure(text, size), l.y+size, c, text)
+	l.y += l.leading(size)
+}
+
+// textCenter выводит строки по центру с переносом.
+func (l *layout) textCenter(f *pdfFont, size float64, c color.RGBA, text string) {
+	for _, line := range wrap(f, size, l.width(), text) {
+		l.ensure(l.leading(size))
+		l.page.text(f, size, l.left+(l.width()-f.measure(line, size))/2, l.y+size, c, line)
+		l.y += l.leading(size)
+	}
+}
+
+// ============================================================
+// Tables
+// ============================================================
+
+// tableColumn — столбец таблицы: заголовок, доля ширины, выравнивание вправо (числа).
+type tableColumn struct {
+	title string
+	share float64
+	right bool
+}
+
+// table выводит таблицу; заголовок повторяется на каждой странице. Строки из totals
+// выделяются полужирным и фоном.
+func (l *layout) table(columns []tableColumn, rows [][]string, totals int) {
+	size := l.size - 1
+	pad := 4.0
+	widths := make([]float64, len(columns))
+	total := 0.0
+	for _, c := range columns {
+		total += c.share
+	}
+	for i, c := range columns {
+		widths[i] = l.width() * c.share / total
+	}
+
+	cellLines := func(cells []string, f *pdfFont) ([][]string, float64) {
+		lines := make([][]string, len(cells))
+		height := 1
+		for i, cell := range cells {
+			lines[i] = wrap(f, size, widths[i]-2*pad, cell)
+			height = max(height, len(lines[i]))
+		}
+		return lines, float64(height)*l.leading(size) + pad
+	}
+	row := func(lines [][]string, h float64, f *pdfFont, shaded bool) {
+		if shaded {
+			l.page.rect(l.left, l.y, l.width(), h, &colorShaded, colorShaded, 0)
+		}
+		x := l.left
+		for i, cell := range lines {
+			for j, line := range cell {
+				tx := x + pad
+				if columns[i].right {
+					tx = x + widths[i] - pad - f.measure(line, size)
+				}
+				l.page.text(f, size, tx, l.y+pad/2+float64(j)*l.leading(size)+size, colorText, line)
+			}
+			x += widths[i]
+		}
+		l.y += h
+		l.page.line(l.left, l.y, l.right, l.y, 0.5, colorLight)
+	}
+
+	titles := make([]string, len(columns))
+	for i, c := range columns {
+		titles[i] = c.title
+	}
+	headerLines, headerHeight := cellLines(titles, l.bold)
+	header := func() {
+		row(headerLines, headerHeight, l.bold, false)
+		l.page.line(l.left, l.y, l.right, l.y, 1, colorRule)
+	}
+
+	// строка, не помещающаяся на странице, переносится вместе с заголовком таблицы
+	l.ensure(headerHeight + l.leading(size) + pad)
+	header()
+	for i, cells := range rows {
+		isTotal := i >= len(rows)-totals
+		f := l.regular
+		if isTotal {
+			f = l.bold
+		}
+		lines, h := cellLines(cells, f)
+		if l.y+h > l.bottom {
+			l.newPage()
+			header()
+		}
+		row(lines, h, f, isTotal)
+	}
+}
+
+// ============================================================
+// Text wrapping
+// ============================================================
+
+// wrap делит текст на строки не шире width по пробелам; слишком длинное слово режется по символам.
+func wrap(f *pdfFont, size, width float64, text string) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if f.measure(candidate, size) <= width {
+				current = candidate
+				continue
+			}
+			if current != "" {
+				lines = append(lines, current)
+			}
+			current = ""
+			for _, r := range word {
+				if current != "" && f.measure(current+string(r), size) > width {
+					lines = append(lines, current)
+					current = ""
+				}
+				current += string(r)
+			}
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,216 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ============================================================
+// PDF Writer
+// ============================================================
+
+// pdfDocument — минимальный писатель PDF 1.4: страницы с текстом, линиями, прямоугольниками
+// и растровыми изображениями. Все страницы используют общий словарь ресурсов.
+type pdfDocument struct {
+	objects [][]byte // тела объектов; номер объекта = индекс + 1
+	pages   []*pdfPage
+	fonts   []*pdfFont
+	images  []int // номера объектов изображений Im1, Im2...
+	width   float64
+	height  float64
+	title   string
+	created time.Time
+}
+
+// pdfPage — страница; координаты методов — в pt от верхнего левого угла.
+type pdfPage struct {
+	doc     *pdfDocument
+	content bytes.Buffer
+}
+
+const (
+	catalogObject = 1
+	pagesObject   = 2
+)
+
+func newPDFDocument(width, height float64) *pdfDocument {
+	d := &pdfDocument{width: width, height: height, created: time.Now()}
+	d.reserve() // каталог
+	d.reserve() // дерево страниц
+	return d
+}
+
+func (d *pdfDocument) reserve() int {
+	d.objects = append(d.objects, nil)
+	return len(d.objects)
+}
+
+func (d *pdfDocument) set(id int, body string) {
+	d.objects[id-1] = []byte(body)
+}
+
+func (d *pdfDocument) add(body string) int {
+	id := d.reserve()
+	d.set(id, body)
+	return id
+}
+
+// addStream добавляет поток, сжатый FlateDecode; dict — дополнительные ключи словаря.
+func (d *pdfDocument) addStream(dict string, data []byte) int {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return d.add(fmt.Sprintf("<< %s/Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", dict, buf.Len(), buf.Bytes()))
+}
+
+func (d *pdfDocument) addPage() *pdfPage {
+	p := &pdfPage{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// addImage добавляет изображение (RGB поверх белого) и возвращает его имя в ресурсах.
+func (d *pdfDocument) addImage(img image.Image) string {
+	b := img.Bounds()
+	data := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			a := uint32(c.A)
+			blend := func(v uint8) byte { return byte((uint32(v)*a + 255*(255-a)) / 255) }
+			data = append(data, blend(c.R), blend(c.G), blend(c.B))
+		}
+	}
+	id := d.addStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 ",
+		b.Dx(), b.Dy()), data)
+	d.images = append(d.images, id)
+	return "Im" + strconv.Itoa(len(d.images))
+}
+
+// bytes собирает документ: шрифты, страницы, каталог, таблицу xref.
+func (d *pdfDocument) bytes() []byte {
+	for _, f := range d.fonts {
+		f.write(d)
+	}
+
+	var resources bytes.Buffer
+	resources.WriteString("<< /Font <<")
+	for _, f := range d.fonts {
+		fmt.Fprintf(&resources, " /%s %d 0 R", f.name, f.id)
+	}
+	resources.WriteString(" >> /XObject <<")
+	for i, id := range d.images {
+		fmt.Fprintf(&resources, " /Im%d %d 0 R", i+1, id)
+	}
+	resources.WriteString(" >> >>")
+
+	kids := make([]string, 0, len(d.pages))
+	for _, p := range d.pages {
+		content := d.addStream("", p.content.Bytes())
+		id := d.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pagesObject, pdfNumber(d.width), pdfNumber(d.height), resources.String(), content))
+		kids = append(kids, strconv.Itoa(id)+" 0 R")
+	}
+	d.set(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	d.set(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+	info := d.add(fmt.Sprintf("<< /Title %s /Producer (api-gateway report) /CreationDate (D:%s) >>",
+		pdfText(d.title), d.created.UTC().Format("20060102150405Z")))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(d.objects))
+	for i, body := range d.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(d.objects)+1, catalogObject, info, xref)
+	return out.Bytes()
+}
+
+// ============================================================
+// Drawing
+// ============================================================
+
+// text выводит строку; y — базовая линия.
+func (p *pdfPage) text(f *pdfFont, size, x, y float64, c color.RGBA, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT %s rg /%s %s Tf %s %s Td <%s> Tj ET\n",
+		pdfColor(c), f.name, pdfNumber(size), pdfNumber(x), pdfNumber(p.doc.height-y), f.encode(s))
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2, width float64, c color.RGBA) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n", pdfColor(c), pdfNumber(width),
+		pdfNumber(x1), pdfNumber(p.doc.height-y1), pdfNumber(x2), pdfNumber(p.doc.height-y2))
+}
+
+// rect рисует прямоугольник: заливка, если fill != nil, и обводка, если width > 0.
+func (p *pdfPage) rect(x, y, w, h float64, fill *color.RGBA, stroke color.RGBA, width float64) {
+	op := ""
+	switch {
+	case fill != nil && width > 0:
+		op = "B"
+	case fill != nil:
+		op = "f"
+	case width > 0:
+		op = "S"
+	default:
+		return
+	}
+	if fill != nil {
+		fmt.Fprintf(&p.content, "%s rg ", pdfColor(*fill))
+	}
+	if width > 0 {
+		fmt.Fprintf(&p.content, "%s RG %s w ", pdfColor(stroke), pdfNumber(width))
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n",
+		pdfNumber(x), pdfNumber(p.doc.height-y-h), pdfNumber(w), pdfNumber(h), op)
+}
+
+// image выводит изображение из ресурсов в прямоугольник x, y, w, h.
+func (p *pdfPage) image(name string, x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n",
+		pdfNumber(w), pdfNumber(h), pdfNumber(x), pdfNumber(p.doc.height-y-h), name)
+}
+
+// ============================================================
+// Helpers
+// ============================================================
+
+// pdfNumber — число с точностью до 0.001 pt.
+func pdfNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+func pdfColor(c color.RGBA) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// pdfText — строка информационного словаря в UTF-16BE с BOM.
+func pdfText(s string) string {
+	var b bytes.Buffer
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
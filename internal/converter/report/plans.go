@@ -0,0 +1,129 @@
+package report
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"api-gateway/internal/converter/mapper"
+	"api-gateway/internal/converter/models"
+	"api-gateway/internal/converter/raster"
+)
+
+// ============================================================
+// Plans
+// ============================================================
+
+const maxPlanPixels = 16_000_000 // ограничение растра одного плана
+
+// plans — исходная и измененная планировки на отдельном листе: одна над другой
+// на книжном листе, рядом — на альбомном.
+func (r *report) plans() error {
+	r.newPage()
+	r.heading(r.tmpl.heading(SectionPlans))
+
+	const pad = 6
+	label := r.leading(r.size) + 4
+	gap := r.size
+	avail := r.bottom - r.y
+	var w, h float64
+	sideBySide := r.width() > avail
+	if sideBySide {
+		w, h = (r.width()-gap)/2, avail-label
+	} else {
+		w, h = r.width(), (avail-gap)/2-label
+	}
+
+	// оба плана в одном масштабе, чтобы их можно было сравнивать
+	sheets := make([]planSheet, 2)
+	scale := math.Inf(1)
+	for i, scene := range []*models.Scene{r.in.Original, r.in.Edited} {
+		sheet, err := r.planSheet(scene)
+		if err != nil {
+			return fmt.Errorf("render plan: %w", err)
+		}
+		sheets[i] = sheet
+		scale = min(scale, (w-2*pad)/sheet.region[2], (h-2*pad)/sheet.region[3])
+	}
+
+	for i, title := range []string{"Исходная планировка", "Планировка после изменений"} {
+		x, y := r.left, r.y
+		if sideBySide {
+			x += float64(i) * (w + gap)
+		} else {
+			y += float64(i) * (h + label + gap)
+		}
+		r.page.rect(x, y, w, h, nil, colorLight, 0.75)
+
+		img, err := r.rasterizePlan(sheets[i], scale)
+		if err != nil {
+			return fmt.Errorf("render plan: %w", err)
+		}
+		pw, ph := sheets[i].region[2]*scale, sheets[i].region[3]*scale
+		r.page.image(r.doc.addImage(img), x+(w-pw)/2, y+(h-ph)/2, pw, ph)
+		r.page.text(r.bold, r.size, x+(w-r.bold.measure(title, r.size))/2, y+h+r.size+4, colorText, title)
+	}
+	r.y = r.bottom
+	r.closed = true
+	return nil
+}
+
+// planSheet — SVG плана и фрагмент листа с чертежом, px листа.
+type planSheet struct {
+	svg    string
+	region [4]float64
+}
+
+// planSheet рисует сцену темой и подписями макета и находит границы чертежа по геометрии сцены.
+func (r *report) planSheet(scene *models.Scene) (planSheet, error) {
+	renderer := mapper.NewRenderer()
+	renderer.SetTheme(r.theme)
+	renderer.SetAnnotations(r.tmpl.annotations)
+	svg, err := renderer.Render(scene)
+	if err != nil {
+		return planSheet{}, err
+	}
+	bounds, err := renderer.ContentBounds(scene, "")
+	if err != nil {
+		return planSheet{}, err
+	}
+	region, err := contentRegion(svg, bounds)
+	if err != nil {
+		return planSheet{}, err
+	}
+	return planSheet{svg: svg, region: region}, nil
+}
+
+// rasterizePlan растрирует фрагмент листа в разрешении макета; scale — pt страницы на px листа.
+func (r *report) rasterizePlan(sheet planSheet, scale float64) (image.Image, error) {
+	region := sheet.region
+	dpi := raster.DefaultDPI * scale * r.tmpl.DPI / 72
+	if pixels := region[2] * region[3] * math.Pow(dpi/raster.DefaultDPI, 2); pixels > maxPlanPixels {
+		dpi *= math.Sqrt(maxPlanPixels / pixels)
+	}
+	return raster.Rasterize(strings.NewReader(sheet.svg), raster.Options{
+		DPI:        min(dpi, raster.MaxDPI),
+		Background: "#fff",
+		MaxPixels:  maxPlanPixels,
+		Region:     region,
+	})
+}
+
+// contentRegion — фрагмент листа с чертежом в px листа с полями 2%; bounds — рамка
+// чертежа в координатах viewBox (mapper.Renderer.ContentBounds).
+func contentRegion(svg string, bounds [4]float64) ([4]float64, error) {
+	content, sheet, err := raster.SheetRegion(strings.NewReader(svg), bounds)
+	if err != nil {
+		return [4]float64{}, err
+	}
+	margin := 0.02 * max(sheet[0], sheet[1])
+	x0 := max(0, content[0]-margin)
+	y0 := max(0, content[1]-margin)
+	x1 := min(sheet[0], content[0]+content[2]+margin)
+	y1 := min(sheet[1], content[1]+content[3]+margin)
+	if x1 <= x0 || y1 <= y0 {
+		return [4]float64{0, 0, sheet[0], sheet[1]}, nil
+	}
+	return [4]float64{x0, y0, x1 - x0, y1 - y0}, nil
+}
@@ -0,0 +1,199 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"api-gateway/internal/converter/analysis"
+	"api-gateway/internal/converter/compliance"
+	"api-gateway/internal/converter/diff"
+	"api-gateway/internal/converter/mapper"
+	"api-gateway/internal/converter/models"
+)
+
+// ============================================================
+// Report Generator
+// ============================================================
+
+// Applicant — данные заявителя для титульного листа.
+type Applicant struct {
+	FIO     string
+	Phone   string
+	Email   string
+	Address string
+}
+
+// Input — данные отчета. Changes и Compliance необязательны: без Changes изменения
+// вычисляются diff.Compare, без Compliance раздел норм сообщает, что проверка не выполнялась.
+type Input struct {
+	Original   *models.Scene
+	Edited     *models.Scene
+	FileID     string
+	Applicant  Applicant
+	Date       time.Time // пусто — текущее время
+	Changes    *diff.Report
+	Compliance *compliance.Report
+}
+
+// Generator собирает PDF отчет о перепланировке по макету.
+type Generator struct {
+	template *Template
+	themes   *mapper.Themes
+}
+
+// NewGenerator создает генератор с макетом и темами по умолчанию.
+func NewGenerator() *Generator {
+	return &Generator{
+		template: DefaultTemplate(),
+		themes:   mapper.DefaultThemes(),
+	}
+}
+
+// SetTemplate задает макет отчета.
+func (g *Generator) SetTemplate(t *Template) {
+	if t != nil {
+		g.template = t
+	}
+}
+
+// SetThemes задает набор тем, из которого макет берет тему планов.
+func (g *Generator) SetThemes(themes *mapper.Themes) {
+	if themes != nil {
+		g.themes = themes
+	}
+}
+
+// templateData — поля строковых шаблонов макета.
+type templateData struct {
+	Date      string
+	FileID    string
+	City      string
+	Applicant Applicant
+	Page      int
+	Pages     int
+}
+
+// report — состояние сборки одного отчета.
+type report struct {
+	*layout
+	tmpl    *Template
+	theme   *mapper.Theme
+	in      Input
+	changes *diff.Report
+	before  *analysis.Report
+	after   *analysis.Report
+	data    templateData
+}
+
+// Generate собирает PDF отчет.
+func (g *Generator) Generate(in Input) ([]byte, error) {
+	if in.Original == nil || in.Edited == nil {
+		return nil, fmt.Errorf("both scenes are required")
+	}
+	tmpl := g.template
+	theme, ok := g.themes.Get(tmpl.Theme)
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q", tmpl.Theme)
+	}
+
+	changes := in.Changes
+	if changes == nil {
+		var err error
+		if changes, err = diff.Compare(in.Original, in.Edited, diff.Options{}); err != nil {
+			return nil, fmt.Errorf("compare scenes: %w", err)
+		}
+	}
+	before, err := analysis.Analyze(in.Original, analysis.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("analyze original scene: %w", err)
+	}
+	after, err := analysis.Analyze(in.Edited, analysis.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("analyze edited scene: %w", err)
+	}
+
+	doc := newPDFDocument(tmpl.width, tmpl.height)
+	regular, err := newPDFFont(doc, "GoRegular", goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := newPDFFont(doc, "GoBold", gobold.TTF)
+	if err != nil {
+		return nil, err
+	}
+
+	date := in.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	margin := tmpl.Margin * ptPerMM
+	r := &report{
+		layout: &layout{
+			doc:     doc,
+			regular: regular,
+			bold:    bold,
+			size:    tmpl.FontSize,
+			left:    margin,
+			right:   tmpl.width - margin,
+			top:     margin,
+			// место под колонтитул
+			bottom: tmpl.height - margin - tmpl.FontSize*2,
+		},
+		tmpl:    tmpl,
+		theme:   theme,
+		in:      in,
+		changes: changes,
+		before:  before,
+		after:   after,
+		data: templateData{
+			Date:      date.Format("02.01.2006"),
+			FileID:    in.FileID,
+			Applicant: withPlaceholders(in.Applicant),
+		},
+	}
+	r.data.City = execute(tmpl.city, r.data)
+
+	for _, section := range tmpl.Sections {
+		switch section {
+		case SectionCover:
+			r.cover()
+		case SectionPlans:
+			if err := r.plans(); err != nil {
+				return nil, err
+			}
+		case SectionChanges:
+			r.changeList()
+		case SectionCompliance:
+			r.compliance()
+		case SectionRooms:
+			r.rooms()
+		}
+	}
+	if len(doc.pages) == 0 {
+		r.newPage()
+	}
+
+	r.data.Pages = len(doc.pages)
+	for i, page := range doc.pages {
+		r.data.Page = i + 1
+		footer := execute(tmpl.footer, r.data)
+		size := tmpl.FontSize * 0.75
+		page.text(regular, size, (tmpl.width-regular.measure(footer, size))/2, tmpl.height-margin, colorMuted, footer)
+	}
+	doc.title = execute(tmpl.title, r.data)
+	return doc.bytes(), nil
+}
+
+// withPlaceholders заменяет незаполненные поля заявителя на «Не указано».
+func withPlaceholders(a Applicant) Applicant {
+	for _, field := range []*string{&a.FIO, &a.Phone, &a.Email, &a.Address} {
+		if strings.TrimSpace(*field) == "" {
+			*field = "Не указано"
+		}
+	}
+	return a
+}
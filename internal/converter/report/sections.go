@@ -0,0 +1,252 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"api-gateway/internal/converter/analysis"
+	"api-gateway/internal/converter/compliance"
+	"api-gateway/internal/converter/diff"
+)
+
+// ============================================================
+// Cover
+// ============================================================
+
+// cover — титульный лист: заголовок, дата, данные заявителя, сводка изменений, подпись.
+func (r *report) cover() {
+	r.newPage()
+	r.y += r.size * 2
+	r.textCenter(r.bold, r.size+5, colorRule, execute(r.tmpl.title, r.data))
+	r.y += 4
+	r.page.line(r.left, r.y, r.right, r.y, 2, colorRule)
+	r.y += r.size
+
+	r.textRight(r.bold, r.size, colorText, "Дата: "+r.data.Date)
+	if r.data.City != "" {
+		r.textRight(r.regular, r.size, colorMuted, r.data.City)
+	}
+	if r.in.FileID != "" {
+		r.paragraph(r.regular, r.size, colorMuted, 0, "Планировка: "+r.in.FileID)
+	}
+
+	const labelWidth = 130
+	a := r.data.Applicant
+	r.heading(r.tmpl.heading(SectionCover))
+	r.field("ФИО:", a.FIO, labelWidth)
+	r.field("Телефон:", a.Phone, labelWidth)
+	r.field("Email:", a.Email, labelWidth)
+	r.field("Адрес объекта:", a.Address, labelWidth)
+
+	s := r.changes.Summary
+	r.heading("Сводка изменений")
+	r.field("Стены:", countSummary(s.Walls), labelWidth)
+	r.field("Проемы:", countSummary(s.Holes), labelWidth)
+	r.field("Помещения:", countSummary(s.Rooms), labelWidth)
+	r.field("Общая площадь:", fmt.Sprintf("%s → %s м² (%s м²)",
+		number(s.TotalAreaBefore, 2), number(s.TotalAreaAfter, 2), signed(s.AreaDelta, 2)), labelWidth)
+
+	r.y += r.size * 3
+	r.ensure(r.leading(r.size) * 3)
+	baseline := r.y + r.leading(r.size)
+	r.page.text(r.bold, r.size, r.left, baseline, colorRule, "Заявитель:")
+	signEnd := r.left + labelWidth + (r.width()-labelWidth)*0.4
+	r.page.line(r.left+labelWidth, baseline+2, signEnd, baseline+2, 0.75, colorRule)
+	r.page.line(signEnd+r.size, baseline+2, r.right, baseline+2, 0.75, colorRule)
+	if r.in.Applicant.FIO != "" {
+		r.page.text(r.regular, r.size, signEnd+r.size*1.5, baseline, colorText, r.in.Applicant.FIO)
+	}
+	small := r.size * 0.75
+	r.page.text(r.regular, small, r.left+labelWidth, baseline+2+small*1.4, colorMuted, "(подпись)")
+	r.page.text(r.regular, small, signEnd+r.size, baseline+2+small*1.4, colorMuted, "(расшифровка подписи)")
+	r.closed = true
+}
+
+// changeKinds — виды изменений в порядке вывода сводки.
+var changeKinds = []struct {
+	kind  string
+	label string
+}{
+	{diff.ChangeAdded, "добавлено"},
+	{diff.ChangeRemoved, "удалено"},
+	{diff.ChangeMoved, "перенесено"},
+	{diff.ChangeResized, "изменено"},
+	{diff.ChangeRenamed, "переименовано"},
+	{diff.ChangeMerged, "объединено"},
+	{diff.ChangeSplit, "разделено"},
+}
+
+// countSummary — «добавлено 1, удалено 2» по счетчикам diff.Summary.
+func countSummary(counts map[string]int) string {
+	var parts []string
+	for _, k := range changeKinds {
+		if n := counts[k.kind]; n > 0 {
+			parts = append(parts, k.label+" "+strconv.Itoa(n))
+		}
+	}
+	if len(parts) == 0 {
+		return "без изменений"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ============================================================
+// Changes
+// ============================================================
+
+func (r *report) changeList() {
+	r.flow()
+	r.heading(r.tmpl.heading(SectionChanges))
+	s := r.changes.Summary
+	r.paragraph(r.regular, r.size, colorText, 0, fmt.Sprintf("Общая площадь помещений: было %s м², стало %s м² (%s м²).",
+		number(s.TotalAreaBefore, 2), number(s.TotalAreaAfter, 2), signed(s.AreaDelta, 2)))
+	r.y += r.size * 0.5
+
+	items := describeChanges(r.changes)
+	if len(items) == 0 {
+		r.paragraph(r.regular, r.size, colorMuted, 0, "Изменения в планировке не обнаружены.")
+		return
+	}
+	for _, item := range items {
+		r.bullet(colorText, item)
+	}
+}
+
+// ============================================================
+// Compliance
+// ============================================================
+
+func (r *report) compliance() {
+	r.flow()
+	r.heading(r.tmpl.heading(SectionCompliance))
+	c := r.in.Compliance
+	if c == nil {
+		r.paragraph(r.regular, r.size, colorMuted, 0, "Проверка норм не выполнялась.")
+		return
+	}
+
+	if c.Passed {
+		r.paragraph(r.bold, r.size+1, colorText, 0, "Перепланировка соответствует нормам")
+	} else {
+		r.paragraph(r.bold, r.size+1, colorError, 0, "Перепланировка нарушает нормы")
+	}
+	profile := c.Profile
+	if profile == "" {
+		profile = c.Region
+	}
+	if profile != "" {
+		r.paragraph(r.regular, r.size, colorMuted, 0, "Профиль норм: "+profile)
+	}
+	r.y += r.size * 0.5
+
+	if len(c.Findings) == 0 {
+		r.paragraph(r.regular, r.size, colorMuted, 0, "Нарушений не выявлено.")
+		return
+	}
+	for _, f := range c.Findings {
+		text := f.Message
+		if text == "" {
+			text = f.Rule
+		}
+		col := colorText
+		switch {
+		case f.Existing:
+			text += " (было до перепланировки)"
+			col = colorMuted
+		case f.Severity == compliance.SeverityError:
+			col = colorError
+		}
+		r.bullet(col, text)
+	}
+}
+
+// ============================================================
+// Rooms
+// ============================================================
+
+// roomArea — помещение экспликации: комната или летнее помещение (без коэффициента).
+type roomArea struct {
+	id   string
+	name string
+	area float64
+}
+
+// rooms — экспликация: площади помещений до и после. Помещения сопоставляются по id
+// и по id_before из отчета diff; упраздненные помещения выводятся в конце.
+func (r *report) rooms() {
+	r.flow()
+	r.heading(r.tmpl.heading(SectionRooms))
+
+	before, after := roomAreas(r.before), roomAreas(r.after)
+
+	previous := make(map[string]string)
+	for _, c := range r.changes.Rooms {
+		if c.IDBefore != "" {
+			previous[c.ID] = c.IDBefore
+		}
+	}
+	byID := make(map[string]int, len(before))
+	for i, room := range before {
+		byID[room.id] = i
+	}
+
+	var rows [][]string
+	used := make(map[int]bool)
+	for _, room := range after {
+		i, ok := byID[room.id]
+		if !ok {
+			i, ok = byID[previous[room.id]]
+		}
+		was, delta := "—", signed(room.area, 2)
+		if ok && !used[i] {
+			used[i] = true
+			was = number(before[i].area, 2)
+			delta = signed(room.area-before[i].area, 2)
+		}
+		rows = append(rows, []string{strconv.Itoa(len(rows) + 1), roomName(room), was, number(room.area, 2), delta})
+	}
+	for i, room := range before {
+		if used[i] {
+			continue
+		}
+		rows = append(rows, []string{strconv.Itoa(len(rows) + 1), roomName(room) + " (упразднено)",
+			number(room.area, 2), "—", signed(-room.area, 2)})
+	}
+
+	total := func(label string, was, now float64) []string {
+		return []string{"", label, number(was, 2), number(now, 2), signed(now-was, 2)}
+	}
+	rows = append(rows,
+		total("Общая площадь", r.before.TotalArea, r.after.TotalArea),
+		total("Жилая площадь", r.before.LivingArea, r.after.LivingArea),
+		total("Летние помещения (с коэф.)", r.before.ReducedBalconyArea, r.after.ReducedBalconyArea),
+	)
+
+	r.table([]tableColumn{
+		{title: "№", share: 0.6},
+		{title: "Помещение", share: 4},
+		{title: "Было, м²", share: 1.5, right: true},
+		{title: "Стало, м²", share: 1.5, right: true},
+		{title: "Изменение, м²", share: 1.8, right: true},
+	}, rows, 3)
+}
+
+// roomAreas — комнаты, затем летние помещения экспликации.
+func roomAreas(report *analysis.Report) []roomArea {
+	out := make([]roomArea, 0, len(report.Rooms)+len(report.Balconies))
+	for _, room := range report.Rooms {
+		out = append(out, roomArea{room.ID, room.Name, room.Area})
+	}
+	for _, b := range report.Balconies {
+		out = append(out, roomArea{b.ID, b.Name, b.Area})
+	}
+	return out
+}
+
+func roomName(room roomArea) string {
+	if room.name != "" {
+		return room.name
+	}
+	return room.id
+}
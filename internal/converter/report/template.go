@@ -0,0 +1,167 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"api-gateway/internal/converter/mapper"
+)
+
+// ============================================================
+// Report Template
+// ============================================================
+
+// Разделы отчета.
+const (
+	SectionCover      = "cover"      // титульный лист: заголовок, дата, заявитель, сводка изменений
+	SectionPlans      = "plans"      // планировки до и после
+	SectionChanges    = "changes"    // список изменений
+	SectionCompliance = "compliance" // проверка норм перепланировки
+	SectionRooms      = "rooms"      // экспликация помещений до и после
+)
+
+// pageSizes — форматы листа в мм (книжная ориентация).
+var pageSizes = map[string][2]float64{
+	"A3":     {297, 420},
+	"A4":     {210, 297},
+	"A5":     {148, 210},
+	"LETTER": {215.9, 279.4},
+}
+
+const ptPerMM = 72 / 25.4
+
+// Template — макет отчета. Title, City и Footer — шаблоны text/template с полями .Date, .FileID,
+// .City, .Applicant (FIO, Phone, Email, Address); в Footer также .Page и .Pages.
+type Template struct {
+	PageSize  string            `json:"page_size"` // A3, A4, A5, Letter
+	Landscape bool              `json:"landscape"` // альбомная ориентация
+	Margin    float64           `json:"margin"`    // поля, мм
+	FontSize  float64           `json:"font_size"` // основной текст, pt
+	Title     string            `json:"title"`     // заголовок титульного листа
+	City      string            `json:"city"`      // город под датой
+	Footer    string            `json:"footer"`    // нижний колонтитул каждой страницы
+	Theme     string            `json:"theme"`     // тема оформления планов (см. /render)
+	Annotate  string            `json:"annotate"`  // слои подписей планов, как ?annotate у /render
+	DPI       float64           `json:"dpi"`       // разрешение растра планов
+	Sections  []string          `json:"sections"`  // разделы по порядку
+	Headings  map[string]string `json:"headings"`  // заголовки разделов по ключу раздела
+
+	width, height float64 // размер листа, pt
+	annotations   mapper.Annotations
+	title         *template.Template
+	city          *template.Template
+	footer        *template.Template
+}
+
+// DefaultTemplate — отчет о перепланировке на листах A4.
+func DefaultTemplate() *Template {
+	t := &Template{
+		PageSize: "A4",
+		Margin:   20,
+		FontSize: 11,
+		Title:    "Отчёт об изменениях планировки помещения",
+		City:     "г. Краснодар",
+		Footer:   "Документ сформирован автоматически | {{.Date}} | стр. {{.Page}} из {{.Pages}}",
+		Theme:    "print",
+		Annotate: "exterior,rooms",
+		DPI:      200,
+		Sections: []string{SectionCover, SectionPlans, SectionChanges, SectionCompliance, SectionRooms},
+		Headings: map[string]string{
+			SectionCover:      "Данные заявителя",
+			SectionPlans:      "Сравнение планировок",
+			SectionChanges:    "Описание изменений",
+			SectionCompliance: "Проверка норм перепланировки",
+			SectionRooms:      "Экспликация помещений",
+		},
+	}
+	if err := t.compile(); err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadTemplate читает макет из JSON файла.
+func LoadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(data)
+}
+
+// ParseTemplate разбирает JSON макета; незаданные поля и заголовки берутся из DefaultTemplate.
+func ParseTemplate(data []byte) (*Template, error) {
+	t := DefaultTemplate()
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("decode report template: %w", err)
+	}
+	if err := t.compile(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// compile проверяет макет и разбирает шаблоны строк.
+func (t *Template) compile() error {
+	size, ok := pageSizes[strings.ToUpper(t.PageSize)]
+	if !ok {
+		return fmt.Errorf("unknown page size %q", t.PageSize)
+	}
+	t.width, t.height = size[0]*ptPerMM, size[1]*ptPerMM
+	if t.Landscape {
+		t.width, t.height = t.height, t.width
+	}
+	if t.Margin < 0 || t.Margin*2 >= size[0] {
+		return fmt.Errorf("margin must be in [0, %s) mm", pdfNumber(size[0]/2))
+	}
+	if t.FontSize <= 0 || t.DPI <= 0 {
+		return fmt.Errorf("font_size and dpi must be positive")
+	}
+	annotations, err := mapper.ParseAnnotations(t.Annotate)
+	if err != nil {
+		return err
+	}
+	t.annotations = annotations
+
+	for _, section := range t.Sections {
+		switch section {
+		case SectionCover, SectionPlans, SectionChanges, SectionCompliance, SectionRooms:
+		default:
+			return fmt.Errorf("unknown section %q", section)
+		}
+	}
+
+	for _, field := range []struct {
+		name string
+		text string
+		dst  **template.Template
+	}{
+		{"title", t.Title, &t.title},
+		{"city", t.City, &t.city},
+		{"footer", t.Footer, &t.footer},
+	} {
+		parsed, err := template.New(field.name).Option("missingkey=zero").Parse(field.text)
+		if err != nil {
+			return fmt.Errorf("template %s: %w", field.name, err)
+		}
+		*field.dst = parsed
+	}
+	return nil
+}
+
+// heading — заголовок раздела.
+func (t *Template) heading(section string) string {
+	return t.Headings[section]
+}
+
+// execute подставляет данные в шаблон строки; ошибка подстановки оставляет строку пустой.
+func execute(tmpl *template.Template, data any) string {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return ""
+	}
+	return b.String()
+}